@@ -0,0 +1,260 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrBase64StreamConsumed is returned when a request built from a plain
+// io.Reader has to be sent again, e.g. on a retry or redirect.
+var ErrBase64StreamConsumed = errors.New("base64 stream reader was already consumed")
+
+var base64StreamIDs atomic.Uint64
+
+// Base64Stream is a MessageContentSource.Data value whose content is read
+// and base64-encoded while the request body is written, so large documents
+// and images never sit in memory as a whole.
+//
+// Only the Client knows how to expand a Base64Stream. Marshaling it with
+// encoding/json yields a placeholder string, not the encoded content.
+type Base64Stream struct {
+	id   uint64
+	open func() (io.ReadCloser, error)
+	// size reports the unencoded length, if it is known without reading.
+	size func() (int64, bool)
+}
+
+func newBase64Stream(
+	open func() (io.ReadCloser, error),
+	size func() (int64, bool),
+) *Base64Stream {
+	return &Base64Stream{id: base64StreamIDs.Add(1), open: open, size: size}
+}
+
+// encodedSize returns the length of the base64 encoding, if it is known.
+func (s *Base64Stream) encodedSize() (int64, bool) {
+	n, ok := s.size()
+	if !ok {
+		return 0, false
+	}
+	return int64(base64.StdEncoding.EncodedLen(int(n))), true
+}
+
+// NewBase64ReaderStream streams r. If r is an io.Seeker it is rewound for
+// every attempt, so retries work; otherwise it can only be sent once.
+func NewBase64ReaderStream(r io.Reader) *Base64Stream {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		var once sync.Once
+		return newBase64Stream(func() (io.ReadCloser, error) {
+			err := ErrBase64StreamConsumed
+			once.Do(func() { err = nil })
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(r), nil
+		}, func() (int64, bool) { return 0, false })
+	}
+
+	var (
+		start    int64
+		startErr error
+		once     sync.Once
+	)
+	return newBase64Stream(func() (io.ReadCloser, error) {
+		once.Do(func() { start, startErr = seeker.Seek(0, io.SeekCurrent) })
+		if startErr != nil {
+			return nil, startErr
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(r), nil
+	}, func() (int64, bool) {
+		once.Do(func() { start, startErr = seeker.Seek(0, io.SeekCurrent) })
+		if startErr != nil {
+			return 0, false
+		}
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, false
+		}
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return 0, false
+		}
+		return end - start, true
+	})
+}
+
+// NewBase64FileStream streams the file at path, opening it for every attempt.
+func NewBase64FileStream(path string) *Base64Stream {
+	return newBase64Stream(func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}, func() (int64, bool) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return 0, false
+		}
+		return info.Size(), true
+	})
+}
+
+// NewBase64ReaderSource returns a base64 source whose data is streamed from r.
+func NewBase64ReaderSource(mediaType string, r io.Reader) MessageContentSource {
+	return NewMessageContentSource(
+		MessagesContentSourceTypeBase64,
+		mediaType,
+		NewBase64ReaderStream(r),
+	)
+}
+
+// NewBase64FileSource returns a base64 source whose data is streamed from the
+// file at path.
+func NewBase64FileSource(mediaType, path string) MessageContentSource {
+	return NewMessageContentSource(
+		MessagesContentSourceTypeBase64,
+		mediaType,
+		NewBase64FileStream(path),
+	)
+}
+
+const base64StreamPlaceholder = `\u0000base64-stream:`
+
+var base64StreamPattern = regexp.MustCompile(`"\\u0000base64-stream:(\d+)\\u0000"`)
+
+func (s *Base64Stream) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + base64StreamPlaceholder + strconv.FormatUint(s.id, 10) + `\u0000"`), nil
+}
+
+// streamedBody returns a GetBody func that writes the marshaled body with
+// every Base64Stream placeholder replaced by the encoded stream. It returns
+// nil if the body has no streams.
+func streamedBody(body any, marshaled []byte) (func() (io.ReadCloser, error), error) {
+	if !bytes.Contains(marshaled, []byte(base64StreamPlaceholder)) {
+		return nil, nil
+	}
+
+	streams := make(map[uint64]*Base64Stream)
+	collectBase64Streams(reflect.ValueOf(body), streams, make(map[visitedPointer]bool))
+
+	matches := base64StreamPattern.FindAllSubmatchIndex(marshaled, -1)
+	for _, m := range matches {
+		id, _ := strconv.ParseUint(string(marshaled[m[2]:m[3]]), 10, 64)
+		if streams[id] == nil {
+			return nil, fmt.Errorf("error, base64 stream %d not found in the request", id)
+		}
+	}
+
+	return func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeStreamedBody(pw, marshaled, matches, streams))
+		}()
+		return pr, nil
+	}, nil
+}
+
+func writeStreamedBody(
+	w io.Writer,
+	marshaled []byte,
+	matches [][]int,
+	streams map[uint64]*Base64Stream,
+) error {
+	last := 0
+	for _, m := range matches {
+		if _, err := w.Write(marshaled[last:m[0]]); err != nil {
+			return err
+		}
+		id, _ := strconv.ParseUint(string(marshaled[m[2]:m[3]]), 10, 64)
+		if err := writeBase64Stream(w, streams[id]); err != nil {
+			return err
+		}
+		last = m[1]
+	}
+	_, err := w.Write(marshaled[last:])
+	return err
+}
+
+func writeBase64Stream(w io.Writer, stream *Base64Stream) error {
+	r, err := stream.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if _, err := io.WriteString(w, `"`); err != nil {
+		return err
+	}
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return fmt.Errorf("error, reading base64 stream: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, `"`)
+	return err
+}
+
+var base64StreamType = reflect.TypeOf((*Base64Stream)(nil))
+
+type visitedPointer struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// collectBase64Streams walks the exported fields reachable from v, which is
+// what encoding/json marshals, and records every Base64Stream.
+func collectBase64Streams(
+	v reflect.Value,
+	streams map[uint64]*Base64Stream,
+	seen map[visitedPointer]bool,
+) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			collectBase64Streams(v.Elem(), streams, seen)
+		}
+	case reflect.Pointer:
+		key := visitedPointer{v.Type(), v.Pointer()}
+		if v.IsNil() || seen[key] {
+			return
+		}
+		seen[key] = true
+		if v.Type() == base64StreamType {
+			if v.CanInterface() {
+				s := v.Interface().(*Base64Stream)
+				streams[s.id] = s
+			}
+			return
+		}
+		collectBase64Streams(v.Elem(), streams, seen)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() || f.Anonymous {
+				collectBase64Streams(v.Field(i), streams, seen)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			collectBase64Streams(v.Index(i), streams, seen)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectBase64Streams(iter.Value(), streams, seen)
+		}
+	}
+}
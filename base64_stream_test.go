@@ -0,0 +1,171 @@
+package anthropic_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+// replayTransport reads every request body once and then sends a replay
+// obtained from GetBody, as the http package does for retries and redirects.
+type replayTransport struct {
+	chunked bool
+}
+
+func (rt *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_, _ = io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+		rt.chunked = req.ContentLength == -1
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestBase64StreamRequest(t *testing.T) {
+	content := bytes.Repeat([]byte("%PDF-1.7 streamed document "), 10_000)
+	path := filepath.Join(t.TempDir(), "large.pdf")
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var received []anthropic.MessageContentSource
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					Source anthropic.MessageContentSource `json:"source"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		received = nil
+		for _, c := range req.Messages[0].Content {
+			received = append(received, c.Source)
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+			`"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	transport := &replayTransport{}
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	request := func(sources ...anthropic.MessageContentSource) anthropic.MessagesRequest {
+		var content []anthropic.MessageContent
+		for _, s := range sources {
+			content = append(content, anthropic.NewDocumentMessageContent(s, "", "", false))
+		}
+		return anthropic.MessagesRequest{
+			Model:     anthropic.ModelClaudeSonnet4Dot5,
+			Messages:  []anthropic.Message{{Role: anthropic.RoleUser, Content: content}},
+			MaxTokens: 100,
+		}
+	}
+
+	t.Run("file and seekable reader are replayed", func(t *testing.T) {
+		_, err := client.CreateMessages(context.Background(), request(
+			anthropic.NewBase64FileSource("application/pdf", path),
+			anthropic.NewBase64ReaderSource("text/plain", strings.NewReader("plain text")),
+		))
+		if err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		if !transport.chunked || len(received) != 2 {
+			t.Fatalf("unexpected request: chunked %v, sources %+v", transport.chunked, received)
+		}
+		if received[0].Data != base64.StdEncoding.EncodeToString(content) ||
+			received[0].MediaType != "application/pdf" {
+			t.Fatal("file content was not streamed")
+		}
+		if received[1].Data != base64.StdEncoding.EncodeToString([]byte("plain text")) {
+			t.Fatalf("unexpected reader content %v", received[1].Data)
+		}
+	})
+
+	t.Run("plain reader cannot be replayed", func(t *testing.T) {
+		r := io.MultiReader(strings.NewReader("once"))
+		_, err := client.CreateMessages(context.Background(), request(
+			anthropic.NewBase64ReaderSource("text/plain", r),
+		))
+		if !errors.Is(err, anthropic.ErrBase64StreamConsumed) {
+			t.Fatalf("expected ErrBase64StreamConsumed, got %v", err)
+		}
+	})
+
+	t.Run("plain reader sent once", func(t *testing.T) {
+		client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+		r := io.MultiReader(strings.NewReader("once"))
+		_, err := client.CreateMessages(context.Background(), request(
+			anthropic.NewBase64ReaderSource("text/plain", r),
+		))
+		if err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		if received[0].Data != base64.StdEncoding.EncodeToString([]byte("once")) {
+			t.Fatalf("unexpected reader content %v", received[0].Data)
+		}
+	})
+}
+
+type failingAdapter struct {
+	anthropic.DefaultAdapter
+}
+
+func (*failingAdapter) SetRequestHeaders(*anthropic.Client, *http.Request) error {
+	return errors.New("no credentials")
+}
+
+func TestBase64StreamNotStartedOnRequestError(t *testing.T) {
+	client := anthropic.NewClient(test.GetTestToken(), func(c *anthropic.ClientConfig) {
+		c.Adapter = &failingAdapter{}
+	})
+	request := anthropic.MessagesRequest{
+		Model: anthropic.ModelClaudeSonnet4Dot5,
+		Messages: []anthropic.Message{{
+			Role: anthropic.RoleUser,
+			Content: []anthropic.MessageContent{anthropic.NewDocumentMessageContent(
+				anthropic.NewBase64ReaderSource("text/plain", strings.NewReader("hello")),
+				"", "", false,
+			)},
+		}},
+		MaxTokens: 100,
+	}
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 20; i++ {
+		if _, err := client.CreateMessages(context.Background(), request); err == nil {
+			t.Fatal("expected the adapter error")
+		}
+	}
+	if after := runtime.NumGoroutine(); after >= before+10 {
+		t.Fatalf("encoder goroutines leaked: %d before, %d after", before, after)
+	}
+}
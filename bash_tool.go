@@ -0,0 +1,382 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	defaultBashTimeout        = 120 * time.Second
+	defaultBashMaxOutputBytes = 16000
+
+	toolOutputClippedNotice = "<response clipped><NOTE>To save on context only part of this " +
+		"output has been shown to you.</NOTE>"
+)
+
+var ErrBashSessionExited = errors.New("bash has exited and must be restarted")
+
+// BashToolInput is the input of the Anthropic-defined bash tool.
+type BashToolInput struct {
+	Command string `json:"command,omitempty"`
+	Restart bool   `json:"restart,omitempty"`
+}
+
+// BashSession is a reference executor for the bash tool. It keeps a single
+// bash process alive across calls, so working directory and environment
+// changes persist between commands, as the model expects.
+type BashSession struct {
+	shell     string
+	dir       string
+	env       []string
+	timeout   time.Duration
+	maxOutput int
+
+	runMu sync.Mutex
+
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	output  bytes.Buffer
+	changed chan struct{}
+	exited  bool
+	// broken is set after a timeout: the shell may still be running the
+	// command, so its output can no longer be trusted until a restart.
+	broken bool
+}
+
+type BashSessionOption func(s *BashSession)
+
+// WithBashShell sets the shell binary, "bash" by default.
+func WithBashShell(shell string) BashSessionOption {
+	return func(s *BashSession) {
+		s.shell = shell
+	}
+}
+
+// WithBashDir sets the initial working directory of the session.
+func WithBashDir(dir string) BashSessionOption {
+	return func(s *BashSession) {
+		s.dir = dir
+	}
+}
+
+// WithBashEnv sets the environment of the session, os.Environ() by default.
+func WithBashEnv(env []string) BashSessionOption {
+	return func(s *BashSession) {
+		s.env = env
+	}
+}
+
+// WithBashTimeout bounds how long a single command may run.
+func WithBashTimeout(timeout time.Duration) BashSessionOption {
+	return func(s *BashSession) {
+		s.timeout = timeout
+	}
+}
+
+// WithBashMaxOutput truncates command output to the given number of bytes.
+func WithBashMaxOutput(maxBytes int) BashSessionOption {
+	return func(s *BashSession) {
+		s.maxOutput = maxBytes
+	}
+}
+
+// NewBashSession creates a session. The shell process is started lazily on
+// the first command.
+func NewBashSession(opts ...BashSessionOption) *BashSession {
+	s := &BashSession{
+		shell:     "bash",
+		timeout:   defaultBashTimeout,
+		maxOutput: defaultBashMaxOutputBytes,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Run executes a command in the session and returns its combined stdout and
+// stderr.
+func (s *BashSession) Run(ctx context.Context, command string) (string, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	if s.cmd == nil {
+		if err := s.start(); err != nil {
+			s.mu.Unlock()
+			return "", err
+		}
+	}
+	if s.broken {
+		s.mu.Unlock()
+		return "", fmt.Errorf(
+			"timed out: bash has not returned in %s and must be restarted",
+			s.timeout,
+		)
+	}
+	if s.exited {
+		s.mu.Unlock()
+		return "", ErrBashSessionExited
+	}
+	s.output.Reset()
+	s.mu.Unlock()
+
+	sentinel, err := newBashSentinel()
+	if err != nil {
+		return "", err
+	}
+
+	script := fmt.Sprintf("%s\nprintf '\\n%s\\n'\n", command, sentinel)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		return "", fmt.Errorf("error, writing to bash: %w", err)
+	}
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	marker := []byte("\n" + sentinel + "\n")
+	for {
+		s.mu.Lock()
+		if i := bytes.Index(s.output.Bytes(), marker); i >= 0 {
+			out := string(s.output.Bytes()[:i])
+			s.output.Reset()
+			s.mu.Unlock()
+			return truncateToolOutput(out, s.maxOutput), nil
+		}
+		if s.exited {
+			out := s.output.String()
+			s.mu.Unlock()
+			return truncateToolOutput(out, s.maxOutput), ErrBashSessionExited
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.broken = true
+			s.mu.Unlock()
+			return "", fmt.Errorf(
+				"timed out: bash has not returned in %s and must be restarted",
+				s.timeout,
+			)
+		}
+	}
+}
+
+// Restart kills the current shell, if any, and starts a fresh one.
+func (s *BashSession) Restart() error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stop()
+	return s.start()
+}
+
+// Close kills the shell process.
+func (s *BashSession) Close() error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stop()
+	return nil
+}
+
+// Execute runs a decoded bash tool input and returns the text for its
+// tool_result.
+func (s *BashSession) Execute(ctx context.Context, input BashToolInput) (string, error) {
+	if input.Restart {
+		if err := s.Restart(); err != nil {
+			return "", err
+		}
+		return "tool has been restarted.", nil
+	}
+	if input.Command == "" {
+		return "", errors.New("no command provided.")
+	}
+
+	out, err := s.Run(ctx, input.Command)
+	if err != nil {
+		if out != "" {
+			return "", fmt.Errorf("%s\n%w", out, err)
+		}
+		return "", err
+	}
+	return out, nil
+}
+
+// HandleToolUse executes a bash tool_use block and returns its tool_result.
+func (s *BashSession) HandleToolUse(
+	ctx context.Context,
+	toolUse MessageContentToolUse,
+) MessageContent {
+	return textToolResult(toolUse, func(input json.RawMessage) (string, error) {
+		var in BashToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return s.Execute(ctx, in)
+	})
+}
+
+// ToolFunc adapts the session to a ToolFunc for use with a ToolExecutor.
+func (s *BashSession) ToolFunc() ToolFunc {
+	return textToolFunc(func(ctx context.Context, input json.RawMessage) (string, error) {
+		var in BashToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return s.Execute(ctx, in)
+	})
+}
+
+// start launches the shell. s.mu must be held.
+func (s *BashSession) start() error {
+	cmd := exec.Command(s.shell, "--noprofile", "--norc")
+	cmd.Dir = s.dir
+	cmd.Env = s.env
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	// stdout and stderr share one pipe so their output keeps its ordering.
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return fmt.Errorf("error, starting bash: %w", err)
+	}
+	w.Close()
+
+	s.cmd = cmd
+	s.stdin = stdin
+	s.output.Reset()
+	s.changed = make(chan struct{})
+	s.exited = false
+	s.broken = false
+
+	go s.readLoop(cmd, r)
+	return nil
+}
+
+// stop kills the shell. s.mu must be held.
+func (s *BashSession) stop() {
+	if s.cmd == nil {
+		return
+	}
+	_ = s.stdin.Close()
+	_ = s.cmd.Process.Kill()
+	s.cmd = nil
+}
+
+func (s *BashSession) readLoop(cmd *exec.Cmd, r io.ReadCloser) {
+	defer r.Close()
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+
+		s.mu.Lock()
+		current := s.cmd == cmd
+		if current && n > 0 {
+			s.output.Write(buf[:n])
+		}
+		if current && err != nil {
+			s.exited = true
+		}
+		if current {
+			close(s.changed)
+			s.changed = make(chan struct{})
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			_ = cmd.Wait()
+			return
+		}
+	}
+}
+
+func newBashSentinel() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "__go_anthropic_done_" + hex.EncodeToString(b) + "__", nil
+}
+
+func truncateToolOutput(s string, maxBytes int) string {
+	if maxBytes <= 0 || len(s) <= maxBytes {
+		return s
+	}
+	return clipUTF8(s, maxBytes) + "\n" + toolOutputClippedNotice
+}
+
+// clipUTF8 cuts s to at most n bytes, backing off to the start of a
+// character so that the result stays valid UTF-8. n must be less than len(s).
+func clipUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// textToolResult runs fn on the tool input and wraps its text, or its error,
+// in a tool_result block.
+func textToolResult(
+	toolUse MessageContentToolUse,
+	fn func(input json.RawMessage) (string, error),
+) MessageContent {
+	out, err := fn(toolUse.Input)
+	if err != nil {
+		return NewToolResultMessageContent(toolUse.ID, err.Error(), true)
+	}
+	return NewToolResultMessageContent(toolUse.ID, out, false)
+}
+
+func textToolFunc(
+	fn func(ctx context.Context, input json.RawMessage) (string, error),
+) ToolFunc {
+	return func(ctx context.Context, toolUse MessageContentToolUse) ([]MessageContent, error) {
+		out, err := fn(ctx, toolUse.Input)
+		if err != nil {
+			return nil, err
+		}
+		return []MessageContent{NewTextMessageContent(out)}, nil
+	}
+}
@@ -0,0 +1,148 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestBashSession(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not available")
+	}
+
+	ctx := context.Background()
+
+	t.Run("keeps state across commands", func(t *testing.T) {
+		session := anthropic.NewBashSession(anthropic.WithBashDir(t.TempDir()))
+		defer session.Close()
+
+		if _, err := session.Run(ctx, "export GREETING=hello && mkdir sub && cd sub"); err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		out, err := session.Run(ctx, `echo "$GREETING from $(basename "$PWD")"`)
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if out != "hello from sub\n" {
+			t.Fatalf("got %q, want %q", out, "hello from sub\n")
+		}
+	})
+
+	t.Run("captures stderr", func(t *testing.T) {
+		session := anthropic.NewBashSession()
+		defer session.Close()
+
+		out, err := session.Run(ctx, "echo out; echo err 1>&2")
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if out != "out\nerr\n" {
+			t.Fatalf("got %q", out)
+		}
+	})
+
+	t.Run("truncates long output", func(t *testing.T) {
+		session := anthropic.NewBashSession(anthropic.WithBashMaxOutput(10))
+		defer session.Close()
+
+		out, err := session.Run(ctx, "printf 'a%.0s' $(seq 1 100)")
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if !strings.HasPrefix(out, "aaaaaaaaaa\n<response clipped>") {
+			t.Fatalf("unexpected output %q", out)
+		}
+
+		// The limit falls inside the fifth é: the cut backs off before it.
+		out, err = session.Run(ctx, "printf 'aéééééé'")
+		if err != nil {
+			t.Fatalf("Run error: %v", err)
+		}
+		if !utf8.ValidString(out) || !strings.HasPrefix(out, "aéééé\n<response clipped>") {
+			t.Fatalf("unexpected output %q", out)
+		}
+	})
+
+	t.Run("times out and restarts", func(t *testing.T) {
+		session := anthropic.NewBashSession(anthropic.WithBashTimeout(50 * time.Millisecond))
+		defer session.Close()
+
+		_, err := session.Run(ctx, "sleep 5")
+		if err == nil || !strings.Contains(err.Error(), "must be restarted") {
+			t.Fatalf("expected timeout error, got %v", err)
+		}
+		if _, err := session.Run(ctx, "echo again"); err == nil {
+			t.Fatalf("expected the session to stay broken until restart")
+		}
+
+		out, err := session.Execute(ctx, anthropic.BashToolInput{Restart: true})
+		if err != nil || out != "tool has been restarted." {
+			t.Fatalf("unexpected restart result %q, %v", out, err)
+		}
+		out, err = session.Run(ctx, "echo again")
+		if err != nil || out != "again\n" {
+			t.Fatalf("unexpected output %q, %v", out, err)
+		}
+	})
+
+	t.Run("reports exited shell", func(t *testing.T) {
+		session := anthropic.NewBashSession()
+		defer session.Close()
+
+		if _, err := session.Run(ctx, "exit 3"); err == nil {
+			t.Fatalf("expected an error after the shell exited")
+		}
+		if _, err := session.Run(ctx, "echo hi"); err != anthropic.ErrBashSessionExited {
+			t.Fatalf("expected ErrBashSessionExited, got %v", err)
+		}
+	})
+
+	t.Run("handles tool use blocks", func(t *testing.T) {
+		session := anthropic.NewBashSession()
+		defer session.Close()
+
+		result := session.HandleToolUse(ctx, *anthropic.NewMessageContentToolUse(
+			"toolu_1",
+			anthropic.ToolNameBash,
+			json.RawMessage(`{"command":"echo tool"}`),
+		))
+		if *result.IsError {
+			t.Fatalf("unexpected error result")
+		}
+		if got := result.MessageContentToolResult.Content[0].GetText(); got != "tool\n" {
+			t.Fatalf("got %q, want %q", got, "tool\n")
+		}
+
+		result = session.HandleToolUse(ctx, *anthropic.NewMessageContentToolUse(
+			"toolu_2",
+			anthropic.ToolNameBash,
+			json.RawMessage(`{}`),
+		))
+		if !*result.IsError {
+			t.Fatalf("expected an error result for a missing command")
+		}
+	})
+}
+
+func TestBashToolDefinitions(t *testing.T) {
+	legacy := anthropic.NewBashToolDefinition("bash")
+	if legacy.Type != "bash_20241022" {
+		t.Fatalf("unexpected type %s", legacy.Type)
+	}
+
+	def := anthropic.NewBash20250124ToolDefinition()
+	b, err := json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"bash","type":"bash_20250124"}` {
+		t.Fatalf("unexpected definition %s", b)
+	}
+}
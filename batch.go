@@ -78,7 +78,11 @@ type RequestCounts struct {
 func (c *Client) CreateBatch(
 	ctx context.Context,
 	request BatchRequest,
+	opts ...RequestOption,
 ) (*BatchResponse, error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
@@ -99,7 +103,11 @@ func (c *Client) CreateBatch(
 func (c *Client) RetrieveBatch(
 	ctx context.Context,
 	batchId BatchId,
+	opts ...RequestOption,
 ) (*BatchResponse, error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
@@ -142,7 +150,11 @@ type RetrieveBatchResultsResponse struct {
 func (c *Client) RetrieveBatchResults(
 	ctx context.Context,
 	batchId BatchId,
+	opts ...RequestOption,
 ) (*RetrieveBatchResultsResponse, error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
@@ -222,7 +234,11 @@ func (l ListBatchesRequest) validate() error {
 func (c *Client) ListBatches(
 	ctx context.Context,
 	lBatchReq ListBatchesRequest,
+	opts ...RequestOption,
 ) (*ListBatchesResponse, error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
@@ -263,7 +279,11 @@ func (c *Client) ListBatches(
 func (c *Client) CancelBatch(
 	ctx context.Context,
 	batchId BatchId,
+	opts ...RequestOption,
 ) (*BatchResponse, error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
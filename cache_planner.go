@@ -0,0 +1,511 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// MaxCacheBreakpoints is the number of cache_control breakpoints the API
+// accepts in one request.
+const MaxCacheBreakpoints = 4
+
+// ErrInvalidCacheControl is returned by ValidateCacheControl.
+var ErrInvalidCacheControl = errors.New("invalid cache_control")
+
+type CacheLocation string
+
+const (
+	CacheLocationTools    CacheLocation = "tools"
+	CacheLocationSystem   CacheLocation = "system"
+	CacheLocationMessages CacheLocation = "messages"
+)
+
+// CacheBreakpoint is a cache_control placed by PlanCacheBreakpoints.
+type CacheBreakpoint struct {
+	Location CacheLocation
+	// Index is the index of the tool, system part or message.
+	Index int
+	// Content is the index of the block within the message.
+	Content int
+	TTL     CacheControlTTL
+	// PrefixTokens estimates the tokens cached by the breakpoint: everything
+	// up to and including its block.
+	PrefixTokens int
+	// Reason explains why the breakpoint was placed.
+	Reason string
+}
+
+// CachePlan explains the choices of PlanCacheBreakpoints.
+type CachePlan struct {
+	// Breakpoints are in prompt order: tools, system, then messages.
+	Breakpoints []CacheBreakpoint
+	// Skipped explains each candidate position that got no breakpoint.
+	Skipped []string
+	// Removed is the number of breakpoints already in the request that the
+	// plan replaced.
+	Removed int
+	// Kept is the number of breakpoints in tool_result content that the plan
+	// left in place. They count towards MaxCacheBreakpoints.
+	Kept int
+}
+
+func (p CachePlan) String() string {
+	var b strings.Builder
+	for _, bp := range p.Breakpoints {
+		ttl := bp.TTL
+		if ttl == "" {
+			ttl = CacheControlTTL5m
+		}
+		fmt.Fprintf(&b, "%s[%d]", bp.Location, bp.Index)
+		if bp.Location == CacheLocationMessages {
+			fmt.Fprintf(&b, ".content[%d]", bp.Content)
+		}
+		fmt.Fprintf(&b, " ttl=%s ~%d tokens: %s\n", ttl, bp.PrefixTokens, bp.Reason)
+	}
+	for _, s := range p.Skipped {
+		fmt.Fprintf(&b, "skipped: %s\n", s)
+	}
+	if p.Removed > 0 {
+		fmt.Fprintf(&b, "replaced %d existing breakpoint(s)\n", p.Removed)
+	}
+	if p.Kept > 0 {
+		fmt.Fprintf(&b, "kept %d breakpoint(s) in tool results\n", p.Kept)
+	}
+	return b.String()
+}
+
+type cachePlanner struct {
+	ttl            CacheControlTTL
+	prefixTTL      CacheControlTTL
+	maxBreakpoints int
+	minTokens      int
+}
+
+type CachePlanOption func(*cachePlanner)
+
+// WithCacheTTL sets the TTL of the breakpoints placed in the messages. The
+// default is the API default of 5 minutes.
+func WithCacheTTL(ttl CacheControlTTL) CachePlanOption {
+	return func(p *cachePlanner) {
+		p.ttl = ttl
+	}
+}
+
+// WithCachePrefixTTL sets the TTL of the breakpoints placed on the tools and
+// the system prompt, which usually change less often than the messages. It
+// defaults to the WithCacheTTL value, and must not be shorter.
+func WithCachePrefixTTL(ttl CacheControlTTL) CachePlanOption {
+	return func(p *cachePlanner) {
+		p.prefixTTL = ttl
+	}
+}
+
+// WithCacheMaxBreakpoints limits the breakpoints the plan places, e.g. to
+// leave room for breakpoints added by hand afterwards.
+func WithCacheMaxBreakpoints(n int) CachePlanOption {
+	return func(p *cachePlanner) {
+		p.maxBreakpoints = n
+	}
+}
+
+// WithCacheMinTokens overrides the minimum cacheable prefix length, which
+// otherwise depends on the request's model.
+func WithCacheMinTokens(tokens int) CachePlanOption {
+	return func(p *cachePlanner) {
+		p.minTokens = tokens
+	}
+}
+
+type cacheCandidate struct {
+	CacheBreakpoint
+	name string
+}
+
+// PlanCacheBreakpoints returns a copy of request with cache_control
+// breakpoints placed for the stable prefixes of a conversation: the end of
+// the conversation so far, the system prompt, the previous user turn and
+// the tools, in that order of priority. Breakpoints already in the request
+// are replaced, except inside tool_result content. Prefixes shorter than
+// the model's minimum cacheable length are skipped, as are positions whose
+// TTL would conflict with a kept breakpoint. The result is checked with
+// ValidateCacheControl.
+//
+// Token counts are estimated locally, see EstimateTokens.
+func PlanCacheBreakpoints(
+	request MessagesRequest,
+	opts ...CachePlanOption,
+) (MessagesRequest, CachePlan, error) {
+	original := request
+	p := cachePlanner{maxBreakpoints: MaxCacheBreakpoints}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	if p.prefixTTL == "" {
+		p.prefixTTL = p.ttl
+	}
+	if p.minTokens <= 0 {
+		p.minTokens = request.Model.minCacheableTokens()
+	}
+	if p.maxBreakpoints > MaxCacheBreakpoints {
+		return request, CachePlan{}, fmt.Errorf("%w: at most %d breakpoints are allowed",
+			ErrInvalidCacheControl, MaxCacheBreakpoints)
+	}
+	if cacheTTLRank(p.prefixTTL) < cacheTTLRank(p.ttl) {
+		return request, CachePlan{}, fmt.Errorf("%w: a %s TTL cannot precede a %s TTL",
+			ErrInvalidCacheControl, p.prefixTTL, p.ttl)
+	}
+
+	var plan CachePlan
+	request, plan.Removed = stripCacheControl(request)
+	if request.System != "" && len(request.MultiSystem) == 0 {
+		request.MultiSystem = []MessageSystemPart{NewSystemMessagePart(request.System)}
+		request.System = ""
+	}
+
+	kept := keptCacheMarks(request)
+	plan.Kept = len(kept)
+	budget := min(p.maxBreakpoints, MaxCacheBreakpoints-len(kept))
+
+	candidates := planCacheCandidates(request, p)
+	for _, c := range candidates {
+		conflict, conflicts := c.ttlConflict(kept)
+		switch {
+		case len(plan.Breakpoints) >= budget:
+			plan.Skipped = append(plan.Skipped, c.name+": breakpoint limit reached")
+		case c.PrefixTokens < p.minTokens:
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf(
+				"%s: ~%d tokens is below the %d token minimum",
+				c.name, c.PrefixTokens, p.minTokens,
+			))
+		case conflicts:
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf(
+				"%s: its TTL is out of order with the breakpoint kept at %s",
+				c.name, conflict,
+			))
+		default:
+			plan.Breakpoints = append(plan.Breakpoints, c.CacheBreakpoint)
+		}
+	}
+	sort.SliceStable(plan.Breakpoints, func(i, j int) bool {
+		return plan.Breakpoints[i].PrefixTokens < plan.Breakpoints[j].PrefixTokens
+	})
+
+	for _, bp := range plan.Breakpoints {
+		control := &MessageCacheControl{Type: CacheControlTypeEphemeral, TTL: bp.TTL}
+		switch bp.Location {
+		case CacheLocationTools:
+			request.Tools[bp.Index].CacheControl = control
+		case CacheLocationSystem:
+			request.MultiSystem[bp.Index].CacheControl = control
+		case CacheLocationMessages:
+			message := request.Messages[bp.Index]
+			message.Content = append([]MessageContent(nil), message.Content...)
+			message.Content[bp.Content].CacheControl = control
+			request.Messages[bp.Index] = message
+		}
+	}
+	if err := ValidateCacheControl(request); err != nil {
+		return original, CachePlan{}, err
+	}
+	return request, plan, nil
+}
+
+// cacheMark is a breakpoint at a position in prompt order.
+type cacheMark struct {
+	where string
+	pos   []int
+	ttl   CacheControlTTL
+}
+
+// keptCacheMarks returns the breakpoints in tool_result content, which
+// stripCacheControl keeps.
+func keptCacheMarks(request MessagesRequest) []cacheMark {
+	var marks []cacheMark
+	var walk func(where string, pos []int, content []MessageContent)
+	walk = func(where string, pos []int, content []MessageContent) {
+		for k, c := range content {
+			at := fmt.Sprintf("%s.content[%d]", where, k)
+			atPos := append(slices.Clip(pos), k)
+			if c.CacheControl != nil {
+				marks = append(marks, cacheMark{at, atPos, c.CacheControl.TTL})
+			}
+			if c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil {
+				walk(at, atPos, c.MessageContentToolResult.Content)
+			}
+		}
+	}
+	for i, m := range request.Messages {
+		for j, c := range m.Content {
+			if c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil {
+				walk(fmt.Sprintf("messages[%d].content[%d]", i, j), []int{2, i, j},
+					c.MessageContentToolResult.Content)
+			}
+		}
+	}
+	return marks
+}
+
+// position returns the breakpoint's position in prompt order. A block's
+// breakpoint comes after any breakpoint in its tool_result content.
+func (bp CacheBreakpoint) position() []int {
+	switch bp.Location {
+	case CacheLocationTools:
+		return []int{0, bp.Index}
+	case CacheLocationSystem:
+		return []int{1, bp.Index}
+	}
+	return []int{2, bp.Index, bp.Content, math.MaxInt}
+}
+
+// ttlConflict reports a kept breakpoint that the candidate's TTL would put
+// out of order: a 1h TTL may not follow a 5m one.
+func (c cacheCandidate) ttlConflict(kept []cacheMark) (string, bool) {
+	pos := c.position()
+	for _, k := range kept {
+		before := slices.Compare(k.pos, pos) < 0
+		if before && cacheTTLRank(c.TTL) > cacheTTLRank(k.ttl) ||
+			!before && cacheTTLRank(k.ttl) > cacheTTLRank(c.TTL) {
+			return k.where, true
+		}
+	}
+	return "", false
+}
+
+// planCacheCandidates returns the candidate breakpoints in priority order.
+func planCacheCandidates(request MessagesRequest, p cachePlanner) []cacheCandidate {
+	tokens := 0
+	toolsEnd := -1
+	if len(request.Tools) > 0 {
+		tokens += estimateJSONTokens(request.Tools)
+		toolsEnd = tokens
+	}
+	systemEnd := -1
+	if len(request.MultiSystem) > 0 {
+		tokens += estimateJSONTokens(request.MultiSystem)
+		systemEnd = tokens
+	}
+
+	// blockEnd[i][j] is the prefix length up to and including block j of
+	// message i.
+	blockEnd := make([][]int, len(request.Messages))
+	for i, m := range request.Messages {
+		blockEnd[i] = make([]int, len(m.Content))
+		for j, c := range m.Content {
+			tokens += estimateContentTokens([]MessageContent{c})
+			blockEnd[i][j] = tokens
+		}
+	}
+
+	var candidates []cacheCandidate
+	add := func(name string, bp CacheBreakpoint) {
+		candidates = append(candidates, cacheCandidate{name: name, CacheBreakpoint: bp})
+	}
+	addMessage := func(name, reason string, i int) {
+		j := lastCacheableBlock(request.Messages[i])
+		if j < 0 {
+			return
+		}
+		add(name, CacheBreakpoint{
+			Location:     CacheLocationMessages,
+			Index:        i,
+			Content:      j,
+			TTL:          p.ttl,
+			PrefixTokens: blockEnd[i][j],
+			Reason:       reason,
+		})
+	}
+
+	last := len(request.Messages) - 1
+	if last >= 0 {
+		addMessage("conversation", "caches the conversation so far for the next turn", last)
+	}
+	if systemEnd >= 0 {
+		add("system", CacheBreakpoint{
+			Location:     CacheLocationSystem,
+			Index:        len(request.MultiSystem) - 1,
+			TTL:          p.prefixTTL,
+			PrefixTokens: systemEnd,
+			Reason:       "the system prompt is stable across turns",
+		})
+	}
+	for i := last - 1; i >= 0; i-- {
+		if request.Messages[i].Role == RoleUser {
+			addMessage("previous turn",
+				"reads the prefix written by the previous turn, however long this turn is", i)
+			break
+		}
+	}
+	if toolsEnd >= 0 {
+		add("tools", CacheBreakpoint{
+			Location:     CacheLocationTools,
+			Index:        len(request.Tools) - 1,
+			TTL:          p.prefixTTL,
+			PrefixTokens: toolsEnd,
+			Reason:       "tool definitions stay cached when the system prompt changes",
+		})
+	}
+	return candidates
+}
+
+// lastCacheableBlock returns the index of the last block of m that can carry
+// cache_control, or -1.
+func lastCacheableBlock(m Message) int {
+	for j := len(m.Content) - 1; j >= 0; j-- {
+		if cacheControlAllowed(m.Content[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+func cacheControlAllowed(c MessageContent) bool {
+	switch c.Type {
+	case MessagesContentTypeThinking, MessagesContentTypeRedactedThinking:
+		return false
+	case MessagesContentTypeText:
+		return c.GetText() != ""
+	default:
+		return true
+	}
+}
+
+// stripCacheControl returns a copy of request without breakpoints, except
+// in tool_result content, and the number removed.
+func stripCacheControl(request MessagesRequest) (MessagesRequest, int) {
+	removed := 0
+	if request.CacheControl != nil {
+		request.CacheControl = nil
+		removed++
+	}
+
+	request.Tools = append([]ToolDefinition(nil), request.Tools...)
+	for i := range request.Tools {
+		if request.Tools[i].CacheControl != nil {
+			request.Tools[i].CacheControl = nil
+			removed++
+		}
+	}
+
+	request.MultiSystem = append([]MessageSystemPart(nil), request.MultiSystem...)
+	for i := range request.MultiSystem {
+		if request.MultiSystem[i].CacheControl != nil {
+			request.MultiSystem[i].CacheControl = nil
+			removed++
+		}
+	}
+
+	request.Messages = append([]Message(nil), request.Messages...)
+	for i, m := range request.Messages {
+		copied := false
+		for j, c := range m.Content {
+			if c.CacheControl == nil {
+				continue
+			}
+			if !copied {
+				m.Content = append([]MessageContent(nil), m.Content...)
+				request.Messages[i] = m
+				copied = true
+			}
+			m.Content[j].CacheControl = nil
+			removed++
+		}
+	}
+	return request, removed
+}
+
+func cacheTTLRank(ttl CacheControlTTL) int {
+	if ttl == CacheControlTTL1h {
+		return 1
+	}
+	return 0
+}
+
+// ValidateCacheControl checks the cache_control breakpoints of a request
+// against the API rules: at most MaxCacheBreakpoints, longer TTLs before
+// shorter ones in prompt order (tools, system, messages), and no
+// breakpoints on thinking or empty text blocks. Every violation is
+// reported, each wrapping ErrInvalidCacheControl.
+func ValidateCacheControl(request MessagesRequest) error {
+	var errs []error
+	for _, p := range cacheControlProblems(request) {
+		if p.Path == "" {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidCacheControl, p.Message))
+		} else {
+			errs = append(errs, fmt.Errorf("%w: %s: %s", ErrInvalidCacheControl, p.Path, p.Message))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cacheControlProblems lists the cache_control violations of request, see
+// ValidateCacheControl.
+func cacheControlProblems(request MessagesRequest) []*RequestValidationError {
+	type mark struct {
+		where string
+		ttl   CacheControlTTL
+	}
+	var marks []mark
+	var problems []*RequestValidationError
+	problem := func(path, format string, args ...any) {
+		problems = append(problems, &RequestValidationError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+			Err:     ErrInvalidCacheControl,
+		})
+	}
+
+	for i, tool := range request.Tools {
+		if tool.CacheControl != nil {
+			marks = append(marks, mark{fmt.Sprintf("tools[%d]", i), tool.CacheControl.TTL})
+		}
+	}
+	for i, part := range request.MultiSystem {
+		if part.CacheControl != nil {
+			marks = append(marks, mark{fmt.Sprintf("system[%d]", i), part.CacheControl.TTL})
+		}
+	}
+	var walk func(where string, content []MessageContent)
+	walk = func(where string, content []MessageContent) {
+		for j, c := range content {
+			at := fmt.Sprintf("%s.content[%d]", where, j)
+			// A tool_result's content precedes the end of the block.
+			if c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil {
+				walk(at, c.MessageContentToolResult.Content)
+			}
+			if c.CacheControl != nil {
+				marks = append(marks, mark{at, c.CacheControl.TTL})
+				if !cacheControlAllowed(c) {
+					problem(at, "%s blocks cannot be cached", cacheBlockKind(c))
+				}
+			}
+		}
+	}
+	for i, m := range request.Messages {
+		walk(fmt.Sprintf("messages[%d]", i), m.Content)
+	}
+	if request.CacheControl != nil {
+		// Automatic caching marks the last cacheable block.
+		marks = append(marks, mark{"cache_control", request.CacheControl.TTL})
+	}
+
+	if len(marks) > MaxCacheBreakpoints {
+		problem("", "%d breakpoints, at most %d are allowed", len(marks), MaxCacheBreakpoints)
+	}
+	for i := 1; i < len(marks); i++ {
+		if cacheTTLRank(marks[i].ttl) > cacheTTLRank(marks[i-1].ttl) {
+			problem(marks[i].where, "the 1h TTL follows a 5m TTL at %s", marks[i-1].where)
+		}
+	}
+	return problems
+}
+
+func cacheBlockKind(c MessageContent) string {
+	if c.Type == MessagesContentTypeText {
+		return "empty text"
+	}
+	return string(c.Type)
+}
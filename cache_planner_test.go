@@ -0,0 +1,190 @@
+package anthropic_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func cachePlannerRequest() anthropic.MessagesRequest {
+	long := strings.Repeat("lorem ipsum ", 1000)
+	return anthropic.MessagesRequest{
+		Model:  anthropic.ModelClaudeSonnet4Dot5,
+		System: long,
+		Tools: []anthropic.ToolDefinition{
+			{Name: "search", Description: long, InputSchema: map[string]any{"type": "object"}},
+		},
+		Messages: []anthropic.Message{
+			anthropic.NewUserTextMessage(long),
+			{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{
+				anthropic.NewTextMessageContent("answer"),
+			}},
+			{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{
+				anthropic.NewTextMessageContent("follow up"),
+				anthropic.NewTextMessageContent(""),
+			}},
+		},
+	}
+}
+
+func TestPlanCacheBreakpoints(t *testing.T) {
+	request := cachePlannerRequest()
+	request.Messages[1].Content[0].SetCacheControl()
+
+	planned, plan, err := anthropic.PlanCacheBreakpoints(
+		request,
+		anthropic.WithCachePrefixTTL(anthropic.CacheControlTTL1h),
+	)
+	if err != nil {
+		t.Fatalf("PlanCacheBreakpoints error: %v", err)
+	}
+	if len(plan.Breakpoints) != 4 || plan.Removed != 1 {
+		t.Fatalf("unexpected plan:\n%s", plan)
+	}
+	if err := anthropic.ValidateCacheControl(planned); err != nil {
+		t.Fatalf("the plan is invalid: %v", err)
+	}
+
+	if planned.Tools[0].CacheControl.TTL != anthropic.CacheControlTTL1h ||
+		planned.MultiSystem[0].CacheControl.TTL != anthropic.CacheControlTTL1h {
+		t.Fatalf("the prefix was not cached for 1h: %+v", planned)
+	}
+	if planned.Messages[1].Content[0].CacheControl != nil {
+		t.Fatal("the existing breakpoint was not removed")
+	}
+	if planned.Messages[0].Content[0].CacheControl == nil {
+		t.Fatal("the previous turn was not cached")
+	}
+	// The empty text block cannot carry a breakpoint.
+	if planned.Messages[2].Content[0].CacheControl == nil ||
+		planned.Messages[2].Content[1].CacheControl != nil {
+		t.Fatalf("unexpected conversation breakpoint: %+v", planned.Messages[2])
+	}
+
+	if request.Messages[1].Content[0].CacheControl == nil ||
+		request.Messages[0].Content[0].CacheControl != nil || request.Tools[0].CacheControl != nil {
+		t.Fatal("the input request was modified")
+	}
+	if !strings.Contains(plan.String(), "system[0] ttl=1h") {
+		t.Fatalf("unexpected explanation:\n%s", plan)
+	}
+}
+
+func TestPlanCacheBreakpointsLimits(t *testing.T) {
+	t.Run("max breakpoints", func(t *testing.T) {
+		_, plan, err := anthropic.PlanCacheBreakpoints(
+			cachePlannerRequest(),
+			anthropic.WithCacheMaxBreakpoints(2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Breakpoints) != 2 || len(plan.Skipped) != 2 {
+			t.Fatalf("unexpected plan:\n%s", plan)
+		}
+		if plan.Breakpoints[0].Location != anthropic.CacheLocationSystem ||
+			plan.Breakpoints[1].Location != anthropic.CacheLocationMessages {
+			t.Fatalf("the highest priority positions were not kept:\n%s", plan)
+		}
+	})
+
+	t.Run("minimum length", func(t *testing.T) {
+		request := anthropic.MessagesRequest{
+			Model:    anthropic.ModelClaudeHaiku4Dot5,
+			System:   "short",
+			Messages: []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+		}
+		planned, plan, err := anthropic.PlanCacheBreakpoints(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Breakpoints) != 0 || !strings.Contains(plan.Skipped[0], "4096") {
+			t.Fatalf("unexpected plan:\n%s", plan)
+		}
+		if planned.Messages[0].Content[0].CacheControl != nil {
+			t.Fatal("a breakpoint was placed below the minimum")
+		}
+	})
+
+	t.Run("kept tool result breakpoints", func(t *testing.T) {
+		for _, tt := range []struct {
+			ttl     anthropic.CacheControlTTL
+			skipped string
+		}{
+			{anthropic.CacheControlTTL5m, "tools: breakpoint limit reached"},
+			{anthropic.CacheControlTTL1h, "previous turn: its TTL is out of order"},
+		} {
+			cached := anthropic.NewTextMessageContent("cached result")
+			cached.CacheControl = &anthropic.MessageCacheControl{
+				Type: anthropic.CacheControlTypeEphemeral,
+				TTL:  tt.ttl,
+			}
+			request := cachePlannerRequest()
+			request.Messages[2] = anthropic.Message{
+				Role: anthropic.RoleUser,
+				Content: []anthropic.MessageContent{anthropic.NewToolResultBlocksMessageContent(
+					"toolu_1", []anthropic.MessageContent{cached}, false,
+				)},
+			}
+
+			planned, plan, err := anthropic.PlanCacheBreakpoints(
+				request,
+				anthropic.WithCachePrefixTTL(anthropic.CacheControlTTL1h),
+			)
+			if err != nil {
+				t.Fatalf("PlanCacheBreakpoints error: %v", err)
+			}
+			if len(plan.Breakpoints) != 3 || plan.Kept != 1 ||
+				!strings.Contains(strings.Join(plan.Skipped, "\n"), tt.skipped) {
+				t.Fatalf("unexpected plan for a %s kept breakpoint:\n%s", tt.ttl, plan)
+			}
+			if err := anthropic.ValidateCacheControl(planned); err != nil {
+				t.Fatalf("the plan is invalid: %v", err)
+			}
+		}
+	})
+
+	t.Run("ttl order", func(t *testing.T) {
+		_, _, err := anthropic.PlanCacheBreakpoints(
+			cachePlannerRequest(),
+			anthropic.WithCacheTTL(anthropic.CacheControlTTL1h),
+			anthropic.WithCachePrefixTTL(anthropic.CacheControlTTL5m),
+		)
+		if !errors.Is(err, anthropic.ErrInvalidCacheControl) {
+			t.Fatalf("expected ErrInvalidCacheControl, got %v", err)
+		}
+	})
+}
+
+func TestValidateCacheControl(t *testing.T) {
+	request := cachePlannerRequest()
+	request.MultiSystem = anthropic.NewMultiSystemMessages("a", "b")
+	request.MultiSystem[0].CacheControl = &anthropic.MessageCacheControl{
+		Type: anthropic.CacheControlTypeEphemeral,
+	}
+	request.MultiSystem[1].CacheControl = &anthropic.MessageCacheControl{
+		Type: anthropic.CacheControlTypeEphemeral,
+		TTL:  anthropic.CacheControlTTL1h,
+	}
+	for i := range request.Messages {
+		for j := range request.Messages[i].Content {
+			request.Messages[i].Content[j].SetCacheControl()
+		}
+	}
+
+	err := anthropic.ValidateCacheControl(request)
+	if !errors.Is(err, anthropic.ErrInvalidCacheControl) {
+		t.Fatalf("expected ErrInvalidCacheControl, got %v", err)
+	}
+	for _, want := range []string{"6 breakpoints", "follows a 5m TTL", "empty text"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	if err := anthropic.ValidateCacheControl(cachePlannerRequest()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
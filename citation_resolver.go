@@ -0,0 +1,343 @@
+package anthropic
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CitationCheck is the outcome of comparing a citation's CitedText with the
+// source span it points at.
+type CitationCheck string
+
+const (
+	// CitationUnchecked means the source text is not available locally, e.g.
+	// for PDF pages, uploaded files and web search results.
+	CitationUnchecked CitationCheck = "unchecked"
+	CitationVerified  CitationCheck = "verified"
+	CitationMismatch  CitationCheck = "mismatch"
+	// CitationUnresolved means the citation points outside the request, such
+	// as a document index that was not sent.
+	CitationUnresolved CitationCheck = "unresolved"
+)
+
+// ResolvedCitation is a citation mapped back to the request it answers.
+type ResolvedCitation struct {
+	Citation Citation
+	// Number is the footnote number. Citations of the same span share it.
+	Number int
+	// Title is the document, search result or web page title.
+	Title string
+	// URL is the web page or search result source, if any.
+	URL string
+	// Location describes the cited span, e.g. "pages 2-3" or "characters 10-42".
+	Location string
+	// SourceText is the text the span selects, when it is available locally.
+	SourceText string
+	Check      CitationCheck
+}
+
+// ResolvedTextBlock is a text block of the response with its citations.
+type ResolvedTextBlock struct {
+	// Index is the position of the block in MessagesResponse.Content.
+	Index     int
+	Text      string
+	Citations []ResolvedCitation
+}
+
+// CitedAnswer is the text of a response with resolved citations.
+type CitedAnswer struct {
+	Blocks []ResolvedTextBlock
+	// Footnotes holds one citation per footnote number, in order.
+	Footnotes []ResolvedCitation
+}
+
+// ResolveCitations maps the citations in response back to the documents and
+// search results of request, which must be the request that produced it.
+// Documents and search results are numbered in request order, including those
+// inside tool results, as the API does.
+func ResolveCitations(request MessagesRequest, response MessagesResponse) CitedAnswer {
+	var documents, searchResults []MessageContent
+	var collect func(content []MessageContent)
+	collect = func(content []MessageContent) {
+		for _, c := range content {
+			switch c.Type {
+			case MessagesContentTypeDocument:
+				documents = append(documents, c)
+			case MessagesContentTypeSearchResult:
+				searchResults = append(searchResults, c)
+			case MessagesContentTypeToolResult:
+				if c.MessageContentToolResult != nil {
+					collect(c.MessageContentToolResult.Content)
+				}
+			}
+		}
+	}
+	for _, m := range request.Messages {
+		collect(m.Content)
+	}
+
+	var answer CitedAnswer
+	numbers := make(map[string]int)
+	for i, c := range response.Content {
+		if c.Type != MessagesContentTypeText {
+			continue
+		}
+		block := ResolvedTextBlock{Index: i, Text: c.GetText()}
+		for _, citation := range c.Citations {
+			resolved := resolveCitation(citation, documents, searchResults)
+			key := resolved.key()
+			if n, ok := numbers[key]; ok {
+				resolved.Number = n
+			} else {
+				resolved.Number = len(answer.Footnotes) + 1
+				numbers[key] = resolved.Number
+				answer.Footnotes = append(answer.Footnotes, resolved)
+			}
+			block.Citations = append(block.Citations, resolved)
+		}
+		answer.Blocks = append(answer.Blocks, block)
+	}
+
+	return answer
+}
+
+func resolveCitation(
+	citation Citation,
+	documents, searchResults []MessageContent,
+) ResolvedCitation {
+	r := ResolvedCitation{Citation: citation, Check: CitationUnchecked}
+
+	switch citation.Type {
+	case CitationTypeWebSearchResultLocation:
+		r.Title = derefString(citation.Title)
+		r.URL = derefString(citation.Url)
+		return r
+
+	case CitationTypeSearchResultLocation:
+		loc, _ := citation.SearchResultLocation()
+		r.Title, r.URL = loc.Title, loc.Source
+		r.Location = spanLocation("blocks", loc.StartBlockIndex, loc.EndBlockIndex)
+		if loc.SearchResultIndex < 0 || loc.SearchResultIndex >= len(searchResults) {
+			r.Check = CitationUnresolved
+			return r
+		}
+		result := searchResults[loc.SearchResultIndex]
+		if result.MessageContentSearchResult != nil {
+			r.setSourceText(blockSpan(
+				result.MessageContentSearchResult.Content,
+				loc.StartBlockIndex,
+				loc.EndBlockIndex,
+			))
+		}
+		return r
+	}
+
+	r.Title = citation.DocumentTitle
+	var document *MessageContent
+	if citation.DocumentIndex >= 0 && citation.DocumentIndex < len(documents) {
+		document = &documents[citation.DocumentIndex]
+		if r.Title == "" {
+			r.Title = document.Title
+		}
+	}
+
+	switch citation.Type {
+	case CitationTypeCharLocation:
+		start, end := derefInt(citation.StartCharIndex), derefInt(citation.EndCharIndex)
+		r.Location = spanLocation("characters", start, end)
+		if document != nil && document.Source != nil {
+			if text, ok := document.Source.Data.(string); ok &&
+				document.Source.Type == MessagesContentSourceTypeText {
+				r.setSourceText(runeSpan(text, start, end))
+			}
+		}
+	case CitationTypePageLocation:
+		r.Location = spanLocation("pages", derefInt(citation.StartPage), derefInt(citation.EndPage))
+	case CitationTypeContentBlockLocation, CitationTypeBlockIndex:
+		start, end := derefInt(citation.StartBlockIndex), derefInt(citation.EndBlockIndex)
+		r.Location = spanLocation("blocks", start, end)
+		if document != nil && document.Source != nil &&
+			document.Source.Type == MessagesContentSourceTypeContent {
+			r.setSourceText(blockSpan(document.Source.Content, start, end))
+		}
+	}
+
+	if document == nil {
+		r.Check = CitationUnresolved
+	}
+	return r
+}
+
+// setSourceText records the span text and compares it with the cited text,
+// ignoring differences in whitespace.
+func (r *ResolvedCitation) setSourceText(text string, ok bool) {
+	if !ok {
+		r.Check = CitationUnresolved
+		return
+	}
+	r.SourceText = text
+	if strings.Join(strings.Fields(text), " ") ==
+		strings.Join(strings.Fields(r.Citation.CitedText), " ") {
+		r.Check = CitationVerified
+	} else {
+		r.Check = CitationMismatch
+	}
+}
+
+func (r ResolvedCitation) key() string {
+	c := r.Citation
+	switch c.Type {
+	case CitationTypeWebSearchResultLocation:
+		return string(c.Type) + "|" + r.URL + "|" + c.CitedText
+	case CitationTypeSearchResultLocation:
+		return string(c.Type) + "|" + strconv.Itoa(derefInt(c.SearchResultIndex)) + "|" + r.Location
+	}
+	return string(c.Type) + "|" + strconv.Itoa(c.DocumentIndex) + "|" + r.Location
+}
+
+// runeSpan returns the characters [start, end) of text.
+func runeSpan(text string, start, end int) (string, bool) {
+	runes := []rune(text)
+	if start < 0 || end > len(runes) || start > end {
+		return "", false
+	}
+	return string(runes[start:end]), true
+}
+
+// blockSpan joins the text of the blocks [start, end).
+func blockSpan(blocks []MessageContent, start, end int) (string, bool) {
+	if start < 0 || end > len(blocks) || start > end {
+		return "", false
+	}
+	texts := make([]string, 0, end-start)
+	for _, b := range blocks[start:end] {
+		texts = append(texts, b.GetText())
+	}
+	return strings.Join(texts, " "), true
+}
+
+// spanLocation describes the half-open span [start, end) of unit.
+func spanLocation(unit string, start, end int) string {
+	if end-start <= 1 {
+		return fmt.Sprintf("%s %d", strings.TrimSuffix(unit, "s"), start)
+	}
+	return fmt.Sprintf("%s %d-%d", unit, start, end-1)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+var (
+	markdownEscaper    = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`)
+	markdownURLEscaper = strings.NewReplacer("(", "%28", ")", "%29", " ", "%20")
+)
+
+// safeURL reports whether u is an absolute http or https URL, which can be
+// linked without running script.
+func safeURL(u string) bool {
+	parsed, err := url.Parse(u)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") &&
+		parsed.Host != ""
+}
+
+// markers renders the footnote references of block, once per number.
+func markers(block ResolvedTextBlock, marker func(n int) string) string {
+	var b strings.Builder
+	seen := make(map[int]bool)
+	for _, c := range block.Citations {
+		if !seen[c.Number] {
+			seen[c.Number] = true
+			b.WriteString(marker(c.Number))
+		}
+	}
+	return b.String()
+}
+
+// Markdown renders the answer with a footnote reference after each cited
+// text block and the footnotes at the end. Brackets in titles are escaped,
+// and only http and https URLs are linked.
+func (a CitedAnswer) Markdown() string {
+	var b strings.Builder
+	for _, block := range a.Blocks {
+		b.WriteString(block.Text)
+		b.WriteString(markers(block, func(n int) string {
+			return fmt.Sprintf("[^%d]", n)
+		}))
+	}
+
+	if len(a.Footnotes) > 0 {
+		b.WriteString("\n\n")
+	}
+	for _, f := range a.Footnotes {
+		title := f.Title
+		if title == "" {
+			title = "Source"
+		}
+		title = markdownEscaper.Replace(title)
+		if safeURL(f.URL) {
+			title = fmt.Sprintf("[%s](%s)", title, markdownURLEscaper.Replace(f.URL))
+		}
+		fmt.Fprintf(&b, "[^%d]: %s", f.Number, title)
+		if f.Location != "" {
+			fmt.Fprintf(&b, ", %s", f.Location)
+		}
+		if f.Citation.CitedText != "" {
+			fmt.Fprintf(&b, `: "%s"`, strings.Join(strings.Fields(f.Citation.CitedText), " "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// HTML renders the answer as a paragraph with superscript links to an
+// ordered list of footnotes. All text is escaped, and only http and https
+// URLs are linked.
+func (a CitedAnswer) HTML() string {
+	var b strings.Builder
+	b.WriteString("<p>")
+	for _, block := range a.Blocks {
+		b.WriteString(html.EscapeString(block.Text))
+		b.WriteString(markers(block, func(n int) string {
+			return fmt.Sprintf(`<sup><a href="#cite-%d">[%d]</a></sup>`, n, n)
+		}))
+	}
+	b.WriteString("</p>\n")
+
+	if len(a.Footnotes) == 0 {
+		return b.String()
+	}
+	b.WriteString("<ol>\n")
+	for _, f := range a.Footnotes {
+		title := html.EscapeString(f.Title)
+		if title == "" {
+			title = "Source"
+		}
+		if safeURL(f.URL) {
+			title = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(f.URL), title)
+		}
+		fmt.Fprintf(&b, `<li id="cite-%d">%s`, f.Number, title)
+		if f.Location != "" {
+			fmt.Fprintf(&b, ", %s", f.Location)
+		}
+		if f.Citation.CitedText != "" {
+			fmt.Fprintf(&b, ": <q>%s</q>", html.EscapeString(f.Citation.CitedText))
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ol>\n")
+	return b.String()
+}
@@ -0,0 +1,205 @@
+package anthropic_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestResolveCitations(t *testing.T) {
+	searchResult := anthropic.NewSearchResultMessageContent(
+		"https://docs.example.com/keys",
+		"API keys",
+		[]string{"Keys are secret.", "Rotate keys <yearly>."},
+		true,
+	)
+	request := anthropic.MessagesRequest{
+		Messages: []anthropic.Message{
+			{
+				Role: anthropic.RoleUser,
+				Content: []anthropic.MessageContent{
+					anthropic.NewTextDocumentMessageContent(
+						"The grass is green. The sky is blue.", "Colors", "", true,
+					),
+					anthropic.NewPDFDocumentMessageContent("JVBERi0=", "Report", "", true),
+				},
+			},
+			{
+				Role: anthropic.RoleUser,
+				Content: []anthropic.MessageContent{
+					anthropic.NewToolResultBlocksMessageContent(
+						"toolu_1",
+						[]anthropic.MessageContent{searchResult},
+						false,
+					),
+				},
+			},
+		},
+	}
+
+	text := func(s string, citations ...anthropic.Citation) anthropic.MessageContent {
+		c := anthropic.NewTextMessageContent(s)
+		c.Citations = citations
+		return c
+	}
+	response := anthropic.MessagesResponse{
+		Content: []anthropic.MessageContent{
+			text("The grass is green", anthropic.Citation{
+				Type:           anthropic.CitationTypeCharLocation,
+				CitedText:      "The grass is green. ",
+				DocumentIndex:  0,
+				StartCharIndex: toPtr(0),
+				EndCharIndex:   toPtr(20),
+			}),
+			text(" and the sky is red", anthropic.Citation{
+				Type:           anthropic.CitationTypeCharLocation,
+				CitedText:      "The sky is red.",
+				DocumentIndex:  0,
+				StartCharIndex: toPtr(20),
+				EndCharIndex:   toPtr(36),
+			}),
+			text(", see the report", anthropic.Citation{
+				Type:          anthropic.CitationTypePageLocation,
+				CitedText:     "Summary",
+				DocumentIndex: 1,
+				StartPage:     toPtr(2),
+				EndPage:       toPtr(4),
+			}),
+			text(". Rotate keys", anthropic.Citation{
+				Type:              anthropic.CitationTypeSearchResultLocation,
+				CitedText:         "Rotate keys <yearly>.",
+				Source:            toPtr("https://docs.example.com/keys"),
+				Title:             toPtr("API keys"),
+				SearchResultIndex: toPtr(0),
+				StartBlockIndex:   toPtr(1),
+				EndBlockIndex:     toPtr(2),
+			}, anthropic.Citation{
+				Type:           anthropic.CitationTypeCharLocation,
+				CitedText:      "The grass is green.",
+				DocumentIndex:  0,
+				StartCharIndex: toPtr(0),
+				EndCharIndex:   toPtr(20),
+			}),
+			text(".", anthropic.Citation{
+				Type:          anthropic.CitationTypeBlockIndex,
+				DocumentIndex: 5,
+			}),
+		},
+	}
+
+	answer := anthropic.ResolveCitations(request, response)
+	if len(answer.Blocks) != 5 || len(answer.Footnotes) != 5 {
+		t.Fatalf("unexpected answer %+v", answer)
+	}
+
+	checks := []struct {
+		block    int
+		number   int
+		title    string
+		location string
+		check    anthropic.CitationCheck
+	}{
+		{0, 1, "Colors", "characters 0-19", anthropic.CitationVerified},
+		{1, 2, "Colors", "characters 20-35", anthropic.CitationMismatch},
+		{2, 3, "Report", "pages 2-3", anthropic.CitationUnchecked},
+		{3, 4, "API keys", "block 1", anthropic.CitationVerified},
+		{4, 5, "", "block 0", anthropic.CitationUnresolved},
+	}
+	for _, c := range checks {
+		got := answer.Blocks[c.block].Citations[0]
+		if got.Number != c.number || got.Title != c.title || got.Location != c.location ||
+			got.Check != c.check {
+			t.Errorf("block %d: unexpected citation %+v", c.block, got)
+		}
+	}
+	if answer.Blocks[1].Citations[0].SourceText != "The sky is blue." {
+		t.Errorf("unexpected source text %q", answer.Blocks[1].Citations[0].SourceText)
+	}
+	if answer.Blocks[3].Citations[1].Number != 1 || answer.Blocks[3].Citations[0].URL == "" {
+		t.Errorf("unexpected citations %+v", answer.Blocks[3].Citations)
+	}
+
+	markdown := answer.Markdown()
+	for _, want := range []string{
+		"The grass is green[^1] and the sky is red[^2], see the report[^3]. " +
+			"Rotate keys[^4][^1].[^5]",
+		`[^3]: Report, pages 2-3: "Summary"`,
+		`[^4]: [API keys](https://docs.example.com/keys), block 1: "Rotate keys <yearly>."`,
+		"[^5]: Source, block 0",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown %q does not contain %q", markdown, want)
+		}
+	}
+
+	html := answer.HTML()
+	for _, want := range []string{
+		`Rotate keys<sup><a href="#cite-4">[4]</a></sup><sup><a href="#cite-1">[1]</a></sup>`,
+		`<li id="cite-4"><a href="https://docs.example.com/keys">API keys</a>, block 1: ` +
+			`<q>Rotate keys &lt;yearly&gt;.</q></li>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html %q does not contain %q", html, want)
+		}
+	}
+}
+
+func TestResolveCitationsContentBlocksAndUnsafeLinks(t *testing.T) {
+	request := anthropic.MessagesRequest{
+		Messages: []anthropic.Message{{
+			Role: anthropic.RoleUser,
+			Content: []anthropic.MessageContent{
+				anthropic.NewCustomContentDocumentMessageContent([]anthropic.MessageContent{
+					anthropic.NewTextMessageContent("First."),
+					anthropic.NewTextMessageContent("Second."),
+				}, "Notes [draft]", "", true),
+				anthropic.NewSearchResultMessageContent(
+					"javascript:alert(1)", "Evil", []string{"Click me."}, true,
+				),
+				anthropic.NewSearchResultMessageContent(
+					"https://example.com/a_(b)", "Wiki", []string{"Parens."}, true,
+				),
+			},
+		}},
+	}
+
+	var content []anthropic.MessageContent
+	data := `[{"type":"text","text":"Second","citations":[{"type":"content_block_location",` +
+		`"cited_text":"Second.","document_index":0,"start_block_index":1,"end_block_index":2}]},` +
+		`{"type":"text","text":" click","citations":[{"type":"search_result_location",` +
+		`"cited_text":"Click me.","source":"javascript:alert(1)","title":"Evil",` +
+		`"search_result_index":0,"start_block_index":0,"end_block_index":1}]},` +
+		`{"type":"text","text":" wiki","citations":[{"type":"search_result_location",` +
+		`"cited_text":"Parens.","source":"https://example.com/a_(b)","title":"Wiki",` +
+		`"search_result_index":1,"start_block_index":0,"end_block_index":1}]}]`
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		t.Fatal(err)
+	}
+
+	answer := anthropic.ResolveCitations(request, anthropic.MessagesResponse{Content: content})
+	got := answer.Blocks[0].Citations[0]
+	if got.Check != anthropic.CitationVerified || got.Location != "block 1" {
+		t.Fatalf("unexpected citation %+v", got)
+	}
+
+	html := answer.HTML()
+	if strings.Contains(html, `href="javascript`) {
+		t.Errorf("unsafe link in %q", html)
+	}
+	if !strings.Contains(html, `<a href="https://example.com/a_(b)">Wiki</a>`) {
+		t.Errorf("missing link in %q", html)
+	}
+
+	markdown := answer.Markdown()
+	for _, want := range []string{
+		`[^1]: Notes \[draft\], block 1`,
+		`[^2]: Evil, block 0`,
+		`[^3]: [Wiki](https://example.com/a_%28b%29), block 0`,
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown %q does not contain %q", markdown, want)
+		}
+	}
+}
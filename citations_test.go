@@ -113,3 +113,96 @@ func strPtr(s string) *string {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestSearchResultMessageContent(t *testing.T) {
+	result := NewSearchResultMessageContent(
+		"https://docs.example.com/api",
+		"API Reference",
+		[]string{"Authentication uses API keys.", "Keys are scoped per workspace."},
+		true,
+	)
+	toolResult := NewToolResultBlocksMessageContent("toolu_1", []MessageContent{result}, false)
+
+	data, err := json.Marshal(toolResult)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "tool_result",
+		"tool_use_id": "toolu_1",
+		"is_error": false,
+		"content": [{
+			"type": "search_result",
+			"source": "https://docs.example.com/api",
+			"title": "API Reference",
+			"content": [
+				{"type": "text", "text": "Authentication uses API keys."},
+				{"type": "text", "text": "Keys are scoped per workspace."}
+			],
+			"citations": {"enabled": true}
+		}]
+	}`, string(data))
+
+	var decoded MessageContent
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	got := decoded.MessageContentToolResult.Content[0]
+	assert.Equal(t, MessagesContentTypeSearchResult, got.Type)
+	assert.Nil(t, got.Source)
+	assert.Equal(t, "https://docs.example.com/api", got.MessageContentSearchResult.Source)
+	assert.Equal(t, "API Reference", got.Title)
+	assert.Len(t, got.MessageContentSearchResult.Content, 2)
+	assert.True(t, got.DocumentCitations.Enabled)
+
+	var image MessageContent
+	assert.NoError(t, json.Unmarshal(
+		[]byte(`{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}}`),
+		&image,
+	))
+	assert.Equal(t, "https://example.com/a.png", image.Source.Url)
+}
+
+func TestSearchResultLocationCitation(t *testing.T) {
+	var text MessageContent
+	err := json.Unmarshal([]byte(`{
+		"type": "text",
+		"text": "API keys are used.",
+		"citations": [{
+			"type": "search_result_location",
+			"cited_text": "Authentication uses API keys.",
+			"source": "https://docs.example.com/api",
+			"title": "API Reference",
+			"search_result_index": 1,
+			"start_block_index": 0,
+			"end_block_index": 1
+		}]
+	}`), &text)
+	assert.NoError(t, err)
+	assert.Len(t, text.Citations, 1)
+
+	loc, ok := text.Citations[0].SearchResultLocation()
+	assert.True(t, ok)
+	assert.Equal(t, SearchResultLocation{
+		CitedText:         "Authentication uses API keys.",
+		Source:            "https://docs.example.com/api",
+		Title:             "API Reference",
+		SearchResultIndex: 1,
+		StartBlockIndex:   0,
+		EndBlockIndex:     1,
+	}, loc)
+
+	_, ok = Citation{Type: CitationTypeCharLocation}.SearchResultLocation()
+	assert.False(t, ok)
+
+	var event MessagesEventContentBlockDeltaData
+	err = json.Unmarshal([]byte(`{"type":"content_block_delta","index":0,"delta":{`+
+		`"type":"citations_delta","citation":{"type":"search_result_location",`+
+		`"cited_text":"Keys are scoped per workspace.","source":"https://docs.example.com/api",`+
+		`"search_result_index":0,"start_block_index":1,"end_block_index":2}}}`), &event)
+	assert.NoError(t, err)
+
+	streamed := NewTextMessageContent("")
+	streamed.MergeContentDelta(event.Delta)
+	assert.Len(t, streamed.Citations, 1)
+	loc, ok = streamed.Citations[0].SearchResultLocation()
+	assert.True(t, ok)
+	assert.Equal(t, 1, loc.StartBlockIndex)
+	assert.Equal(t, "https://docs.example.com/api", loc.Source)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
 )
 
 type Client struct {
@@ -108,17 +109,56 @@ func (c *Client) newRequest(
 	body any,
 	requestSetters ...requestSetter,
 ) (req *http.Request, err error) {
+	fullURL, reqBody, err := c.prepareRequestBody(method, urlSuffix, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.newRequestWithBody(ctx, method, fullURL, body, reqBody, requestSetters...)
+}
 
+// prepareRequestBody returns the URL of a request and body marshaled as its
+// JSON body.
+func (c *Client) prepareRequestBody(method, urlSuffix string, body any) (string, []byte, error) {
 	// prepare the request
-	var fullURL string
-	fullURL, err = c.config.Adapter.PrepareRequest(c, method, urlSuffix, body)
+	fullURL, err := c.config.Adapter.PrepareRequest(c, method, urlSuffix, body)
+	if err != nil {
+		return "", nil, err
+	}
+	if body == nil {
+		return fullURL, nil, nil
+	}
+	reqBody, err := c.marshalRequestBody(body)
+	if err != nil {
+		return "", nil, err
+	}
+	return fullURL, reqBody, nil
+}
+
+// marshalRequestBody marshals a body that the adapter has prepared, adding
+// the call's extra body fields.
+func (c *Client) marshalRequestBody(body any) ([]byte, error) {
+	reqBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
+	if len(c.config.extraBody) > 0 {
+		return mergeExtraBody(reqBody, c.config.extraBody)
+	}
+	return reqBody, nil
+}
 
-	var reqBody []byte
+// newRequestWithBody builds a request to fullURL whose body is reqBody, as
+// returned by prepareRequestBody for body.
+func (c *Client) newRequestWithBody(
+	ctx context.Context,
+	method, fullURL string,
+	body any,
+	reqBody []byte,
+	requestSetters ...requestSetter,
+) (req *http.Request, err error) {
+	var getBody func() (io.ReadCloser, error)
 	if body != nil {
-		reqBody, err = json.Marshal(body)
+		getBody, err = streamedBody(body, reqBody)
 		if err != nil {
 			return nil, err
 		}
@@ -145,6 +185,21 @@ func (c *Client) newRequest(
 	for _, setter := range requestSetters {
 		setter(req)
 	}
+	for key, values := range c.config.header {
+		req.Header[key] = slices.Clone(values)
+	}
+
+	// Base64Stream sources are encoded lazily while the body is sent; getBody
+	// starts a fresh encoding for every attempt. It is called last because it
+	// opens files and starts the encoder, which nothing would stop if a later
+	// step failed.
+	if getBody != nil {
+		if req.Body, err = getBody(); err != nil {
+			return nil, err
+		}
+		req.GetBody = getBody
+		req.ContentLength = -1
+	}
 
 	return req, nil
 }
@@ -161,9 +216,12 @@ func (c *Client) newStreamRequest(
 		return nil, err
 	}
 
+	setStreamHeaders(req)
+	return req, nil
+}
+
+func setStreamHeaders(req *http.Request) {
 	req.Header.Set("Accept", "text/event-stream")
 	req.Header.Set("Cache-Control", "no-cache")
 	req.Header.Set("Connection", "keep-alive")
-
-	return req, nil
 }
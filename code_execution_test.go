@@ -0,0 +1,206 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+const codeExecutionResponseContent = `[
+	{
+		"type": "server_tool_use",
+		"id": "srvtoolu_1",
+		"name": "bash_code_execution",
+		"input": {"command": "python plot.py"}
+	},
+	{
+		"type": "bash_code_execution_tool_result",
+		"tool_use_id": "srvtoolu_1",
+		"content": {
+			"type": "bash_code_execution_result",
+			"stdout": "done\n",
+			"stderr": "",
+			"return_code": 0,
+			"content": [{"type": "bash_code_execution_output", "file_id": "file_abc"}]
+		}
+	},
+	{
+		"type": "text_editor_code_execution_tool_result",
+		"tool_use_id": "srvtoolu_2",
+		"content": {
+			"type": "text_editor_code_execution_result",
+			"file_type": "text",
+			"content": "print('hi')",
+			"numLines": 1,
+			"startLine": 1,
+			"totalLines": 1
+		}
+	},
+	{
+		"type": "code_execution_tool_result",
+		"tool_use_id": "srvtoolu_3",
+		"content": {
+			"type": "code_execution_tool_result_error",
+			"error_code": "unavailable"
+		}
+	}
+]`
+
+func TestCodeExecutionResultBlocks(t *testing.T) {
+	var content []anthropic.MessageContent
+	if err := json.Unmarshal([]byte(codeExecutionResponseContent), &content); err != nil {
+		t.Fatal(err)
+	}
+
+	bash := content[1].MessageContentCodeExecutionToolResult
+	if bash == nil || bash.Content == nil {
+		t.Fatalf("bash_code_execution_tool_result was not decoded: %+v", content[1])
+	}
+	if *bash.ToolUseID != "srvtoolu_1" || bash.Content.Stdout != "done\n" ||
+		bash.Content.ReturnCode == nil || *bash.Content.ReturnCode != 0 ||
+		bash.Content.IsError() {
+		t.Fatalf("unexpected bash result %+v", bash.Content)
+	}
+	if ids := bash.Content.FileIDs(); !reflect.DeepEqual(ids, []string{"file_abc"}) {
+		t.Fatalf("unexpected file IDs %v", ids)
+	}
+
+	editor := content[2].MessageContentTextEditorCodeExecutionToolResult
+	if editor == nil || editor.Content.FileContent != "print('hi')" ||
+		*editor.Content.TotalLines != 1 {
+		t.Fatalf("unexpected text editor result %+v", content[2])
+	}
+
+	failed := content[3].MessageContentCodeExecutionToolResult
+	if !failed.Content.IsError() || failed.Content.ErrorCode != "unavailable" {
+		t.Fatalf("unexpected error result %+v", failed.Content)
+	}
+
+	// The blocks are sent back verbatim in the next turn.
+	for _, c := range content[1:] {
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roundTrip anthropic.MessageContent
+		if err := json.Unmarshal(b, &roundTrip); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(roundTrip, c) {
+			t.Fatalf("round trip mismatch:\n got %+v\nwant %+v\njson %s", roundTrip, c, b)
+		}
+	}
+
+	b, err := json.Marshal(anthropic.NewCodeExecutionToolResultContent(
+		anthropic.MessagesContentTypeBashCodeExecutionToolResult,
+		"srvtoolu_9",
+		anthropic.CodeExecutionResult{
+			Type:   anthropic.CodeExecutionResultTypeBash,
+			Stdout: "ok",
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"tool_use_id":"srvtoolu_9"`, `"stdout":"ok"`, `"return_code":0`} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("marshal missing %q: %s", want, b)
+		}
+	}
+}
+
+func TestCodeExecutionContainer(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		var req anthropic.MessagesRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Container == nil || *req.Container != "container_1" {
+			http.Error(w, "missing container", http.StatusBadRequest)
+			return
+		}
+		if got := r.Header.Get("anthropic-beta"); got != "code-execution-2025-08-25" {
+			http.Error(w, "missing beta header: "+got, http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"id":"1","type":"message","role":"assistant",` +
+				`"content":[],"model":"claude-sonnet-4-5","usage":{"input_tokens":1,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":` +
+				`{"type":"bash_code_execution_tool_result","tool_use_id":"srvtoolu_1","content":` +
+				`{"type":"bash_code_execution_result","stdout":"42\n","stderr":"","return_code":0}}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn","container":` +
+				`{"id":"container_1","expires_at":"2025-09-01T12:00:00Z"}},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			var typed struct {
+				Type string `json:"type"`
+			}
+			_ = json.Unmarshal([]byte(e), &typed)
+			_, _ = w.Write([]byte("event: " + typed.Type + "\ndata: " + e + "\n\n"))
+		}
+	})
+
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithBetaVersion(anthropic.BetaCodeExecution20250825),
+	)
+
+	resp, err := client.CreateMessagesStream(context.Background(), anthropic.MessagesStreamRequest{
+		MessagesRequest: anthropic.MessagesRequest{
+			Model:     anthropic.ModelClaude3Haiku20240307,
+			Messages:  []anthropic.Message{anthropic.NewUserTextMessage("What is 6*7?")},
+			MaxTokens: 1000,
+			Tools:     []anthropic.ToolDefinition{anthropic.NewCodeExecutionToolDefinition()},
+			Container: toPtr("container_1"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessagesStream error: %v", err)
+	}
+
+	if resp.Container == nil || resp.Container.ID != "container_1" ||
+		resp.Container.ExpiresAt.IsZero() {
+		t.Fatalf("unexpected container %+v", resp.Container)
+	}
+	result := resp.Content[0].MessageContentCodeExecutionToolResult
+	if result == nil || result.Content.Stdout != "42\n" {
+		t.Fatalf("unexpected content %+v", resp.Content[0])
+	}
+}
+
+func TestCodeExecutionResultMarshalMatchesAPI(t *testing.T) {
+	for _, data := range []string{
+		`{"type":"code_execution_tool_result_error","error_code":"unavailable"}`,
+		`{"type":"bash_code_execution_result","stdout":"ok\n","stderr":"","return_code":0,` +
+			`"content":[]}`,
+		`{"type":"code_execution_result","stdout":"","stderr":"boom","return_code":1,` +
+			`"content":[{"type":"code_execution_output","file_id":"file_1"}]}`,
+	} {
+		var result anthropic.CodeExecutionResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != data {
+			t.Errorf("round trip changed the result\n got %s\nwant %s", got, data)
+		}
+	}
+}
@@ -11,9 +11,9 @@ const (
 	ModelClaude3Opus20240229 Model = "claude-3-opus-20240229"
 	// Deprecated: claude-3-sonnet-20240229 was retired on July 21, 2025; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
 	ModelClaude3Sonnet20240229 Model = "claude-3-sonnet-20240229"
-	// Deprecated: claude-3-5-sonnet-20240620 was retired on October 28, 2025; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
+	// Deprecated: claude-3-5-sonnet-20240620 was retired on October 22, 2025; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
 	ModelClaude3Dot5Sonnet20240620 Model = "claude-3-5-sonnet-20240620"
-	// Deprecated: claude-3-5-sonnet-20241022 was retired on October 28, 2025; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
+	// Deprecated: claude-3-5-sonnet-20241022 was retired on October 22, 2025; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
 	ModelClaude3Dot5Sonnet20241022 Model = "claude-3-5-sonnet-20241022"
 	// Deprecated: the claude-3-5-sonnet-latest alias pointed at a now-retired Claude 3.5 Sonnet snapshot; requests now fail. Use ModelClaudeSonnet4Dot6 or ModelClaudeSonnet5.
 	ModelClaude3Dot5SonnetLatest Model = "claude-3-5-sonnet-latest"
@@ -60,50 +60,3 @@ const (
 	RoleUser      ChatRole = "user"
 	RoleAssistant ChatRole = "assistant"
 )
-
-func (m Model) asVertexModel() string {
-	switch m {
-	case ModelClaude3Opus20240229:
-		return "claude-3-opus@20240229"
-	case ModelClaude3Sonnet20240229:
-		return "claude-3-sonnet@20240229"
-	case ModelClaude3Dot5Sonnet20240620:
-		return "claude-3-5-sonnet@20240620"
-	case ModelClaude3Dot5Sonnet20241022:
-		return "claude-3-5-sonnet-v2@20241022"
-	case ModelClaude3Dot7Sonnet20250219:
-		return "claude-3-7-sonnet@20250219"
-	case ModelClaude3Haiku20240307:
-		return "claude-3-haiku@20240307"
-	case ModelClaude3Dot5Haiku20241022:
-		return "claude-3-5-haiku@20241022"
-	case ModelClaudeHaiku4Dot5, ModelClaudeHaiku4Dot5V20251001:
-		return "claude-haiku-4-5@20251001"
-	case ModelClaudeOpus4Dot0, ModelClaudeOpus4V20250514:
-		return "claude-opus-4@20250514"
-	case ModelClaudeOpus4Dot1, ModelClaudeOpus4Dot1V20250805:
-		return "claude-opus-4-1@20250805"
-	case ModelClaudeSonnet4Dot0, ModelClaudeSonnet4V20250514:
-		return "claude-sonnet-4@20250514"
-	case ModelClaudeSonnet4Dot5, ModelClaudeSonnet4Dot5V20250929:
-		return "claude-sonnet-4-5@20250929"
-	case ModelClaudeOpus4Dot5V20251101, ModelClaudeOpus4Dot5:
-		return "claude-opus-4-5@20251101"
-	case ModelClaudeSonnet4Dot6:
-		return "claude-sonnet-4-6"
-	case ModelClaudeOpus4Dot6:
-		return "claude-opus-4-6"
-	case ModelClaudeOpus4Dot7:
-		return "claude-opus-4-7"
-	case ModelClaudeOpus4Dot8:
-		return "claude-opus-4-8"
-	case ModelClaudeSonnet5:
-		return "claude-sonnet-5"
-	case ModelClaudeFable5:
-		return "claude-fable-5"
-	case ModelClaudeMythos5:
-		return "claude-mythos-5"
-	default:
-		return string(m)
-	}
-}
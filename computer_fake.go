@@ -0,0 +1,145 @@
+package anthropic
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// FakeComputerEvent is a primitive operation recorded by FakeComputer.
+type FakeComputerEvent struct {
+	// Op is the ComputerExecutor method name, such as "MouseMove" or "KeyDown".
+	Op string
+	// Args holds the formatted arguments, such as "100,200" or "left x2".
+	Args string
+}
+
+func (e FakeComputerEvent) String() string {
+	if e.Args == "" {
+		return e.Op
+	}
+	return e.Op + " " + e.Args
+}
+
+// FakeComputer is an in-memory ComputerExecutor. It tracks the cursor,
+// records every operation and returns a solid-color screenshot, which makes
+// computer use agent loops testable without a real display.
+type FakeComputer struct {
+	width  int
+	height int
+
+	mu     sync.Mutex
+	x, y   int
+	events []FakeComputerEvent
+	screen image.Image
+}
+
+var _ ComputerExecutor = (*FakeComputer)(nil)
+
+func NewFakeComputer(width, height int) *FakeComputer {
+	screen := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(screen, screen.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	return &FakeComputer{
+		width:  width,
+		height: height,
+		screen: screen,
+	}
+}
+
+// SetScreen replaces the image returned by Screenshot.
+func (f *FakeComputer) SetScreen(img image.Image) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.screen = img
+}
+
+// Events returns the operations recorded so far.
+func (f *FakeComputer) Events() []FakeComputerEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeComputerEvent(nil), f.events...)
+}
+
+// Reset clears the recorded operations.
+func (f *FakeComputer) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = nil
+}
+
+func (f *FakeComputer) DisplaySize() (int, int) {
+	return f.width, f.height
+}
+
+func (f *FakeComputer) Screenshot(ctx context.Context) (image.Image, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("Screenshot", "")
+	return f.screen, nil
+}
+
+func (f *FakeComputer) CursorPosition(ctx context.Context) (int, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("CursorPosition", "")
+	return f.x, f.y, nil
+}
+
+func (f *FakeComputer) MouseMove(ctx context.Context, x, y int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if x < 0 || y < 0 || x >= f.width || y >= f.height {
+		return fmt.Errorf("coordinate (%d, %d) is outside of the display", x, y)
+	}
+	f.x, f.y = x, y
+	f.record("MouseMove", fmt.Sprintf("%d,%d", x, y))
+	return nil
+}
+
+func (f *FakeComputer) MouseDown(ctx context.Context, button MouseButton) error {
+	return f.recordLocked("MouseDown", string(button))
+}
+
+func (f *FakeComputer) MouseUp(ctx context.Context, button MouseButton) error {
+	return f.recordLocked("MouseUp", string(button))
+}
+
+func (f *FakeComputer) Click(ctx context.Context, button MouseButton, count int) error {
+	return f.recordLocked("Click", fmt.Sprintf("%s x%d", button, count))
+}
+
+func (f *FakeComputer) Scroll(ctx context.Context, direction ScrollDirection, amount int) error {
+	return f.recordLocked("Scroll", fmt.Sprintf("%s x%d", direction, amount))
+}
+
+func (f *FakeComputer) KeyPress(ctx context.Context, keys string) error {
+	return f.recordLocked("KeyPress", keys)
+}
+
+func (f *FakeComputer) KeyDown(ctx context.Context, key string) error {
+	return f.recordLocked("KeyDown", key)
+}
+
+func (f *FakeComputer) KeyUp(ctx context.Context, key string) error {
+	return f.recordLocked("KeyUp", key)
+}
+
+func (f *FakeComputer) Type(ctx context.Context, text string) error {
+	return f.recordLocked("Type", text)
+}
+
+func (f *FakeComputer) recordLocked(op, args string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record(op, args)
+	return nil
+}
+
+// record appends an event. f.mu must be held.
+func (f *FakeComputer) record(op, args string) {
+	f.events = append(f.events, FakeComputerEvent{Op: op, Args: args})
+}
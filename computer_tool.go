@@ -0,0 +1,620 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+)
+
+type ComputerActionType string
+
+const (
+	ComputerActionKey            ComputerActionType = "key"
+	ComputerActionTypeText       ComputerActionType = "type"
+	ComputerActionMouseMove      ComputerActionType = "mouse_move"
+	ComputerActionLeftClick      ComputerActionType = "left_click"
+	ComputerActionLeftClickDrag  ComputerActionType = "left_click_drag"
+	ComputerActionRightClick     ComputerActionType = "right_click"
+	ComputerActionMiddleClick    ComputerActionType = "middle_click"
+	ComputerActionDoubleClick    ComputerActionType = "double_click"
+	ComputerActionScreenshot     ComputerActionType = "screenshot"
+	ComputerActionCursorPosition ComputerActionType = "cursor_position"
+
+	// Actions added by computer_20250124.
+	ComputerActionScroll        ComputerActionType = "scroll"
+	ComputerActionTripleClick   ComputerActionType = "triple_click"
+	ComputerActionLeftMouseDown ComputerActionType = "left_mouse_down"
+	ComputerActionLeftMouseUp   ComputerActionType = "left_mouse_up"
+	ComputerActionHoldKey       ComputerActionType = "hold_key"
+	ComputerActionWait          ComputerActionType = "wait"
+)
+
+var computer20241022Actions = map[ComputerActionType]bool{
+	ComputerActionKey:            true,
+	ComputerActionTypeText:       true,
+	ComputerActionMouseMove:      true,
+	ComputerActionLeftClick:      true,
+	ComputerActionLeftClickDrag:  true,
+	ComputerActionRightClick:     true,
+	ComputerActionMiddleClick:    true,
+	ComputerActionDoubleClick:    true,
+	ComputerActionScreenshot:     true,
+	ComputerActionCursorPosition: true,
+}
+
+type ScrollDirection string
+
+const (
+	ScrollDirectionUp    ScrollDirection = "up"
+	ScrollDirectionDown  ScrollDirection = "down"
+	ScrollDirectionLeft  ScrollDirection = "left"
+	ScrollDirectionRight ScrollDirection = "right"
+)
+
+type MouseButton string
+
+const (
+	MouseButtonLeft   MouseButton = "left"
+	MouseButtonRight  MouseButton = "right"
+	MouseButtonMiddle MouseButton = "middle"
+)
+
+// ComputerCoordinate is a pixel position, encoded as [x, y] on the wire.
+type ComputerCoordinate struct {
+	X int
+	Y int
+}
+
+func (c ComputerCoordinate) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]int{c.X, c.Y})
+}
+
+func (c *ComputerCoordinate) UnmarshalJSON(data []byte) error {
+	var xy []float64
+	if err := json.Unmarshal(data, &xy); err != nil {
+		return err
+	}
+	if len(xy) != 2 {
+		return fmt.Errorf("coordinate must be a list of two integers, got %s", data)
+	}
+	c.X, c.Y = int(math.Round(xy[0])), int(math.Round(xy[1]))
+	return nil
+}
+
+// ComputerToolInput is the raw input of the computer use tool. Use Decode to
+// get a typed ComputerAction.
+type ComputerToolInput struct {
+	Action          ComputerActionType  `json:"action"`
+	Coordinate      *ComputerCoordinate `json:"coordinate,omitempty"`
+	StartCoordinate *ComputerCoordinate `json:"start_coordinate,omitempty"`
+	Text            *string             `json:"text,omitempty"`
+	ScrollDirection ScrollDirection     `json:"scroll_direction,omitempty"`
+	ScrollAmount    *int                `json:"scroll_amount,omitempty"`
+	Duration        *float64            `json:"duration,omitempty"`
+}
+
+// ComputerAction is implemented by every typed computer use action.
+type ComputerAction interface {
+	ActionType() ComputerActionType
+}
+
+type ComputerScreenshotAction struct{}
+
+type ComputerCursorPositionAction struct{}
+
+// ComputerKeyAction presses a key or key combination in xdotool syntax,
+// such as "Return" or "ctrl+s".
+type ComputerKeyAction struct {
+	Keys string
+}
+
+type ComputerTypeAction struct {
+	Text string
+}
+
+type ComputerMouseMoveAction struct {
+	Coordinate ComputerCoordinate
+}
+
+// ComputerClickAction covers left, right, middle, double and triple clicks.
+// Coordinate is nil when the click happens at the current cursor position.
+type ComputerClickAction struct {
+	Action     ComputerActionType
+	Coordinate *ComputerCoordinate
+	// Modifiers are keys held during the click, such as "shift" or "ctrl+alt".
+	Modifiers string
+}
+
+// Button returns the mouse button pressed by the click.
+func (a ComputerClickAction) Button() MouseButton {
+	switch a.Action {
+	case ComputerActionRightClick:
+		return MouseButtonRight
+	case ComputerActionMiddleClick:
+		return MouseButtonMiddle
+	default:
+		return MouseButtonLeft
+	}
+}
+
+// Count returns the number of clicks.
+func (a ComputerClickAction) Count() int {
+	switch a.Action {
+	case ComputerActionDoubleClick:
+		return 2
+	case ComputerActionTripleClick:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// ComputerMouseButtonAction is a left_mouse_down or left_mouse_up action.
+type ComputerMouseButtonAction struct {
+	Action     ComputerActionType
+	Coordinate *ComputerCoordinate
+}
+
+// ComputerDragAction drags with the left button held. Start is nil when the
+// drag begins at the current cursor position.
+type ComputerDragAction struct {
+	Start *ComputerCoordinate
+	End   ComputerCoordinate
+}
+
+type ComputerScrollAction struct {
+	Coordinate *ComputerCoordinate
+	Direction  ScrollDirection
+	Amount     int
+	Modifiers  string
+}
+
+type ComputerHoldKeyAction struct {
+	Key      string
+	Duration time.Duration
+}
+
+type ComputerWaitAction struct {
+	Duration time.Duration
+}
+
+func (ComputerScreenshotAction) ActionType() ComputerActionType {
+	return ComputerActionScreenshot
+}
+
+func (ComputerCursorPositionAction) ActionType() ComputerActionType {
+	return ComputerActionCursorPosition
+}
+
+func (ComputerKeyAction) ActionType() ComputerActionType {
+	return ComputerActionKey
+}
+
+func (ComputerTypeAction) ActionType() ComputerActionType {
+	return ComputerActionTypeText
+}
+
+func (ComputerMouseMoveAction) ActionType() ComputerActionType {
+	return ComputerActionMouseMove
+}
+
+func (a ComputerClickAction) ActionType() ComputerActionType {
+	return a.Action
+}
+
+func (a ComputerMouseButtonAction) ActionType() ComputerActionType {
+	return a.Action
+}
+
+func (ComputerDragAction) ActionType() ComputerActionType {
+	return ComputerActionLeftClickDrag
+}
+
+func (ComputerScrollAction) ActionType() ComputerActionType {
+	return ComputerActionScroll
+}
+
+func (ComputerHoldKeyAction) ActionType() ComputerActionType {
+	return ComputerActionHoldKey
+}
+
+func (ComputerWaitAction) ActionType() ComputerActionType {
+	return ComputerActionWait
+}
+
+// Decode validates the input against the given tool version
+// (ToolTypeComputer20241022 or ToolTypeComputer20250124) and returns the
+// typed action.
+func (in ComputerToolInput) Decode(toolType string) (ComputerAction, error) {
+	if toolType == ToolTypeComputer20241022 && !computer20241022Actions[in.Action] {
+		return nil, fmt.Errorf("action %s is not supported by %s", in.Action, toolType)
+	}
+
+	text := func() (string, error) {
+		if in.Text == nil || *in.Text == "" {
+			return "", fmt.Errorf("text is required for %s", in.Action)
+		}
+		return *in.Text, nil
+	}
+	coordinate := func() (ComputerCoordinate, error) {
+		if in.Coordinate == nil {
+			return ComputerCoordinate{}, fmt.Errorf("coordinate is required for %s", in.Action)
+		}
+		return *in.Coordinate, nil
+	}
+	modifiers := func() string {
+		if in.Text == nil {
+			return ""
+		}
+		return *in.Text
+	}
+	duration := func() (time.Duration, error) {
+		if in.Duration == nil || *in.Duration < 0 || *in.Duration > 100 {
+			return 0, fmt.Errorf("duration must be a number between 0 and 100 for %s", in.Action)
+		}
+		return time.Duration(*in.Duration * float64(time.Second)), nil
+	}
+
+	switch in.Action {
+	case ComputerActionScreenshot:
+		return ComputerScreenshotAction{}, nil
+	case ComputerActionCursorPosition:
+		return ComputerCursorPositionAction{}, nil
+	case ComputerActionKey:
+		keys, err := text()
+		return ComputerKeyAction{Keys: keys}, err
+	case ComputerActionTypeText:
+		t, err := text()
+		return ComputerTypeAction{Text: t}, err
+	case ComputerActionMouseMove:
+		c, err := coordinate()
+		return ComputerMouseMoveAction{Coordinate: c}, err
+	case ComputerActionLeftClick,
+		ComputerActionRightClick,
+		ComputerActionMiddleClick,
+		ComputerActionDoubleClick,
+		ComputerActionTripleClick:
+		return ComputerClickAction{
+			Action:     in.Action,
+			Coordinate: in.Coordinate,
+			Modifiers:  modifiers(),
+		}, nil
+	case ComputerActionLeftMouseDown, ComputerActionLeftMouseUp:
+		return ComputerMouseButtonAction{Action: in.Action, Coordinate: in.Coordinate}, nil
+	case ComputerActionLeftClickDrag:
+		// computer_20241022 sends only the end coordinate and drags from the
+		// cursor; computer_20250124 also sends start_coordinate.
+		end, err := coordinate()
+		return ComputerDragAction{Start: in.StartCoordinate, End: end}, err
+	case ComputerActionScroll:
+		if in.ScrollDirection == "" {
+			return nil, errors.New("scroll_direction is required for scroll")
+		}
+		amount := 1
+		if in.ScrollAmount != nil {
+			if *in.ScrollAmount < 0 {
+				return nil, errors.New("scroll_amount must be a non-negative integer")
+			}
+			amount = *in.ScrollAmount
+		}
+		return ComputerScrollAction{
+			Coordinate: in.Coordinate,
+			Direction:  in.ScrollDirection,
+			Amount:     amount,
+			Modifiers:  modifiers(),
+		}, nil
+	case ComputerActionHoldKey:
+		key, err := text()
+		if err != nil {
+			return nil, err
+		}
+		d, err := duration()
+		return ComputerHoldKeyAction{Key: key, Duration: d}, err
+	case ComputerActionWait:
+		d, err := duration()
+		return ComputerWaitAction{Duration: d}, err
+	}
+
+	return nil, fmt.Errorf("invalid action: %s", in.Action)
+}
+
+// DecodeComputerAction decodes the input of a computer use tool_use block.
+func DecodeComputerAction(toolType string, input json.RawMessage) (ComputerAction, error) {
+	var in ComputerToolInput
+	if err := json.Unmarshal(input, &in); err != nil {
+		return nil, err
+	}
+	return in.Decode(toolType)
+}
+
+// ComputerExecutor performs primitive input and capture operations on a real
+// or virtual display. All coordinates are in the display's own pixels;
+// ComputerTool takes care of scaling from the size declared to the model.
+type ComputerExecutor interface {
+	// DisplaySize returns the real resolution of the display.
+	DisplaySize() (width, height int)
+	Screenshot(ctx context.Context) (image.Image, error)
+	CursorPosition(ctx context.Context) (x, y int, err error)
+	MouseMove(ctx context.Context, x, y int) error
+	MouseDown(ctx context.Context, button MouseButton) error
+	MouseUp(ctx context.Context, button MouseButton) error
+	Click(ctx context.Context, button MouseButton, count int) error
+	Scroll(ctx context.Context, direction ScrollDirection, amount int) error
+	// KeyPress presses and releases a key combination in xdotool syntax.
+	KeyPress(ctx context.Context, keys string) error
+	KeyDown(ctx context.Context, key string) error
+	KeyUp(ctx context.Context, key string) error
+	Type(ctx context.Context, text string) error
+}
+
+const (
+	// Claude works best with screenshots no larger than WXGA.
+	defaultComputerMaxWidth  = 1280
+	defaultComputerMaxHeight = 800
+)
+
+// ComputerTool runs computer use actions against a ComputerExecutor. The
+// model sees a display of Width x Height pixels; coordinates and screenshots
+// are scaled between that size and the executor's real resolution.
+type ComputerTool struct {
+	executor        ComputerExecutor
+	toolType        string
+	width           int
+	height          int
+	displayNumber   *int
+	screenshotDelay time.Duration
+	sleep           func(ctx context.Context, d time.Duration) error
+}
+
+type ComputerToolOption func(t *ComputerTool)
+
+// WithComputerToolType selects the tool version, ToolTypeComputer20250124 by
+// default.
+func WithComputerToolType(toolType string) ComputerToolOption {
+	return func(t *ComputerTool) {
+		t.toolType = toolType
+	}
+}
+
+// WithComputerDisplaySize sets the resolution declared to the model. By
+// default the real resolution is scaled down to fit within 1280x800.
+func WithComputerDisplaySize(width, height int) ComputerToolOption {
+	return func(t *ComputerTool) {
+		t.width = width
+		t.height = height
+	}
+}
+
+func WithComputerDisplayNumber(displayNumber int) ComputerToolOption {
+	return func(t *ComputerTool) {
+		t.displayNumber = &displayNumber
+	}
+}
+
+// WithComputerScreenshotDelay waits before the screenshot taken after every
+// action, giving the UI time to settle.
+func WithComputerScreenshotDelay(delay time.Duration) ComputerToolOption {
+	return func(t *ComputerTool) {
+		t.screenshotDelay = delay
+	}
+}
+
+func NewComputerTool(executor ComputerExecutor, opts ...ComputerToolOption) *ComputerTool {
+	t := &ComputerTool{
+		executor: executor,
+		toolType: ToolTypeComputer20250124,
+		sleep:    sleepContext,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	if t.width == 0 || t.height == 0 {
+		realW, realH := executor.DisplaySize()
+		t.width, t.height = fitWithin(
+			realW,
+			realH,
+			defaultComputerMaxWidth,
+			defaultComputerMaxHeight,
+		)
+	}
+
+	return t
+}
+
+// ToolDefinition returns the definition declaring this tool to the model.
+func (t *ComputerTool) ToolDefinition(name string) ToolDefinition {
+	def := NewComputerUseToolDefinition(name, t.width, t.height, t.displayNumber)
+	def.Type = t.toolType
+	return def
+}
+
+// Execute performs the action and returns the content of its tool_result:
+// the cursor position as text for cursor_position, a screenshot otherwise.
+func (t *ComputerTool) Execute(
+	ctx context.Context,
+	action ComputerAction,
+) ([]MessageContent, error) {
+	e := t.executor
+
+	var err error
+	switch a := action.(type) {
+	case ComputerScreenshotAction:
+		return t.screenshot(ctx)
+	case ComputerCursorPositionAction:
+		x, y, err := e.CursorPosition(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mx, my := t.toModel(x, y)
+		return []MessageContent{NewTextMessageContent(fmt.Sprintf("X=%d,Y=%d", mx, my))}, nil
+	case ComputerKeyAction:
+		err = e.KeyPress(ctx, a.Keys)
+	case ComputerTypeAction:
+		err = e.Type(ctx, a.Text)
+	case ComputerMouseMoveAction:
+		err = t.moveTo(ctx, &a.Coordinate)
+	case ComputerClickAction:
+		err = t.moveTo(ctx, a.Coordinate)
+		if err == nil {
+			err = t.withModifiers(ctx, a.Modifiers, func() error {
+				return e.Click(ctx, a.Button(), a.Count())
+			})
+		}
+	case ComputerMouseButtonAction:
+		err = t.moveTo(ctx, a.Coordinate)
+		if err == nil && a.Action == ComputerActionLeftMouseDown {
+			err = e.MouseDown(ctx, MouseButtonLeft)
+		} else if err == nil {
+			err = e.MouseUp(ctx, MouseButtonLeft)
+		}
+	case ComputerDragAction:
+		err = t.drag(ctx, a)
+	case ComputerScrollAction:
+		err = t.moveTo(ctx, a.Coordinate)
+		if err == nil {
+			err = t.withModifiers(ctx, a.Modifiers, func() error {
+				return e.Scroll(ctx, a.Direction, a.Amount)
+			})
+		}
+	case ComputerHoldKeyAction:
+		err = t.withModifiers(ctx, a.Key, func() error {
+			return t.sleep(ctx, a.Duration)
+		})
+	case ComputerWaitAction:
+		err = t.sleep(ctx, a.Duration)
+	default:
+		return nil, fmt.Errorf("invalid action: %s", action.ActionType())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.sleep(ctx, t.screenshotDelay); err != nil {
+		return nil, err
+	}
+	return t.screenshot(ctx)
+}
+
+// HandleToolUse executes a computer use tool_use block and returns its
+// tool_result.
+func (t *ComputerTool) HandleToolUse(
+	ctx context.Context,
+	toolUse MessageContentToolUse,
+) MessageContent {
+	content, err := t.ToolFunc()(ctx, toolUse)
+	if err != nil {
+		return NewToolResultMessageContent(toolUse.ID, err.Error(), true)
+	}
+	return NewToolResultBlocksMessageContent(toolUse.ID, content, false)
+}
+
+// ToolFunc adapts the tool to a ToolFunc for use with a ToolExecutor.
+func (t *ComputerTool) ToolFunc() ToolFunc {
+	return func(ctx context.Context, toolUse MessageContentToolUse) ([]MessageContent, error) {
+		action, err := DecodeComputerAction(t.toolType, toolUse.Input)
+		if err != nil {
+			return nil, err
+		}
+		return t.Execute(ctx, action)
+	}
+}
+
+func (t *ComputerTool) screenshot(ctx context.Context) ([]MessageContent, error) {
+	img, err := t.executor.Screenshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if b := img.Bounds(); b.Dx() != t.width || b.Dy() != t.height {
+		img = resizeImage(img, t.width, t.height)
+	}
+
+	content, err := NewPNGImageMessageContent(img)
+	if err != nil {
+		return nil, err
+	}
+	return []MessageContent{content}, nil
+}
+
+func (t *ComputerTool) moveTo(ctx context.Context, c *ComputerCoordinate) error {
+	if c == nil {
+		return nil
+	}
+	if c.X < 0 || c.Y < 0 || c.X >= t.width || c.Y >= t.height {
+		return fmt.Errorf("coordinate (%d, %d) is out of bounds", c.X, c.Y)
+	}
+	x, y := t.toReal(c.X, c.Y)
+	return t.executor.MouseMove(ctx, x, y)
+}
+
+func (t *ComputerTool) drag(ctx context.Context, a ComputerDragAction) error {
+	if err := t.moveTo(ctx, a.Start); err != nil {
+		return err
+	}
+	if err := t.executor.MouseDown(ctx, MouseButtonLeft); err != nil {
+		return err
+	}
+	if err := t.moveTo(ctx, &a.End); err != nil {
+		_ = t.executor.MouseUp(ctx, MouseButtonLeft)
+		return err
+	}
+	return t.executor.MouseUp(ctx, MouseButtonLeft)
+}
+
+// withModifiers holds every key of a "+" separated combination while fn runs.
+func (t *ComputerTool) withModifiers(ctx context.Context, keys string, fn func() error) error {
+	var held []string
+	defer func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			_ = t.executor.KeyUp(ctx, held[i])
+		}
+	}()
+
+	if keys != "" {
+		for _, key := range strings.Split(keys, "+") {
+			if err := t.executor.KeyDown(ctx, key); err != nil {
+				return err
+			}
+			held = append(held, key)
+		}
+	}
+	return fn()
+}
+
+func (t *ComputerTool) toReal(x, y int) (int, int) {
+	realW, realH := t.executor.DisplaySize()
+	return scaleCoordinate(x, t.width, realW), scaleCoordinate(y, t.height, realH)
+}
+
+func (t *ComputerTool) toModel(x, y int) (int, int) {
+	realW, realH := t.executor.DisplaySize()
+	return scaleCoordinate(x, realW, t.width), scaleCoordinate(y, realH, t.height)
+}
+
+func scaleCoordinate(v, from, to int) int {
+	if from == 0 || from == to {
+		return v
+	}
+	return int(math.Round(float64(v) * float64(to) / float64(from)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
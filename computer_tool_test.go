@@ -0,0 +1,225 @@
+package anthropic_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestDecodeComputerAction(t *testing.T) {
+	tests := []struct {
+		name     string
+		toolType string
+		input    string
+		want     anthropic.ComputerAction
+		wantErr  string
+	}{
+		{
+			name:     "screenshot",
+			toolType: anthropic.ToolTypeComputer20241022,
+			input:    `{"action":"screenshot"}`,
+			want:     anthropic.ComputerScreenshotAction{},
+		},
+		{
+			name:     "left click with modifiers",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"left_click","coordinate":[10,20],"text":"shift"}`,
+			want: anthropic.ComputerClickAction{
+				Action:     anthropic.ComputerActionLeftClick,
+				Coordinate: &anthropic.ComputerCoordinate{X: 10, Y: 20},
+				Modifiers:  "shift",
+			},
+		},
+		{
+			name:     "type",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"type","text":"hello"}`,
+			want:     anthropic.ComputerTypeAction{Text: "hello"},
+		},
+		{
+			name:     "scroll defaults amount",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"scroll","coordinate":[1,2],"scroll_direction":"down"}`,
+			want: anthropic.ComputerScrollAction{
+				Coordinate: &anthropic.ComputerCoordinate{X: 1, Y: 2},
+				Direction:  anthropic.ScrollDirectionDown,
+				Amount:     1,
+			},
+		},
+		{
+			name:     "drag",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"left_click_drag","start_coordinate":[1,2],"coordinate":[3,4]}`,
+			want: anthropic.ComputerDragAction{
+				Start: &anthropic.ComputerCoordinate{X: 1, Y: 2},
+				End:   anthropic.ComputerCoordinate{X: 3, Y: 4},
+			},
+		},
+		{
+			name:     "scroll is not in the 2024 version",
+			toolType: anthropic.ToolTypeComputer20241022,
+			input:    `{"action":"scroll","scroll_direction":"down"}`,
+			wantErr:  "action scroll is not supported by computer_20241022",
+		},
+		{
+			name:     "key requires text",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"key"}`,
+			wantErr:  "text is required for key",
+		},
+		{
+			name:     "wait bounds duration",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"wait","duration":1000}`,
+			wantErr:  "duration must be a number between 0 and 100 for wait",
+		},
+		{
+			name:     "unknown action",
+			toolType: anthropic.ToolTypeComputer20250124,
+			input:    `{"action":"dance"}`,
+			wantErr:  "invalid action: dance",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := anthropic.DecodeComputerAction(tt.toolType, json.RawMessage(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputerTool(t *testing.T) {
+	ctx := context.Background()
+	// A 2560x1600 display is presented to the model as 1280x800.
+	fake := anthropic.NewFakeComputer(2560, 1600)
+	tool := anthropic.NewComputerTool(fake)
+
+	def := tool.ToolDefinition("computer")
+	if def.Type != anthropic.ToolTypeComputer20250124 ||
+		def.DisplayWidthPx != 1280 || def.DisplayHeightPx != 800 {
+		t.Fatalf("unexpected definition %+v", def)
+	}
+
+	t.Run("scales coordinates and returns a screenshot", func(t *testing.T) {
+		fake.Reset()
+		result := tool.HandleToolUse(ctx, *anthropic.NewMessageContentToolUse(
+			"toolu_1",
+			"computer",
+			json.RawMessage(`{"action":"double_click","coordinate":[100,50],"text":"ctrl"}`),
+		))
+		if *result.IsError {
+			t.Fatalf("unexpected error %q", result.MessageContentToolResult.Content[0].GetText())
+		}
+
+		var events []string
+		for _, e := range fake.Events() {
+			events = append(events, e.String())
+		}
+		want := []string{
+			"MouseMove 200,100",
+			"KeyDown ctrl",
+			"Click left x2",
+			"KeyUp ctrl",
+			"Screenshot",
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("got events %q, want %q", events, want)
+		}
+
+		content := result.MessageContentToolResult.Content
+		if len(content) != 1 || content[0].Type != anthropic.MessagesContentTypeImage {
+			t.Fatalf("expected a single image block, got %+v", content)
+		}
+		raw, err := base64.StdEncoding.DecodeString(content[0].Source.Data.(string))
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != 1280 || b.Dy() != 800 {
+			t.Fatalf("screenshot was not scaled: %v", b)
+		}
+	})
+
+	t.Run("reports the cursor in model coordinates", func(t *testing.T) {
+		if err := fake.MouseMove(ctx, 640, 320); err != nil {
+			t.Fatal(err)
+		}
+		content, err := tool.Execute(ctx, anthropic.ComputerCursorPositionAction{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := content[0].GetText(); got != "X=320,Y=160" {
+			t.Fatalf("got %q", got)
+		}
+	})
+
+	t.Run("drags with the left button", func(t *testing.T) {
+		fake.Reset()
+		_, err := tool.Execute(ctx, anthropic.ComputerDragAction{
+			Start: &anthropic.ComputerCoordinate{X: 1, Y: 1},
+			End:   anthropic.ComputerCoordinate{X: 2, Y: 2},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ops []string
+		for _, e := range fake.Events() {
+			ops = append(ops, e.String())
+		}
+		got := strings.Join(ops, "; ")
+		want := "MouseMove 2,2; MouseDown left; MouseMove 4,4; MouseUp left; Screenshot"
+		if got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	})
+
+	t.Run("rejects out of bounds coordinates", func(t *testing.T) {
+		for _, c := range []anthropic.ComputerCoordinate{
+			{X: 2000, Y: 10}, {X: 1280, Y: 0}, {X: 0, Y: 800},
+		} {
+			_, err := tool.Execute(ctx, anthropic.ComputerMouseMoveAction{Coordinate: c})
+			if err == nil || !strings.Contains(err.Error(), "out of bounds") {
+				t.Fatalf("expected an out of bounds error for %+v, got %v", c, err)
+			}
+		}
+		_, err := tool.Execute(ctx, anthropic.ComputerMouseMoveAction{
+			Coordinate: anthropic.ComputerCoordinate{X: 1279, Y: 799},
+		})
+		if err != nil {
+			t.Fatalf("the last pixel was rejected: %v", err)
+		}
+	})
+
+	t.Run("fake rejects coordinates on the display edge", func(t *testing.T) {
+		for _, c := range [][2]int{{2560, 0}, {0, 1600}} {
+			if err := fake.MouseMove(ctx, c[0], c[1]); err == nil {
+				t.Fatalf("expected an error for %v", c)
+			}
+		}
+		if err := fake.MouseMove(ctx, 2559, 1599); err != nil {
+			t.Fatalf("the last pixel was rejected: %v", err)
+		}
+	})
+}
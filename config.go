@@ -31,6 +31,11 @@ const (
 	BetaInterleavedThinking20250514 BetaVersion = "interleaved-thinking-2025-05-14"
 	BetaComputerUse20250124         BetaVersion = "computer-use-2025-01-24"
 	BetaStructuredOutputs20251113   BetaVersion = "structured-outputs-2025-11-13"
+	BetaMCPClient20250404           BetaVersion = "mcp-client-2025-04-04"
+	BetaContextManagement20250627   BetaVersion = "context-management-2025-06-27"
+	BetaCodeExecution20250825       BetaVersion = "code-execution-2025-08-25"
+	BetaWebFetch20250910            BetaVersion = "web-fetch-2025-09-10"
+	BetaFilesAPI20250414            BetaVersion = "files-api-2025-04-14"
 )
 
 type ApiKeyFunc func() string
@@ -48,6 +53,31 @@ type ClientConfig struct {
 	EmptyMessagesLimit uint
 
 	Adapter ClientAdapter
+
+	// ModelCheck sets how requests to retired models are handled, see
+	// ModelCheckMode. ModelWarningHandler receives the warnings; they are
+	// logged with the log package when it is nil.
+	ModelCheck          ModelCheckMode
+	ModelWarningHandler func(error)
+
+	// MaxRequestBytes is the size limit checked before a messages request is
+	// sent. Zero means MaxMessagesRequestBytes; a negative value disables the
+	// check.
+	MaxRequestBytes int64
+	// FilesAPIFallback uploads the largest inline documents and images of an
+	// oversized messages request with the Files API and sends file
+	// references instead. The uploaded files are deleted when the call
+	// returns. It is ignored with Vertex AI, which has no Files API.
+	FilesAPIFallback bool
+
+	// RequestValidation runs MessagesRequest.Validate before messages
+	// requests are sent.
+	RequestValidation bool
+
+	// header and extraBody are set for a single call by RequestOptions and
+	// apply to its primary request only, see withoutCallOptions.
+	header    http.Header
+	extraBody map[string]any
 }
 
 type ClientOption func(c *ClientConfig)
@@ -121,6 +151,36 @@ func WithVertexAI(projectID string, location string) ClientOption {
 	}
 }
 
+func WithModelCheck(mode ModelCheckMode) ClientOption {
+	return func(c *ClientConfig) {
+		c.ModelCheck = mode
+	}
+}
+
+func WithModelWarningHandler(handler func(error)) ClientOption {
+	return func(c *ClientConfig) {
+		c.ModelWarningHandler = handler
+	}
+}
+
+func WithMaxRequestBytes(n int64) ClientOption {
+	return func(c *ClientConfig) {
+		c.MaxRequestBytes = n
+	}
+}
+
+func WithFilesAPIFallback() ClientOption {
+	return func(c *ClientConfig) {
+		c.FilesAPIFallback = true
+	}
+}
+
+func WithRequestValidation() ClientOption {
+	return func(c *ClientConfig) {
+		c.RequestValidation = true
+	}
+}
+
 func WithApiKeyFunc(apiKeyFunc ApiKeyFunc) ClientOption {
 	return func(c *ClientConfig) {
 		c.apiKeyFunc = apiKeyFunc
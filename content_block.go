@@ -0,0 +1,472 @@
+package anthropic
+
+import "encoding/json"
+
+// ContentBlock is a content block of a concrete type, for use in a type
+// switch instead of checking which embedded struct of a MessageContent is set:
+//
+//	switch b := c.ContentBlock().(type) {
+//	case anthropic.TextBlock:
+//		fmt.Println(b.Text)
+//	case anthropic.ToolUseBlock:
+//		run(b.Name, b.Input)
+//	case anthropic.UnknownBlock:
+//		log.Printf("unhandled %s block", b.BlockType())
+//	}
+//
+// Converting a MessageContent to a ContentBlock and back keeps every field
+// the block's type uses, so both forms can be mixed while migrating.
+type ContentBlock interface {
+	// BlockType returns the block's "type".
+	BlockType() MessagesContentType
+	// MessageContent converts the block to a MessageContent.
+	MessageContent() MessageContent
+}
+
+type TextBlock struct {
+	Text         string
+	Citations    []Citation
+	CacheControl *MessageCacheControl
+}
+
+type ImageBlock struct {
+	Source       MessageContentSource
+	CacheControl *MessageCacheControl
+}
+
+type DocumentBlock struct {
+	Source       MessageContentSource
+	Title        string
+	Context      string
+	Citations    *DocumentCitations
+	CacheControl *MessageCacheControl
+}
+
+type SearchResultBlock struct {
+	Source       string
+	Title        string
+	Content      []ContentBlock
+	Citations    *DocumentCitations
+	CacheControl *MessageCacheControl
+}
+
+type ToolUseBlock struct {
+	ID           string
+	Name         string
+	Input        json.RawMessage
+	CacheControl *MessageCacheControl
+}
+
+// ServerToolUseBlock is a server_tool_use block, a call of a tool the API
+// runs itself, such as web search or code execution.
+type ServerToolUseBlock ToolUseBlock
+
+type MCPToolUseBlock struct {
+	ID           string
+	Name         string
+	ServerName   string
+	Input        json.RawMessage
+	CacheControl *MessageCacheControl
+}
+
+type ToolResultBlock struct {
+	ToolUseID    string
+	Content      []ContentBlock
+	IsError      *bool
+	CacheControl *MessageCacheControl
+}
+
+type MCPToolResultBlock ToolResultBlock
+
+type WebSearchToolResultBlock struct {
+	ToolUseID    string
+	Content      []WebSearchResult
+	CacheControl *MessageCacheControl
+}
+
+type WebFetchToolResultBlock struct {
+	ToolUseID    string
+	Content      *WebFetchResult
+	CacheControl *MessageCacheControl
+}
+
+type CodeExecutionToolResultBlock struct {
+	ToolUseID    string
+	Content      *CodeExecutionResult
+	CacheControl *MessageCacheControl
+}
+
+type BashCodeExecutionToolResultBlock CodeExecutionToolResultBlock
+
+type TextEditorCodeExecutionToolResultBlock struct {
+	ToolUseID    string
+	Content      *TextEditorCodeExecutionResult
+	CacheControl *MessageCacheControl
+}
+
+type ThinkingBlock struct {
+	Thinking  string
+	Signature string
+}
+
+type RedactedThinkingBlock struct {
+	Data string
+}
+
+// UnknownBlock holds a MessageContent without a concrete block type: blocks
+// of a type this package does not know (see MessageContent.IsUnknown) and
+// stream deltas.
+type UnknownBlock struct {
+	Content MessageContent
+}
+
+func (TextBlock) BlockType() MessagesContentType     { return MessagesContentTypeText }
+func (ImageBlock) BlockType() MessagesContentType    { return MessagesContentTypeImage }
+func (DocumentBlock) BlockType() MessagesContentType { return MessagesContentTypeDocument }
+func (ToolUseBlock) BlockType() MessagesContentType  { return MessagesContentTypeToolUse }
+func (ThinkingBlock) BlockType() MessagesContentType { return MessagesContentTypeThinking }
+
+func (SearchResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeSearchResult
+}
+
+func (ServerToolUseBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeServerToolUse
+}
+
+func (MCPToolUseBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeMCPToolUse
+}
+
+func (ToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeToolResult
+}
+
+func (MCPToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeMCPToolResult
+}
+
+func (WebSearchToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeWebSearchToolResult
+}
+
+func (WebFetchToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeWebFetchToolResult
+}
+
+func (CodeExecutionToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeCodeExecutionToolResult
+}
+
+func (BashCodeExecutionToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeBashCodeExecutionToolResult
+}
+
+func (TextEditorCodeExecutionToolResultBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeTextEditorCodeExecutionToolResult
+}
+
+func (RedactedThinkingBlock) BlockType() MessagesContentType {
+	return MessagesContentTypeRedactedThinking
+}
+
+func (b UnknownBlock) BlockType() MessagesContentType {
+	return b.Content.Type
+}
+
+func (b TextBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:         MessagesContentTypeText,
+		Text:         &b.Text,
+		Citations:    b.Citations,
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b ImageBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:         MessagesContentTypeImage,
+		Source:       &b.Source,
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b DocumentBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:              MessagesContentTypeDocument,
+		Source:            &b.Source,
+		Title:             b.Title,
+		Context:           b.Context,
+		DocumentCitations: b.Citations,
+		CacheControl:      b.CacheControl,
+	}
+}
+
+func (b SearchResultBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:  MessagesContentTypeSearchResult,
+		Title: b.Title,
+		MessageContentSearchResult: &MessageContentSearchResult{
+			Source:  b.Source,
+			Content: MessageContents(b.Content),
+		},
+		DocumentCitations: b.Citations,
+		CacheControl:      b.CacheControl,
+	}
+}
+
+func (b ToolUseBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:                  MessagesContentTypeToolUse,
+		MessageContentToolUse: &MessageContentToolUse{ID: b.ID, Name: b.Name, Input: b.Input},
+		CacheControl:          b.CacheControl,
+	}
+}
+
+func (b ServerToolUseBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeServerToolUse,
+		MessageContentServerToolUse: &MessageContentServerToolUse{
+			ID:    b.ID,
+			Name:  b.Name,
+			Input: b.Input,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b MCPToolUseBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeMCPToolUse,
+		MessageContentMCPToolUse: &MessageContentMCPToolUse{
+			ID:         b.ID,
+			Name:       b.Name,
+			ServerName: b.ServerName,
+			Input:      b.Input,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b ToolResultBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeToolResult,
+		MessageContentToolResult: &MessageContentToolResult{
+			ToolUseID: &b.ToolUseID,
+			Content:   MessageContents(b.Content),
+			IsError:   b.IsError,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b MCPToolResultBlock) MessageContent() MessageContent {
+	m := ToolResultBlock(b).MessageContent()
+	m.Type = MessagesContentTypeMCPToolResult
+	return m
+}
+
+func (b WebSearchToolResultBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeWebSearchToolResult,
+		MessageContentWebSearchToolResult: &MessageContentWebSearchToolResult{
+			ToolUseID: &b.ToolUseID,
+			Content:   b.Content,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b WebFetchToolResultBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeWebFetchToolResult,
+		MessageContentWebFetchToolResult: &MessageContentWebFetchToolResult{
+			ToolUseID: &b.ToolUseID,
+			Content:   b.Content,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b CodeExecutionToolResultBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeCodeExecutionToolResult,
+		MessageContentCodeExecutionToolResult: &MessageContentCodeExecutionToolResult{
+			ToolUseID: &b.ToolUseID,
+			Content:   b.Content,
+		},
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b BashCodeExecutionToolResultBlock) MessageContent() MessageContent {
+	m := CodeExecutionToolResultBlock(b).MessageContent()
+	m.Type = MessagesContentTypeBashCodeExecutionToolResult
+	return m
+}
+
+func (b TextEditorCodeExecutionToolResultBlock) MessageContent() MessageContent {
+	result := &MessageContentTextEditorCodeExecutionToolResult{
+		ToolUseID: &b.ToolUseID,
+		Content:   b.Content,
+	}
+	return MessageContent{
+		Type: MessagesContentTypeTextEditorCodeExecutionToolResult,
+		MessageContentTextEditorCodeExecutionToolResult: result,
+		CacheControl: b.CacheControl,
+	}
+}
+
+func (b ThinkingBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeThinking,
+		MessageContentThinking: &MessageContentThinking{
+			Thinking:  b.Thinking,
+			Signature: b.Signature,
+		},
+	}
+}
+
+func (b RedactedThinkingBlock) MessageContent() MessageContent {
+	return MessageContent{
+		Type:                           MessagesContentTypeRedactedThinking,
+		MessageContentRedactedThinking: &MessageContentRedactedThinking{Data: b.Data},
+	}
+}
+
+func (b UnknownBlock) MessageContent() MessageContent {
+	return b.Content
+}
+
+// ContentBlock converts m to the ContentBlock of its type, or to an
+// UnknownBlock.
+func (m MessageContent) ContentBlock() ContentBlock {
+	if m.IsUnknown() {
+		return UnknownBlock{Content: m}
+	}
+
+	switch m.Type {
+	case MessagesContentTypeText:
+		return TextBlock{Text: m.GetText(), Citations: m.Citations, CacheControl: m.CacheControl}
+	case MessagesContentTypeImage:
+		return ImageBlock{Source: derefSource(m.Source), CacheControl: m.CacheControl}
+	case MessagesContentTypeDocument:
+		return DocumentBlock{
+			Source:       derefSource(m.Source),
+			Title:        m.Title,
+			Context:      m.Context,
+			Citations:    m.DocumentCitations,
+			CacheControl: m.CacheControl,
+		}
+	case MessagesContentTypeSearchResult:
+		b := SearchResultBlock{
+			Title:        m.Title,
+			Citations:    m.DocumentCitations,
+			CacheControl: m.CacheControl,
+		}
+		if r := m.MessageContentSearchResult; r != nil {
+			b.Source, b.Content = r.Source, ContentBlocks(r.Content)
+		}
+		return b
+	case MessagesContentTypeToolUse:
+		b := ToolUseBlock{CacheControl: m.CacheControl}
+		if t := m.MessageContentToolUse; t != nil {
+			b.ID, b.Name, b.Input = t.ID, t.Name, t.Input
+		}
+		return b
+	case MessagesContentTypeServerToolUse:
+		b := ServerToolUseBlock{CacheControl: m.CacheControl}
+		if t := m.MessageContentServerToolUse; t != nil {
+			b.ID, b.Name, b.Input = t.ID, t.Name, t.Input
+		}
+		return b
+	case MessagesContentTypeMCPToolUse:
+		b := MCPToolUseBlock{CacheControl: m.CacheControl}
+		if t := m.MessageContentMCPToolUse; t != nil {
+			b.ID, b.Name, b.ServerName, b.Input = t.ID, t.Name, t.ServerName, t.Input
+		}
+		return b
+	case MessagesContentTypeToolResult, MessagesContentTypeMCPToolResult:
+		b := ToolResultBlock{CacheControl: m.CacheControl}
+		if r := m.MessageContentToolResult; r != nil {
+			b.ToolUseID = derefString(r.ToolUseID)
+			b.Content, b.IsError = ContentBlocks(r.Content), r.IsError
+		}
+		if m.Type == MessagesContentTypeMCPToolResult {
+			return MCPToolResultBlock(b)
+		}
+		return b
+	case MessagesContentTypeWebSearchToolResult:
+		b := WebSearchToolResultBlock{CacheControl: m.CacheControl}
+		if r := m.MessageContentWebSearchToolResult; r != nil {
+			b.ToolUseID, b.Content = derefString(r.ToolUseID), r.Content
+		}
+		return b
+	case MessagesContentTypeWebFetchToolResult:
+		b := WebFetchToolResultBlock{CacheControl: m.CacheControl}
+		if r := m.MessageContentWebFetchToolResult; r != nil {
+			b.ToolUseID, b.Content = derefString(r.ToolUseID), r.Content
+		}
+		return b
+	case MessagesContentTypeCodeExecutionToolResult,
+		MessagesContentTypeBashCodeExecutionToolResult:
+		b := CodeExecutionToolResultBlock{CacheControl: m.CacheControl}
+		if r := m.MessageContentCodeExecutionToolResult; r != nil {
+			b.ToolUseID, b.Content = derefString(r.ToolUseID), r.Content
+		}
+		if m.Type == MessagesContentTypeBashCodeExecutionToolResult {
+			return BashCodeExecutionToolResultBlock(b)
+		}
+		return b
+	case MessagesContentTypeTextEditorCodeExecutionToolResult:
+		b := TextEditorCodeExecutionToolResultBlock{CacheControl: m.CacheControl}
+		if r := m.MessageContentTextEditorCodeExecutionToolResult; r != nil {
+			b.ToolUseID, b.Content = derefString(r.ToolUseID), r.Content
+		}
+		return b
+	case MessagesContentTypeThinking:
+		var b ThinkingBlock
+		if t := m.MessageContentThinking; t != nil {
+			b.Thinking, b.Signature = t.Thinking, t.Signature
+		}
+		return b
+	case MessagesContentTypeRedactedThinking:
+		var b RedactedThinkingBlock
+		if r := m.MessageContentRedactedThinking; r != nil {
+			b.Data = r.Data
+		}
+		return b
+	}
+	return UnknownBlock{Content: m}
+}
+
+// ContentBlocks converts content to ContentBlocks.
+func ContentBlocks(content []MessageContent) []ContentBlock {
+	if content == nil {
+		return nil
+	}
+	blocks := make([]ContentBlock, len(content))
+	for i, c := range content {
+		blocks[i] = c.ContentBlock()
+	}
+	return blocks
+}
+
+// MessageContents converts blocks to MessageContents.
+func MessageContents(blocks []ContentBlock) []MessageContent {
+	if blocks == nil {
+		return nil
+	}
+	content := make([]MessageContent, len(blocks))
+	for i, b := range blocks {
+		content[i] = b.MessageContent()
+	}
+	return content
+}
+
+func derefSource(s *MessageContentSource) MessageContentSource {
+	if s == nil {
+		return MessageContentSource{}
+	}
+	return *s
+}
@@ -0,0 +1,82 @@
+package anthropic_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestContentBlockRoundTrip(t *testing.T) {
+	data := `[
+		{"type":"text","text":"See","citations":[{"type":"char_location","cited_text":"a",` +
+		`"document_index":0,"start_char_index":0,"end_char_index":1}]},
+		{"type":"image","source":{"type":"url","url":"https://example.com/a.png"}},
+		{"type":"document","source":{"type":"text","media_type":"text/plain","data":"a"},` +
+		`"title":"A","citations":{"enabled":true},"cache_control":{"type":"ephemeral"}},
+		{"type":"search_result","source":"https://example.com","title":"S",` +
+		`"content":[{"type":"text","text":"x"}]},
+		{"type":"thinking","thinking":"hmm","signature":"sig"},
+		{"type":"redacted_thinking","data":"xyz"},
+		{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}},
+		{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"q"}},
+		{"type":"mcp_tool_use","id":"mcptoolu_1","name":"echo","server_name":"s","input":{}},
+		{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"sunny"}],` +
+		`"is_error":false},
+		{"type":"mcp_tool_result","tool_use_id":"mcptoolu_1",` +
+		`"content":[{"type":"text","text":"e"}]},
+		{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[` +
+		`{"type":"web_search_result","url":"https://example.com","title":"E"}]},
+		{"type":"bash_code_execution_tool_result","tool_use_id":"srvtoolu_2",` +
+		`"content":{"type":"bash_code_execution_result","stdout":"ok","return_code":0}},
+		{"type":"text_editor_code_execution_tool_result","tool_use_id":"srvtoolu_3",` +
+		`"content":{"type":"text_editor_code_execution_result","is_file_update":true}},
+		{"type":"tool_search_result","hits":[1,2]}
+	]`
+
+	var content []anthropic.MessageContent
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	blocks := anthropic.ContentBlocks(content)
+	var text, toolUses, unknown int
+	for i, b := range blocks {
+		if b.BlockType() != content[i].Type {
+			t.Errorf("block %d: got type %s, want %s", i, b.BlockType(), content[i].Type)
+		}
+		switch b := b.(type) {
+		case anthropic.TextBlock:
+			text++
+			if len(b.Citations) != 1 {
+				t.Errorf("unexpected citations %+v", b.Citations)
+			}
+		case anthropic.ToolUseBlock:
+			toolUses++
+			if b.ID != "toolu_1" || string(b.Input) != `{"city":"Paris"}` {
+				t.Errorf("unexpected tool use %+v", b)
+			}
+		case anthropic.MCPToolResultBlock:
+			if _, ok := b.Content[0].(anthropic.TextBlock); !ok || b.ToolUseID != "mcptoolu_1" {
+				t.Errorf("unexpected mcp tool result %+v", b)
+			}
+		case anthropic.UnknownBlock:
+			unknown++
+		}
+	}
+	if text != 1 || toolUses != 1 || unknown != 1 {
+		t.Fatalf("got %d text, %d tool_use and %d unknown blocks", text, toolUses, unknown)
+	}
+
+	want, err := json.Marshal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(anthropic.MessageContents(blocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("round trip changed the content\n got: %s\nwant: %s", got, want)
+	}
+}
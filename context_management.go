@@ -0,0 +1,148 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// ContextManagementEditType is the type of a server-side context edit. Edits
+// require the BetaContextManagement20250627 beta.
+type ContextManagementEditType string
+
+const (
+	// ContextManagementEditClearToolUses20250919 clears the oldest tool results
+	// (and optionally tool inputs) once the trigger is reached.
+	ContextManagementEditClearToolUses20250919 ContextManagementEditType = "clear_tool_uses_20250919"
+	// ContextManagementEditClearThinking20251015 clears thinking blocks from
+	// earlier assistant turns. It requires extended thinking and must be the
+	// first edit.
+	ContextManagementEditClearThinking20251015 ContextManagementEditType = "clear_thinking_20251015"
+)
+
+type ContextManagementUnit string
+
+const (
+	ContextManagementUnitInputTokens   ContextManagementUnit = "input_tokens"
+	ContextManagementUnitToolUses      ContextManagementUnit = "tool_uses"
+	ContextManagementUnitThinkingTurns ContextManagementUnit = "thinking_turns"
+)
+
+// ContextManagement is the context_management field of a request: the edits
+// the server applies to the history before the model sees it. The stored
+// history is not changed; the edits are reapplied on every request.
+type ContextManagement struct {
+	Edits []ContextManagementEdit `json:"edits"`
+}
+
+type ContextManagementEdit struct {
+	Type ContextManagementEditType `json:"type"`
+	// Trigger is when the edit applies, in input tokens or tool uses. The
+	// server defaults to 100,000 input tokens.
+	Trigger *ContextManagementAmount `json:"trigger,omitempty"`
+	// Keep is how many of the most recent tool uses or thinking turns are
+	// kept.
+	Keep *ContextManagementKeep `json:"keep,omitempty"`
+	// ClearAtLeast is the minimum number of input tokens an edit must clear
+	// to be applied, so that a prompt cache is not invalidated for a small
+	// gain.
+	ClearAtLeast *ContextManagementAmount `json:"clear_at_least,omitempty"`
+	// ExcludeTools lists tool names whose uses are never cleared.
+	ExcludeTools []string `json:"exclude_tools,omitempty"`
+	// ClearToolInputs also clears the tool_use inputs, not only the results.
+	ClearToolInputs *bool `json:"clear_tool_inputs,omitempty"`
+}
+
+type ContextManagementAmount struct {
+	Type  ContextManagementUnit `json:"type"`
+	Value int                   `json:"value"`
+}
+
+// ContextManagementKeep is either an amount or, for clear_thinking, "all".
+type ContextManagementKeep struct {
+	ContextManagementAmount
+	All bool
+}
+
+func (k ContextManagementKeep) MarshalJSON() ([]byte, error) {
+	if k.All {
+		return json.Marshal("all")
+	}
+	return json.Marshal(k.ContextManagementAmount)
+}
+
+func (k *ContextManagementKeep) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte(`"all"`)) {
+		*k = ContextManagementKeep{All: true}
+		return nil
+	}
+	*k = ContextManagementKeep{}
+	return json.Unmarshal(data, &k.ContextManagementAmount)
+}
+
+// NewClearToolUsesEdit returns a clear_tool_uses edit with the server
+// defaults; set its fields to tune it.
+func NewClearToolUsesEdit() ContextManagementEdit {
+	return ContextManagementEdit{Type: ContextManagementEditClearToolUses20250919}
+}
+
+// NewClearThinkingEdit returns a clear_thinking edit with the server
+// defaults; set Keep to tune it.
+func NewClearThinkingEdit() ContextManagementEdit {
+	return ContextManagementEdit{Type: ContextManagementEditClearThinking20251015}
+}
+
+func InputTokensAmount(n int) *ContextManagementAmount {
+	return &ContextManagementAmount{Type: ContextManagementUnitInputTokens, Value: n}
+}
+
+func ToolUsesAmount(n int) *ContextManagementAmount {
+	return &ContextManagementAmount{Type: ContextManagementUnitToolUses, Value: n}
+}
+
+func KeepToolUses(n int) *ContextManagementKeep {
+	return &ContextManagementKeep{ContextManagementAmount: *ToolUsesAmount(n)}
+}
+
+func KeepThinkingTurns(n int) *ContextManagementKeep {
+	return &ContextManagementKeep{
+		ContextManagementAmount: ContextManagementAmount{
+			Type:  ContextManagementUnitThinkingTurns,
+			Value: n,
+		},
+	}
+}
+
+func KeepAllThinkingTurns() *ContextManagementKeep {
+	return &ContextManagementKeep{All: true}
+}
+
+// MessagesContextManagement reports the edits the server applied to a
+// request.
+type MessagesContextManagement struct {
+	AppliedEdits []ContextManagementAppliedEdit `json:"applied_edits"`
+}
+
+type ContextManagementAppliedEdit struct {
+	Type                 ContextManagementEditType `json:"type"`
+	ClearedToolUses      int                       `json:"cleared_tool_uses,omitempty"`
+	ClearedThinkingTurns int                       `json:"cleared_thinking_turns,omitempty"`
+	ClearedInputTokens   int                       `json:"cleared_input_tokens,omitempty"`
+}
+
+// ClearedInputTokens returns the input tokens cleared by all applied edits.
+func (m *MessagesContextManagement) ClearedInputTokens() int {
+	if m == nil {
+		return 0
+	}
+	total := 0
+	for _, edit := range m.AppliedEdits {
+		total += edit.ClearedInputTokens
+	}
+	return total
+}
+
+// CountTokensContextManagement is returned by CountTokens when the request
+// has context management: InputTokens is then the count after the edits.
+type CountTokensContextManagement struct {
+	OriginalInputTokens int `json:"original_input_tokens"`
+}
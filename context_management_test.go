@@ -0,0 +1,151 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestContextManagementMarshal(t *testing.T) {
+	clearTools := anthropic.NewClearToolUsesEdit()
+	clearTools.Trigger = anthropic.InputTokensAmount(30000)
+	clearTools.Keep = anthropic.KeepToolUses(3)
+	clearTools.ClearAtLeast = anthropic.InputTokensAmount(5000)
+	clearTools.ExcludeTools = []string{"web_search"}
+	clearTools.ClearToolInputs = toPtr(true)
+
+	clearThinking := anthropic.NewClearThinkingEdit()
+	clearThinking.Keep = anthropic.KeepAllThinkingTurns()
+
+	management := anthropic.ContextManagement{
+		Edits: []anthropic.ContextManagementEdit{clearThinking, clearTools},
+	}
+	data, err := json.Marshal(management)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"edits":[{"type":"clear_thinking_20251015","keep":"all"},` +
+		`{"type":"clear_tool_uses_20250919","trigger":{"type":"input_tokens","value":30000},` +
+		`"keep":{"type":"tool_uses","value":3},` +
+		`"clear_at_least":{"type":"input_tokens","value":5000},` +
+		`"exclude_tools":["web_search"],"clear_tool_inputs":true}]}`
+	if string(data) != want {
+		t.Fatalf("got  %s\nwant %s", data, want)
+	}
+
+	var decoded anthropic.ContextManagement
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Edits[0].Keep.All || decoded.Edits[1].Keep.Value != 3 {
+		t.Fatalf("unexpected decoded edits %+v", decoded.Edits)
+	}
+}
+
+const contextManagementReport = `{"applied_edits":[` +
+	`{"type":"clear_thinking_20251015","cleared_thinking_turns":2,"cleared_input_tokens":1500},` +
+	`{"type":"clear_tool_uses_20250919","cleared_tool_uses":8,"cleared_input_tokens":50000}]}`
+
+func TestContextManagementResponse(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("anthropic-beta") != string(anthropic.BetaContextManagement20250627) {
+			http.Error(w, "missing beta header", http.StatusBadRequest)
+			return
+		}
+		var req map[string]json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := req["context_management"]; !ok {
+			http.Error(w, "missing context_management", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant",`+
+			`"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn",`+
+			`"usage":{"input_tokens":1,"output_tokens":1},"context_management":%s}`,
+			contextManagementReport)
+	})
+	countTokens := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"input_tokens":100,`+
+			`"context_management":{"original_input_tokens":900}}`)
+	}
+	server.RegisterHandler("/v1/messages/count_tokens", countTokens)
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithBetaVersion(anthropic.BetaContextManagement20250627),
+	)
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+		MaxTokens: 1000,
+		ContextManagement: &anthropic.ContextManagement{
+			Edits: []anthropic.ContextManagementEdit{anthropic.NewClearToolUsesEdit()},
+		},
+	}
+
+	resp, err := client.CreateMessages(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CreateMessages error: %v", err)
+	}
+	edits := resp.ContextManagement.AppliedEdits
+	if len(edits) != 2 || edits[0].ClearedThinkingTurns != 2 || edits[1].ClearedToolUses != 8 {
+		t.Fatalf("unexpected applied edits %+v", edits)
+	}
+	if resp.ContextManagement.ClearedInputTokens() != 51500 {
+		t.Fatalf("unexpected cleared tokens %d", resp.ContextManagement.ClearedInputTokens())
+	}
+
+	count, err := client.CountTokens(context.Background(), request)
+	if err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if count.InputTokens != 100 || count.ContextManagement.OriginalInputTokens != 900 {
+		t.Fatalf("unexpected count %+v", count)
+	}
+}
+
+func TestContextManagementStream(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		write := func(event, data string) {
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		}
+		write("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"usage":{"input_tokens":1,"output_tokens":1}}}`)
+		write("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},`+
+			`"usage":{"output_tokens":1},"context_management":`+contextManagementReport+`}`)
+		write("message_stop", `{"type":"message_stop"}`)
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+	resp, err := client.CreateMessagesStream(context.Background(), anthropic.MessagesStreamRequest{
+		MessagesRequest: anthropic.MessagesRequest{
+			Model:     anthropic.ModelClaude3Haiku20240307,
+			Messages:  []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+			MaxTokens: 1000,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessagesStream error: %v", err)
+	}
+	if resp.ContextManagement.ClearedInputTokens() != 51500 {
+		t.Fatalf("unexpected context management %+v", resp.ContextManagement)
+	}
+}
@@ -0,0 +1,575 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultContextWindow is the context window assumed for models whose
+	// window is not in the model registry.
+	DefaultContextWindow = 200_000
+
+	// estimatedCharsPerToken is deliberately low so that the local estimate
+	// errs on the side of trimming too early rather than getting a 400.
+	estimatedCharsPerToken = 3
+	// estimatedImageTokens is roughly the cost of an image at the largest
+	// size the API keeps without downscaling (1.15 megapixels / 750).
+	estimatedImageTokens = 1600
+
+	defaultSummaryMaxTokens = 1024
+	defaultSummaryPrompt    = "Summarize the conversation transcript below for the assistant " +
+		"that will continue it. Keep every fact, decision, open task, file name and tool " +
+		"outcome that may matter later. Reply with the summary only."
+	defaultClearedToolResult = "[tool result cleared to save context]"
+)
+
+// ErrContextWindowExceeded is returned by ContextManager.Fit when no strategy
+// could bring the request within the context window.
+var ErrContextWindowExceeded = errors.New("request does not fit in the context window")
+
+// TokenCounter counts the input tokens of a request.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, request MessagesRequest) (int, error)
+}
+
+// TokenCounterFunc adapts a function to the TokenCounter interface.
+type TokenCounterFunc func(ctx context.Context, request MessagesRequest) (int, error)
+
+func (f TokenCounterFunc) CountTokens(ctx context.Context, request MessagesRequest) (int, error) {
+	return f(ctx, request)
+}
+
+// NewAPITokenCounter counts tokens with the count_tokens endpoint. It is
+// exact but costs a round trip per count.
+func NewAPITokenCounter(client *Client) TokenCounter {
+	return TokenCounterFunc(func(ctx context.Context, request MessagesRequest) (int, error) {
+		resp, err := client.CountTokens(ctx, request)
+		if err != nil {
+			return 0, err
+		}
+		return resp.InputTokens, nil
+	})
+}
+
+// NewEstimatedTokenCounter counts tokens locally with EstimateTokens.
+func NewEstimatedTokenCounter() TokenCounter {
+	return TokenCounterFunc(func(_ context.Context, request MessagesRequest) (int, error) {
+		return EstimateTokens(request), nil
+	})
+}
+
+// EstimateTokens returns a rough, pessimistic estimate of the input tokens
+// of a request, without calling the API. Text is counted at about three
+// characters per token and each image at a fixed cost.
+func EstimateTokens(request MessagesRequest) int {
+	tokens := estimateMessagesTokens(request.Messages)
+	if request.System != "" {
+		tokens += estimateJSONTokens(request.System)
+	}
+	if len(request.MultiSystem) > 0 {
+		tokens += estimateJSONTokens(request.MultiSystem)
+	}
+	if len(request.Tools) > 0 {
+		tokens += estimateJSONTokens(request.Tools)
+	}
+	return tokens
+}
+
+func estimateMessagesTokens(messages []Message) int {
+	tokens := 0
+	for _, m := range messages {
+		tokens += estimateContentTokens(m.Content)
+	}
+	return tokens
+}
+
+func estimateContentTokens(content []MessageContent) int {
+	tokens := 0
+	for _, c := range content {
+		switch {
+		case c.Type == MessagesContentTypeImage:
+			tokens += estimatedImageTokens
+		case c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil:
+			tokens += estimateContentTokens(c.MessageContentToolResult.Content) + 10
+		default:
+			tokens += estimateJSONTokens(c)
+		}
+	}
+	return tokens
+}
+
+func estimateJSONTokens(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)/estimatedCharsPerToken + 1
+}
+
+// ContextTrim describes what one strategy removed from the history.
+type ContextTrim struct {
+	// Strategy names the strategy that made the change.
+	Strategy string
+	// Removed holds the messages that were dropped or summarized. A message
+	// that was only partially removed appears with the removed blocks only.
+	Removed []Message
+	// ClearedToolResults lists the tool_use IDs whose results were cleared.
+	ClearedToolResults []string
+	// Summary is the text that replaced the removed messages, if any.
+	Summary string
+}
+
+func (t ContextTrim) empty() bool {
+	return len(t.Removed) == 0 && len(t.ClearedToolResults) == 0
+}
+
+// ContextReport is returned by ContextManager.Fit.
+type ContextReport struct {
+	// Budget is the context window minus the request's max_tokens.
+	Budget int
+	// InputTokens is the count before any trimming.
+	InputTokens int
+	// FinalInputTokens is the count of the request that is returned.
+	FinalInputTokens int
+	// Trims lists the changes in the order they were applied.
+	Trims []ContextTrim
+}
+
+// Trimmed reports whether the history was changed.
+func (r ContextReport) Trimmed() bool {
+	return len(r.Trims) > 0
+}
+
+// ContextStrategy shrinks a history that is over budget. excess is the
+// number of tokens to free; a strategy may free more or less, Fit recounts
+// and calls it again as needed. A strategy that cannot shrink the history
+// any further returns an empty ContextTrim.
+//
+// Strategies must not modify messages in place and must never leave a
+// tool_result block without the tool_use block it answers.
+type ContextStrategy interface {
+	Trim(ctx context.Context, messages []Message, excess int) ([]Message, ContextTrim, error)
+}
+
+// ContextManager keeps requests within the model's context window. Before
+// each request it counts the input tokens and, when they exceed the window
+// minus max_tokens, applies its strategies in order until the request fits.
+type ContextManager struct {
+	window     int
+	counter    TokenCounter
+	strategies []ContextStrategy
+}
+
+type ContextManagerOption func(*ContextManager)
+
+// WithContextWindow sets the context window size in tokens. By default it is
+// looked up from the request's model, falling back to DefaultContextWindow.
+func WithContextWindow(tokens int) ContextManagerOption {
+	return func(m *ContextManager) {
+		m.window = tokens
+	}
+}
+
+// WithTokenCounter sets how tokens are counted. The default is
+// NewEstimatedTokenCounter; NewAPITokenCounter is exact.
+func WithTokenCounter(counter TokenCounter) ContextManagerOption {
+	return func(m *ContextManager) {
+		m.counter = counter
+	}
+}
+
+// WithContextStrategies sets the strategies applied, in order, when a
+// request is over budget. The default clears old tool results and then
+// drops the oldest turns.
+func WithContextStrategies(strategies ...ContextStrategy) ContextManagerOption {
+	return func(m *ContextManager) {
+		m.strategies = strategies
+	}
+}
+
+func NewContextManager(opts ...ContextManagerOption) *ContextManager {
+	m := &ContextManager{
+		counter:    NewEstimatedTokenCounter(),
+		strategies: []ContextStrategy{ClearOldToolResults{KeepLast: 3}, DropOldestTurns{}},
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Fit returns the request with its history trimmed to fit the context
+// window, and a report of what was trimmed. The request itself is not
+// modified. When the strategies run out before the request fits, the
+// trimmed request is returned with ErrContextWindowExceeded.
+func (m *ContextManager) Fit(
+	ctx context.Context,
+	request MessagesRequest,
+) (MessagesRequest, ContextReport, error) {
+	window := m.window
+	if window <= 0 {
+		window = DefaultContextWindow
+		if info, ok := request.Model.Info(); ok && info.ContextWindow > 0 {
+			window = info.ContextWindow
+		}
+	}
+
+	report := ContextReport{Budget: window - request.MaxTokens}
+	if report.Budget <= 0 {
+		return request, report, fmt.Errorf("%w: max_tokens %d leaves no room for input",
+			ErrContextWindowExceeded, request.MaxTokens)
+	}
+
+	tokens, err := m.counter.CountTokens(ctx, request)
+	if err != nil {
+		return request, report, fmt.Errorf("error, counting tokens: %w", err)
+	}
+	report.InputTokens = tokens
+	report.FinalInputTokens = tokens
+
+	for _, strategy := range m.strategies {
+		for tokens > report.Budget {
+			messages, trim, err := strategy.Trim(ctx, request.Messages, tokens-report.Budget)
+			if err != nil {
+				return request, report, err
+			}
+			if trim.empty() {
+				break
+			}
+			request.Messages = messages
+			report.Trims = append(report.Trims, trim)
+
+			tokens, err = m.counter.CountTokens(ctx, request)
+			if err != nil {
+				return request, report, fmt.Errorf("error, counting tokens: %w", err)
+			}
+			report.FinalInputTokens = tokens
+		}
+	}
+
+	if tokens > report.Budget {
+		return request, report, fmt.Errorf("%w: %d input tokens, budget %d",
+			ErrContextWindowExceeded, tokens, report.Budget)
+	}
+	return request, report, nil
+}
+
+// DropOldestTurns removes the oldest turns. It cuts where a user turn begins,
+// or keeps the prompt of a tool loop and drops its oldest tool_use and
+// tool_result pairs, so a tool_use and its tool_result are always dropped
+// together.
+type DropOldestTurns struct {
+	// KeepLast is the number of most recent messages that are never dropped.
+	// At least the last message is always kept.
+	KeepLast int
+}
+
+func (s DropOldestTurns) Trim(
+	_ context.Context,
+	messages []Message,
+	excess int,
+) ([]Message, ContextTrim, error) {
+	trim := ContextTrim{Strategy: "drop_oldest_turns"}
+
+	cuts := historyCutPoints(messages, s.KeepLast)
+	if len(cuts) == 0 {
+		return messages, trim, nil
+	}
+	cut := cuts[len(cuts)-1]
+	for _, c := range cuts {
+		if c.removedTokens(messages) >= excess {
+			cut = c
+			break
+		}
+	}
+
+	trimmed, removed := cutHistory(messages, cut)
+	trim.Removed = removed
+	return trimmed, trim, nil
+}
+
+// ClearOldToolResults replaces the content of the oldest tool results with a
+// short placeholder. The tool_use and tool_result blocks themselves stay, so
+// the history remains valid.
+type ClearOldToolResults struct {
+	// KeepLast is the number of most recent tool results that are never
+	// cleared. Results in the last message are always kept.
+	KeepLast int
+	// Placeholder replaces the cleared content. It defaults to a short note.
+	Placeholder string
+}
+
+func (s ClearOldToolResults) Trim(
+	_ context.Context,
+	messages []Message,
+	excess int,
+) ([]Message, ContextTrim, error) {
+	trim := ContextTrim{Strategy: "clear_old_tool_results"}
+	placeholder := s.Placeholder
+	if placeholder == "" {
+		placeholder = defaultClearedToolResult
+	}
+
+	type location struct{ message, content int }
+	var candidates []location
+	for i, m := range messages[:max(len(messages)-1, 0)] {
+		for j, c := range m.Content {
+			if c.Type != MessagesContentTypeToolResult || c.MessageContentToolResult == nil {
+				continue
+			}
+			if isClearedToolResult(c.MessageContentToolResult, placeholder) {
+				continue
+			}
+			candidates = append(candidates, location{i, j})
+		}
+	}
+	candidates = candidates[:max(len(candidates)-s.KeepLast, 0)]
+	if len(candidates) == 0 {
+		return messages, trim, nil
+	}
+
+	messages = append([]Message(nil), messages...)
+	copied := map[int]bool{}
+	freed := 0
+	for _, loc := range candidates {
+		if freed >= excess {
+			break
+		}
+		m := messages[loc.message]
+		if !copied[loc.message] {
+			m.Content = append([]MessageContent(nil), m.Content...)
+			messages[loc.message] = m
+			copied[loc.message] = true
+		}
+
+		old := m.Content[loc.content]
+		result := *old.MessageContentToolResult
+		freed += estimateContentTokens(result.Content)
+		result.Content = []MessageContent{NewTextMessageContent(placeholder)}
+
+		cleared := old
+		cleared.MessageContentToolResult = &result
+		m.Content[loc.content] = cleared
+
+		if result.ToolUseID != nil {
+			trim.ClearedToolResults = append(trim.ClearedToolResults, *result.ToolUseID)
+		}
+		trim.Removed = append(trim.Removed, Message{
+			Role:    m.Role,
+			Content: []MessageContent{old},
+		})
+	}
+	return messages, trim, nil
+}
+
+func isClearedToolResult(result *MessageContentToolResult, placeholder string) bool {
+	return len(result.Content) == 1 &&
+		result.Content[0].Type == MessagesContentTypeText &&
+		result.Content[0].GetText() == placeholder
+}
+
+// SummarizeHistory replaces the oldest turns with a summary written by a
+// model, typically a cheaper one than the conversation uses. Like
+// DropOldestTurns it only cuts where a user turn begins.
+type SummarizeHistory struct {
+	Client *Client
+	Model  Model
+	// MaxTokens bounds the summary length. It defaults to 1024.
+	MaxTokens int
+	// Prompt is the system prompt of the summary request. It defaults to a
+	// prompt asking to keep facts, decisions and open tasks.
+	Prompt string
+	// KeepLast is the number of most recent messages that are never
+	// summarized. At least the last message is always kept.
+	KeepLast int
+}
+
+func (s SummarizeHistory) Trim(
+	ctx context.Context,
+	messages []Message,
+	excess int,
+) ([]Message, ContextTrim, error) {
+	trim := ContextTrim{Strategy: "summarize_history"}
+	if s.Client == nil {
+		return messages, trim, errors.New("SummarizeHistory has no client")
+	}
+	maxTokens := s.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultSummaryMaxTokens
+	}
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = defaultSummaryPrompt
+	}
+
+	cuts := historyCutPoints(messages, s.KeepLast)
+	if len(cuts) == 0 {
+		return messages, trim, nil
+	}
+	cut := cuts[len(cuts)-1]
+	for _, c := range cuts {
+		// The summary takes up to maxTokens of the room freed.
+		if c.removedTokens(messages) >= excess+maxTokens {
+			cut = c
+			break
+		}
+	}
+
+	trimmed, removed := cutHistory(messages, cut)
+	resp, err := s.Client.CreateMessages(ctx, MessagesRequest{
+		Model:     s.Model,
+		System:    prompt,
+		MaxTokens: maxTokens,
+		Messages:  []Message{NewUserTextMessage(renderTranscript(removed))},
+	})
+	if err != nil {
+		return messages, trim, fmt.Errorf("error, summarizing history: %w", err)
+	}
+
+	var summary strings.Builder
+	for _, c := range resp.Content {
+		if c.Type == MessagesContentTypeText {
+			summary.WriteString(c.GetText())
+		}
+	}
+	trim.Removed = removed
+	trim.Summary = summary.String()
+
+	// trimmed starts with a user message: add the summary to it so that roles
+	// keep alternating. It goes after the prompt when the removed messages
+	// followed it.
+	first := trimmed[0]
+	summaryContent := NewTextMessageContent(
+		"Summary of the earlier conversation:\n" + trim.Summary,
+	)
+	content := make([]MessageContent, 0, len(first.Content)+1)
+	if cut.start > cut.prompt+1 {
+		content = append(append(content, first.Content...), summaryContent)
+	} else {
+		content = append(append(content, summaryContent), first.Content...)
+	}
+	first.Content = content
+	trimmed[0] = first
+	return trimmed, trim, nil
+}
+
+// historyCut is a point at which the history can be shortened: it keeps
+// messages[prompt], without the results of dropped tool uses, followed by
+// messages[start:].
+type historyCut struct {
+	prompt, start int
+}
+
+// removedTokens estimates the tokens the cut removes from messages.
+func (c historyCut) removedTokens(messages []Message) int {
+	tokens := estimateMessagesTokens(messages[:c.prompt]) +
+		estimateMessagesTokens(messages[c.prompt+1:c.start])
+	for _, content := range messages[c.prompt].Content {
+		if content.Type == MessagesContentTypeToolResult {
+			tokens += estimateContentTokens([]MessageContent{content})
+		}
+	}
+	return tokens
+}
+
+// historyCutPoints returns the points at which the history can be shortened,
+// oldest first. A history must start with a user message, and that message
+// must keep some content once the results of the dropped tool uses are
+// removed from it. Such a message is a prompt: the history can start at any
+// prompt, or keep the latest prompt and drop whole tool_use/tool_result pairs
+// after it, so that long tool loops started by a single prompt can be cut.
+func historyCutPoints(messages []Message, keepLast int) []historyCut {
+	last := len(messages) - max(keepLast, 1)
+	var cuts []historyCut
+	prompt := -1
+	if len(messages) > 0 && isPromptMessage(messages[0]) {
+		prompt = 0
+	}
+	for i := 1; i <= last; i++ {
+		switch {
+		case isPromptMessage(messages[i]):
+			prompt = i
+			if messages[i-1].Role != RoleUser {
+				cuts = append(cuts, historyCut{prompt: i, start: i + 1})
+			}
+		case messages[i].Role == RoleAssistant && messages[i-1].Role == RoleUser &&
+			prompt >= 0 && i-1 > prompt:
+			cuts = append(cuts, historyCut{prompt: prompt, start: i})
+		}
+	}
+	return cuts
+}
+
+// isPromptMessage reports whether m is a user message with content other
+// than tool results.
+func isPromptMessage(m Message) bool {
+	if m.Role != RoleUser {
+		return false
+	}
+	for _, c := range m.Content {
+		if c.Type != MessagesContentTypeToolResult {
+			return true
+		}
+	}
+	return false
+}
+
+// cutHistory shortens messages at cut, dropping the tool results in
+// messages[cut.prompt], whose tool uses were in a dropped assistant message.
+// It returns the new history and what was removed.
+func cutHistory(messages []Message, cut historyCut) ([]Message, []Message) {
+	removed := append([]Message(nil), messages[:cut.prompt]...)
+
+	first := Message{Role: messages[cut.prompt].Role}
+	var results []MessageContent
+	for _, c := range messages[cut.prompt].Content {
+		if c.Type == MessagesContentTypeToolResult {
+			results = append(results, c)
+		} else {
+			first.Content = append(first.Content, c)
+		}
+	}
+	if len(results) > 0 {
+		removed = append(removed, Message{Role: first.Role, Content: results})
+	}
+	removed = append(removed, messages[cut.prompt+1:cut.start]...)
+
+	trimmed := make([]Message, 0, len(messages)-cut.start+1)
+	trimmed = append(trimmed, first)
+	trimmed = append(trimmed, messages[cut.start:]...)
+	return trimmed, removed
+}
+
+// renderTranscript writes messages as plain text for a summary request.
+func renderTranscript(messages []Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "%s:\n", m.Role)
+		renderTranscriptContent(&b, m.Content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func renderTranscriptContent(b *strings.Builder, content []MessageContent) {
+	for _, c := range content {
+		switch {
+		case c.Type == MessagesContentTypeText:
+			b.WriteString(c.GetText())
+		case c.Type == MessagesContentTypeToolUse && c.MessageContentToolUse != nil:
+			use := c.MessageContentToolUse
+			fmt.Fprintf(b, "[tool_use %s %s]", use.Name, use.Input)
+		case c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil:
+			b.WriteString("[tool_result]\n")
+			renderTranscriptContent(b, c.MessageContentToolResult.Content)
+			b.WriteString("\n[/tool_result]")
+		default:
+			fmt.Fprintf(b, "[%s]", c.Type)
+		}
+		b.WriteString("\n")
+	}
+}
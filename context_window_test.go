@@ -0,0 +1,315 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+// toolHistory returns a history of three user turns, the first two of which
+// use a tool with a large result.
+func toolHistory() []anthropic.Message {
+	big := strings.Repeat("x", 3000)
+	return []anthropic.Message{
+		anthropic.NewUserTextMessage("q1"),
+		{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{
+			anthropic.NewToolUseMessageContent("t1", "read", json.RawMessage(`{}`)),
+		}},
+		{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{
+			anthropic.NewToolResultMessageContent("t1", big, false),
+		}},
+		anthropic.NewAssistantTextMessage("a1"),
+		anthropic.NewUserTextMessage("q2"),
+		{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{
+			anthropic.NewToolUseMessageContent("t2", "read", json.RawMessage(`{}`)),
+		}},
+		{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{
+			anthropic.NewToolResultMessageContent("t2", big, false),
+			anthropic.NewTextMessageContent("and q3"),
+		}},
+		anthropic.NewAssistantTextMessage("a2"),
+		anthropic.NewUserTextMessage("q4"),
+	}
+}
+
+// checkToolPairs fails when a history does not start with a user message or
+// holds a tool_result whose tool_use is missing.
+func checkToolPairs(t *testing.T, messages []anthropic.Message) {
+	t.Helper()
+	if len(messages) == 0 || messages[0].Role != anthropic.RoleUser {
+		t.Fatalf("history must start with a user message: %+v", messages)
+	}
+	uses := map[string]bool{}
+	for _, m := range messages {
+		for _, c := range m.Content {
+			switch c.Type {
+			case anthropic.MessagesContentTypeToolUse:
+				uses[c.MessageContentToolUse.ID] = true
+			case anthropic.MessagesContentTypeToolResult:
+				if id := *c.MessageContentToolResult.ToolUseID; !uses[id] {
+					t.Fatalf("orphaned tool_result %s", id)
+				}
+			}
+		}
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	short := anthropic.EstimateTokens(anthropic.MessagesRequest{
+		Messages: []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+	})
+	long := anthropic.EstimateTokens(anthropic.MessagesRequest{
+		System:   "be brief",
+		Messages: toolHistory(),
+	})
+	if short <= 0 || long < 2000 {
+		t.Fatalf("unexpected estimates %d, %d", short, long)
+	}
+}
+
+func TestContextStrategies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("clear old tool results", func(t *testing.T) {
+		history := toolHistory()
+		messages, trim, err := anthropic.ClearOldToolResults{KeepLast: 1}.Trim(ctx, history, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trim.ClearedToolResults) != 1 || trim.ClearedToolResults[0] != "t1" {
+			t.Fatalf("unexpected trim %+v", trim)
+		}
+		cleared := messages[2].Content[0].MessageContentToolResult.Content[0].GetText()
+		if cleared != "[tool result cleared to save context]" {
+			t.Fatalf("unexpected cleared content %q", cleared)
+		}
+		original := history[2].Content[0].MessageContentToolResult.Content[0].GetText()
+		if len(original) != 3000 {
+			t.Fatal("the input history was modified")
+		}
+		checkToolPairs(t, messages)
+
+		_, trim, _ = anthropic.ClearOldToolResults{KeepLast: 1}.Trim(ctx, messages, 1)
+		if len(trim.ClearedToolResults) != 0 {
+			t.Fatalf("cleared results again: %+v", trim)
+		}
+	})
+
+	t.Run("drop oldest turns", func(t *testing.T) {
+		messages, trim, err := anthropic.DropOldestTurns{}.Trim(ctx, toolHistory(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The smallest cut drops the first tool pair whole and keeps its prompt.
+		if len(messages) != 7 || len(trim.Removed) != 2 ||
+			messages[0].Content[0].GetText() != "q1" {
+			t.Fatalf("unexpected cut: %d left, %d removed", len(messages), len(trim.Removed))
+		}
+		checkToolPairs(t, messages)
+
+		keep3 := anthropic.DropOldestTurns{KeepLast: 3}
+		messages, trim, _ = keep3.Trim(ctx, toolHistory(), 1_000_000)
+		checkToolPairs(t, messages)
+		// The t2 result is removed from its message together with its tool_use.
+		if len(messages) != 3 || messages[0].Content[0].GetText() != "and q3" {
+			t.Fatalf("unexpected history %+v", messages)
+		}
+		if last := trim.Removed[len(trim.Removed)-1]; last.Content[0].Type !=
+			anthropic.MessagesContentTypeToolResult {
+			t.Fatalf("the stripped tool result was not reported: %+v", last)
+		}
+
+		_, trim, _ = anthropic.DropOldestTurns{KeepLast: 9}.Trim(ctx, toolHistory(), 1)
+		if len(trim.Removed) != 0 {
+			t.Fatalf("dropped kept messages: %+v", trim)
+		}
+	})
+
+	t.Run("summarize history", func(t *testing.T) {
+		var got anthropic.MessagesRequest
+		client := newStructuredTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewDecoder(r.Body).Decode(&got)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant",`+
+				`"content":[{"type":"text","text":"they asked q1 and q2"}],`+
+				`"stop_reason":"end_turn",`+
+				`"usage":{"input_tokens":1,"output_tokens":1}}`)
+		})
+
+		strategy := anthropic.SummarizeHistory{
+			Client: client,
+			Model:  anthropic.ModelClaude3Haiku20240307,
+		}
+		messages, trim, err := strategy.Trim(ctx, toolHistory(), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkToolPairs(t, messages)
+		if trim.Summary != "they asked q1 and q2" || len(trim.Removed) == 0 {
+			t.Fatalf("unexpected trim %+v", trim)
+		}
+		// The removed tool pair followed the kept prompt, so the summary comes after it.
+		if len(messages[0].Content) != 2 || messages[0].Content[0].GetText() != "q1" ||
+			!strings.Contains(messages[0].Content[1].GetText(), "they asked q1 and q2") {
+			t.Fatalf("summary was not added after the prompt: %+v", messages[0])
+		}
+		if got.Model != anthropic.ModelClaude3Haiku20240307 ||
+			!strings.Contains(got.Messages[0].Content[0].GetText(), "[tool_use read") {
+			t.Fatalf("unexpected summary request %+v", got)
+		}
+	})
+}
+
+func TestContextManagerFit(t *testing.T) {
+	ctx := context.Background()
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		MaxTokens: 100,
+		Messages:  toolHistory(),
+	}
+
+	t.Run("under budget", func(t *testing.T) {
+		fitted, report, err := anthropic.NewContextManager().Fit(ctx, request)
+		if err != nil || report.Trimmed() || len(fitted.Messages) != len(request.Messages) {
+			t.Fatalf("unexpected fit %+v, %v", report, err)
+		}
+	})
+
+	t.Run("over budget", func(t *testing.T) {
+		manager := anthropic.NewContextManager(
+			anthropic.WithContextWindow(1000),
+			anthropic.WithContextStrategies(
+				anthropic.ClearOldToolResults{},
+				anthropic.DropOldestTurns{},
+			),
+		)
+		fitted, report, err := manager.Fit(ctx, request)
+		if err != nil {
+			t.Fatalf("Fit error: %v", err)
+		}
+		checkToolPairs(t, fitted.Messages)
+		if report.FinalInputTokens > report.Budget || report.InputTokens <= report.Budget {
+			t.Fatalf("unexpected report %+v", report)
+		}
+		if len(report.Trims) != 1 || report.Trims[0].Strategy != "clear_old_tool_results" ||
+			len(fitted.Messages) != 9 {
+			t.Fatalf("unexpected trims %+v", report.Trims)
+		}
+	})
+
+	t.Run("does not fit", func(t *testing.T) {
+		manager := anthropic.NewContextManager(
+			anthropic.WithContextWindow(105),
+			anthropic.WithContextStrategies(anthropic.DropOldestTurns{}),
+		)
+		_, _, err := manager.Fit(ctx, request)
+		if !errors.Is(err, anthropic.ErrContextWindowExceeded) {
+			t.Fatalf("expected ErrContextWindowExceeded, got %v", err)
+		}
+	})
+
+	t.Run("api counter", func(t *testing.T) {
+		server := test.NewTestServer()
+		server.RegisterHandler("/v1/messages/count_tokens", func(
+			w http.ResponseWriter,
+			r *http.Request,
+		) {
+			var req anthropic.MessagesRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"input_tokens":%d}`, 100*len(req.Messages))
+		})
+		ts := server.AnthropicTestServer()
+		ts.Start()
+		defer ts.Close()
+		client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+
+		manager := anthropic.NewContextManager(
+			anthropic.WithContextWindow(600),
+			anthropic.WithTokenCounter(anthropic.NewAPITokenCounter(client)),
+			anthropic.WithContextStrategies(anthropic.DropOldestTurns{}),
+		)
+		fitted, report, err := manager.Fit(ctx, request)
+		if err != nil {
+			t.Fatalf("Fit error: %v", err)
+		}
+		if report.InputTokens != 900 || report.FinalInputTokens != 300 ||
+			len(fitted.Messages) != 3 || len(report.Trims) != 2 {
+			t.Fatalf("unexpected fit %+v", report)
+		}
+	})
+}
+
+func TestContextManagerFitToolLoop(t *testing.T) {
+	// A single prompt followed by a long tool loop: every later user message
+	// holds only tool results.
+	messages := []anthropic.Message{anthropic.NewUserTextMessage("fix the build")}
+	for i := range 10 {
+		id := fmt.Sprintf("t%d", i)
+		messages = append(messages,
+			anthropic.Message{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{
+				anthropic.NewToolUseMessageContent(id, "read", json.RawMessage(`{}`)),
+			}},
+			anthropic.Message{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{
+				anthropic.NewToolResultMessageContent(id, strings.Repeat("x", 3000), false),
+			}},
+		)
+	}
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		MaxTokens: 100,
+		Messages:  messages,
+	}
+
+	manager := anthropic.NewContextManager(
+		anthropic.WithContextWindow(3000),
+		anthropic.WithContextStrategies(anthropic.DropOldestTurns{KeepLast: 2}),
+	)
+	fitted, report, err := manager.Fit(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Fit error: %v", err)
+	}
+	checkToolPairs(t, fitted.Messages)
+	if fitted.Messages[0].Content[0].GetText() != "fix the build" {
+		t.Fatalf("the prompt was dropped: %+v", fitted.Messages[0])
+	}
+	if fitted.Messages[1].Role != anthropic.RoleAssistant || len(fitted.Messages)%2 != 1 {
+		t.Fatalf("roles do not alternate: %d messages", len(fitted.Messages))
+	}
+	last := fitted.Messages[len(fitted.Messages)-1]
+	if id := *last.Content[0].MessageContentToolResult.ToolUseID; id != "t9" {
+		t.Fatalf("the last tool pair was dropped: %s", id)
+	}
+	if report.FinalInputTokens > report.Budget || len(fitted.Messages) >= len(messages) {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestConversationContextManager(t *testing.T) {
+	client, last := conversationServer(t)
+	conv := anthropic.NewConversation(client, anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		MaxTokens: 100,
+	})
+	conv.ContextManager = anthropic.NewContextManager(anthropic.WithContextWindow(300))
+
+	for i := 0; i < 5; i++ {
+		text := anthropic.NewTextMessageContent(strings.Repeat("word ", 100))
+		if _, err := conv.Send(context.Background(), text); err != nil {
+			t.Fatalf("Send %d error: %v", i, err)
+		}
+	}
+	if !conv.LastContextReport.Trimmed() || len(last.Messages) >= 9 {
+		t.Fatalf("history was not trimmed: %d messages sent", len(last.Messages))
+	}
+	if len(conv.Messages) != len(last.Messages)+1 {
+		t.Fatalf("trimmed history was not kept: %d, %d", len(conv.Messages), len(last.Messages))
+	}
+}
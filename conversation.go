@@ -0,0 +1,286 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// ConversationUsage accumulates the token usage of every turn of a
+// Conversation.
+type ConversationUsage struct {
+	Requests                 int `json:"requests"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u *ConversationUsage) add(usage MessagesUsage) {
+	u.Requests++
+	u.InputTokens += usage.InputTokens
+	u.OutputTokens += usage.OutputTokens
+	u.CacheCreationInputTokens += usage.CacheCreationInputTokens
+	u.CacheReadInputTokens += usage.CacheReadInputTokens
+}
+
+// Conversation keeps the history of a multi-turn exchange. Each Send appends
+// the user content and the assistant response, merging consecutive messages
+// of the same role, and keeps every response block (thinking blocks and
+// their signatures included) so tool use turns can be continued as the API
+// requires.
+//
+// A Conversation is not safe for concurrent use. It can be persisted with
+// json.Marshal and restored with LoadConversation.
+type Conversation struct {
+	// Request is the template of every turn: model, system prompt, tools and
+	// any other setting. Its Messages field is replaced by the history.
+	Request MessagesRequest
+	// Messages is the history sent with the next turn.
+	Messages []Message
+	// Usage is the running total over all turns, including undone ones.
+	Usage ConversationUsage
+	// ContextManager, if set, trims the history before each turn so that it
+	// fits the context window. Trimming is kept in the history and clears the
+	// undo history. It is not persisted.
+	ContextManager *ContextManager
+	// LastContextReport is the report of the last ContextManager.Fit call.
+	LastContextReport ContextReport
+
+	client *Client
+	turns  []conversationMark
+}
+
+// conversationMark records the size of the history before a turn, so Undo
+// can restore it even when the turn was merged into the last message.
+type conversationMark struct {
+	Messages    int `json:"messages"`
+	LastContent int `json:"last_content"`
+}
+
+// NewConversation starts a conversation using request as the template of
+// every turn. Messages already in the request become the initial history.
+func NewConversation(client *Client, request MessagesRequest) *Conversation {
+	c := &Conversation{client: client, Request: request}
+	c.Append(request.Messages...)
+	c.Request.Messages = nil
+	return c
+}
+
+// LoadConversation restores a conversation persisted with json.Marshal.
+func LoadConversation(client *Client, data []byte) (*Conversation, error) {
+	c := &Conversation{client: client}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Append adds messages to the history without sending them, merging each
+// into the last message when they share a role.
+func (c *Conversation) Append(messages ...Message) {
+	for _, m := range messages {
+		c.appendContent(m.Role, m.Content...)
+	}
+}
+
+// NextRequest returns the request the next turn would send, with content
+// appended as a user message. It does not change the conversation, which
+// makes it usable with CreateMessages, CountTokens or a batch directly.
+func (c *Conversation) NextRequest(content ...MessageContent) MessagesRequest {
+	messages := c.Messages
+	if len(content) > 0 {
+		fork := c.Fork()
+		fork.appendContent(RoleUser, content...)
+		messages = fork.Messages
+	}
+
+	request := c.Request
+	request.Messages = append([]Message(nil), messages...)
+	return request
+}
+
+// Send appends content as a user turn, sends the history and appends the
+// response. Sending no content continues the conversation as is, e.g. after
+// a pause_turn stop. On error the history is left unchanged.
+func (c *Conversation) Send(
+	ctx context.Context,
+	content ...MessageContent,
+) (MessagesResponse, error) {
+	if c.client == nil {
+		return MessagesResponse{}, errors.New("conversation has no client")
+	}
+
+	request, err := c.prepare(ctx, content)
+	if err != nil {
+		return MessagesResponse{}, err
+	}
+	resp, err := c.client.CreateMessages(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	c.record(request, content, resp)
+	return resp, nil
+}
+
+// SendStream is the streaming variant of Send. The callbacks of stream are
+// used; its MessagesRequest is replaced by the conversation's next request.
+func (c *Conversation) SendStream(
+	ctx context.Context,
+	stream MessagesStreamRequest,
+	content ...MessageContent,
+) (MessagesResponse, error) {
+	if c.client == nil {
+		return MessagesResponse{}, errors.New("conversation has no client")
+	}
+
+	request, err := c.prepare(ctx, content)
+	if err != nil {
+		return MessagesResponse{}, err
+	}
+	stream.MessagesRequest = request
+	resp, err := c.client.CreateMessagesStream(ctx, stream)
+	if err != nil {
+		return resp, err
+	}
+
+	c.record(request, content, resp)
+	return resp, nil
+}
+
+// Fork returns an independent copy of the conversation that shares its
+// client. Changes to either history do not affect the other.
+func (c *Conversation) Fork() *Conversation {
+	fork := *c
+	fork.Messages = append([]Message(nil), c.Messages...)
+	fork.turns = append([]conversationMark(nil), c.turns...)
+	return &fork
+}
+
+// Undo removes the last turn sent with Send or SendStream, both the user
+// content and the response. It reports false when there is nothing to undo.
+func (c *Conversation) Undo() bool {
+	if len(c.turns) == 0 {
+		return false
+	}
+
+	mark := c.turns[len(c.turns)-1]
+	c.turns = c.turns[:len(c.turns)-1]
+
+	c.Messages = c.Messages[:mark.Messages]
+	if mark.LastContent > 0 {
+		last := c.Messages[len(c.Messages)-1]
+		last.Content = append([]MessageContent(nil), last.Content[:mark.LastContent]...)
+		c.Messages[len(c.Messages)-1] = last
+	}
+	return true
+}
+
+// LastResponseText returns the text of the last assistant message.
+func (c *Conversation) LastResponseText() string {
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		if c.Messages[i].Role != RoleAssistant {
+			continue
+		}
+		var text string
+		for _, content := range c.Messages[i].Content {
+			if content.Type == MessagesContentTypeText {
+				text += content.GetText()
+			}
+		}
+		return text
+	}
+	return ""
+}
+
+// prepare returns the next request, fitted to the context window when the
+// conversation has a ContextManager.
+func (c *Conversation) prepare(
+	ctx context.Context,
+	content []MessageContent,
+) (MessagesRequest, error) {
+	request := c.NextRequest(content...)
+	if c.ContextManager == nil {
+		return request, nil
+	}
+
+	request, report, err := c.ContextManager.Fit(ctx, request)
+	c.LastContextReport = report
+	return request, err
+}
+
+func (c *Conversation) record(
+	request MessagesRequest,
+	content []MessageContent,
+	resp MessagesResponse,
+) {
+	if c.ContextManager != nil && c.LastContextReport.Trimmed() {
+		// The trimmed history replaces the old one; earlier marks no longer
+		// point into it.
+		c.Messages = request.Messages
+		c.turns = nil
+		c.appendContent(RoleAssistant, resp.Content...)
+		c.Usage.add(resp.Usage)
+		return
+	}
+
+	mark := conversationMark{Messages: len(c.Messages)}
+	if len(c.Messages) > 0 {
+		mark.LastContent = len(c.Messages[len(c.Messages)-1].Content)
+	}
+	c.turns = append(c.turns, mark)
+
+	c.appendContent(RoleUser, content...)
+	c.appendContent(RoleAssistant, resp.Content...)
+	c.Usage.add(resp.Usage)
+}
+
+func (c *Conversation) appendContent(role ChatRole, content ...MessageContent) {
+	if len(content) == 0 {
+		return
+	}
+
+	if n := len(c.Messages); n > 0 && c.Messages[n-1].Role == role {
+		// Copy instead of appending in place: forks may share the slice.
+		last := c.Messages[n-1]
+		merged := make([]MessageContent, 0, len(last.Content)+len(content))
+		merged = append(merged, last.Content...)
+		merged = append(merged, content...)
+		c.Messages[n-1] = Message{Role: role, Content: merged}
+		return
+	}
+
+	c.Messages = append(c.Messages, Message{
+		Role:    role,
+		Content: append([]MessageContent(nil), content...),
+	})
+}
+
+type conversationJSON struct {
+	Request  MessagesRequest    `json:"request"`
+	Messages []Message          `json:"messages"`
+	Usage    ConversationUsage  `json:"usage"`
+	Turns    []conversationMark `json:"turns,omitempty"`
+}
+
+func (c Conversation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(conversationJSON{
+		Request:  c.Request,
+		Messages: c.Messages,
+		Usage:    c.Usage,
+		Turns:    c.turns,
+	})
+}
+
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var aux conversationJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	c.Request = aux.Request
+	c.Messages = aux.Messages
+	c.Usage = aux.Usage
+	c.turns = aux.Turns
+	return nil
+}
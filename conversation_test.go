@@ -0,0 +1,164 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+// conversationServer answers every request with a text block counting the
+// messages it received, and records the last request.
+func conversationServer(t *testing.T) (*anthropic.Client, *anthropic.MessagesRequest) {
+	t.Helper()
+	last := &anthropic.MessagesRequest{}
+
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		var req anthropic.MessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		*last = req
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","content":[`+
+			`{"type":"thinking","thinking":"hmm","signature":"sig_%d"},`+
+			`{"type":"text","text":"reply %d"}],"stop_reason":"end_turn",`+
+			`"usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":2}}`,
+			len(req.Messages), len(req.Messages))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	return anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1")), last
+}
+
+func TestConversation(t *testing.T) {
+	client, last := conversationServer(t)
+	ctx := context.Background()
+
+	conv := anthropic.NewConversation(client, anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		MaxTokens: 1000,
+		System:    "be brief",
+	})
+
+	resp, err := conv.Send(ctx, anthropic.NewTextMessageContent("hi"))
+	if err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if resp.ID != "msg_1" || conv.LastResponseText() != "reply 1" {
+		t.Fatalf("unexpected reply %q", conv.LastResponseText())
+	}
+	if last.System != "be brief" || len(last.Messages) != 1 {
+		t.Fatalf("unexpected request %+v", last)
+	}
+
+	if _, err := conv.Send(ctx, anthropic.NewTextMessageContent("again")); err != nil {
+		t.Fatalf("Send error: %v", err)
+	}
+	if len(last.Messages) != 3 || last.Messages[1].Role != anthropic.RoleAssistant {
+		t.Fatalf("history was not sent: %+v", last.Messages)
+	}
+	thinking := last.Messages[1].Content[0]
+	if thinking.MessageContentThinking == nil || thinking.Signature != "sig_1" {
+		t.Fatalf("thinking block was not kept: %+v", thinking)
+	}
+
+	if conv.Usage.Requests != 2 || conv.Usage.InputTokens != 20 ||
+		conv.Usage.OutputTokens != 10 || conv.Usage.CacheReadInputTokens != 4 {
+		t.Fatalf("unexpected usage %+v", conv.Usage)
+	}
+
+	t.Run("fork is independent", func(t *testing.T) {
+		fork := conv.Fork()
+		if _, err := fork.Send(ctx, anthropic.NewTextMessageContent("forked")); err != nil {
+			t.Fatalf("Send error: %v", err)
+		}
+		if len(fork.Messages) != 6 || len(conv.Messages) != 4 {
+			t.Fatalf("fork changed the original: %d, %d", len(fork.Messages), len(conv.Messages))
+		}
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		fork := conv.Fork()
+		if !fork.Undo() || len(fork.Messages) != 2 || fork.LastResponseText() != "reply 1" {
+			t.Fatalf("unexpected history after undo: %+v", fork.Messages)
+		}
+		if !fork.Undo() || len(fork.Messages) != 0 || fork.Undo() {
+			t.Fatalf("unexpected history after undoing everything: %+v", fork.Messages)
+		}
+	})
+
+	t.Run("merges consecutive user content", func(t *testing.T) {
+		fork := conv.Fork()
+		fork.Append(anthropic.NewUserTextMessage("note"))
+		req := fork.NextRequest(anthropic.NewTextMessageContent("question"))
+		if len(req.Messages) != 5 || len(req.Messages[4].Content) != 2 {
+			t.Fatalf("user content was not merged: %+v", req.Messages)
+		}
+		if len(fork.Messages) != 5 || len(fork.Messages[4].Content) != 1 {
+			t.Fatalf("NextRequest changed the conversation: %+v", fork.Messages)
+		}
+
+		if _, err := fork.Send(ctx, anthropic.NewTextMessageContent("question")); err != nil {
+			t.Fatal(err)
+		}
+		fork.Undo()
+		if len(fork.Messages) != 5 || len(fork.Messages[4].Content) != 1 {
+			t.Fatalf("undo did not restore the merged message: %+v", fork.Messages)
+		}
+	})
+
+	t.Run("persists", func(t *testing.T) {
+		data, err := json.Marshal(conv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := anthropic.LoadConversation(client, data)
+		if err != nil {
+			t.Fatalf("LoadConversation error: %v", err)
+		}
+		if restored.Request.System != "be brief" || restored.Usage != conv.Usage ||
+			len(restored.Messages) != 4 || restored.LastResponseText() != "reply 3" {
+			t.Fatalf("unexpected restored conversation %+v", restored)
+		}
+		if !restored.Undo() || len(restored.Messages) != 2 {
+			t.Fatalf("undo history was not restored")
+		}
+	})
+}
+
+func TestConversationSendStream(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", handlerMessagesStream)
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+	conv := anthropic.NewConversation(client, anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		MaxTokens: 1000,
+	})
+
+	deltas := 0
+	_, err := conv.SendStream(context.Background(), anthropic.MessagesStreamRequest{
+		OnContentBlockDelta: func(anthropic.MessagesEventContentBlockDeltaData) {
+			deltas++
+		},
+	}, anthropic.NewTextMessageContent("What is your name?"))
+	if err != nil {
+		t.Fatalf("SendStream error: %v", err)
+	}
+	if deltas == 0 || len(conv.Messages) != 2 || conv.LastResponseText() == "" {
+		t.Fatalf("unexpected conversation %+v", conv.Messages)
+	}
+}
@@ -9,6 +9,8 @@ type CountTokensResponse struct {
 	httpHeader
 
 	InputTokens int `json:"input_tokens"`
+	// ContextManagement is set when the request has context management.
+	ContextManagement *CountTokensContextManagement `json:"context_management,omitempty"`
 }
 
 // countTokensRequest is the restricted body sent to the count_tokens
@@ -27,6 +29,8 @@ type countTokensRequest struct {
 	Thinking     *Thinking            `json:"thinking,omitempty"`
 	CacheControl *MessageCacheControl `json:"cache_control,omitempty"`
 	OutputConfig *OutputConfig        `json:"output_config,omitempty"`
+
+	ContextManagement *ContextManagement `json:"context_management,omitempty"`
 }
 
 // newCountTokensRequest builds the restricted count_tokens body from a
@@ -41,6 +45,8 @@ func newCountTokensRequest(request MessagesRequest) countTokensRequest {
 		Thinking:     request.Thinking,
 		CacheControl: request.CacheControl,
 		OutputConfig: request.OutputConfig,
+
+		ContextManagement: request.ContextManagement,
 	}
 
 	if len(request.MultiSystem) > 0 {
@@ -55,7 +61,11 @@ func newCountTokensRequest(request MessagesRequest) countTokensRequest {
 func (c *Client) CountTokens(
 	ctx context.Context,
 	request MessagesRequest,
+	opts ...RequestOption,
 ) (response CountTokensResponse, err error) {
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
+
 	var setters []requestSetter
 	if len(c.config.BetaVersion) > 0 {
 		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
@@ -0,0 +1,290 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+type FileId string
+
+type FileMetadata struct {
+	ID           FileId    `json:"id"`
+	Type         string    `json:"type"`
+	Filename     string    `json:"filename"`
+	MimeType     string    `json:"mime_type"`
+	SizeBytes    int64     `json:"size_bytes"`
+	CreatedAt    time.Time `json:"created_at"`
+	Downloadable bool      `json:"downloadable"`
+}
+
+type FileResponse struct {
+	httpHeader
+
+	FileMetadata
+}
+
+type UploadFileRequest struct {
+	// Filename is sent as the multipart file name and reported back in the metadata.
+	Filename string
+	// MimeType is the content type of the file. When empty it is derived from
+	// the Filename extension, falling back to application/octet-stream.
+	MimeType string
+	// File is read once and streamed to the API without being buffered in memory.
+	File io.Reader
+}
+
+func (u UploadFileRequest) validate() error {
+	if u.Filename == "" {
+		return errors.New("filename is required")
+	}
+	if u.File == nil {
+		return errors.New("file is required")
+	}
+
+	return nil
+}
+
+func (u UploadFileRequest) mimeType() string {
+	if u.MimeType != "" {
+		return u.MimeType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(u.Filename)); t != "" {
+		return t
+	}
+
+	return "application/octet-stream"
+}
+
+// UploadFile uploads a file to the Files API. The returned ID can be referenced
+// from messages with NewFileDocumentMessageContent or NewFileImageMessageContent,
+// which requires BetaFilesAPI20250414 to be set on the client.
+func (c *Client) UploadFile(
+	ctx context.Context,
+	uFileReq UploadFileRequest,
+) (*FileResponse, error) {
+	if err := uFileReq.validate(); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipartFile(mw, uFileReq))
+	}()
+
+	setters := c.betaSetters(BetaFilesAPI20250414)
+	setters = append(setters, withMultipartBody(pr, mw.FormDataContentType()))
+	req, err := c.newRequest(ctx, http.MethodPost, "/files", nil, setters...)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+
+	var response FileResponse
+	err = c.sendRequest(req, &response)
+	// unblock the writer if the request failed before the body was consumed
+	pr.Close()
+
+	return &response, err
+}
+
+func writeMultipartFile(mw *multipart.Writer, uFileReq UploadFileRequest) error {
+	header := make(textproto.MIMEHeader)
+	header.Set(
+		"Content-Disposition",
+		fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(uFileReq.Filename)),
+	)
+	header.Set("Content-Type", uFileReq.mimeType())
+
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, uFileReq.File); err != nil {
+		return fmt.Errorf("error, reading file: %w", err)
+	}
+
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+func withMultipartBody(body io.ReadCloser, contentType string) requestSetter {
+	return func(req *http.Request) {
+		req.Body = body
+		req.GetBody = nil
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", contentType)
+	}
+}
+
+type ListFilesResponse struct {
+	httpHeader
+
+	Data    []FileMetadata `json:"data"`
+	HasMore bool           `json:"has_more"`
+	FirstId *FileId        `json:"first_id"`
+	LastId  *FileId        `json:"last_id"`
+}
+
+type ListFilesRequest struct {
+	BeforeId *FileId `json:"before_id,omitempty"`
+	AfterId  *FileId `json:"after_id,omitempty"`
+	Limit    *int    `json:"limit,omitempty"`
+}
+
+func (l ListFilesRequest) validate() error {
+	if l.Limit != nil && (*l.Limit < 1 || *l.Limit > 1000) {
+		return errors.New("limit must be between 1 and 1000")
+	}
+
+	return nil
+}
+
+// ListFiles lists uploaded files, most recently created first.
+func (c *Client) ListFiles(
+	ctx context.Context,
+	lFileReq ListFilesRequest,
+) (*ListFilesResponse, error) {
+	if err := lFileReq.validate(); err != nil {
+		return nil, err
+	}
+
+	urlSuffix := "/files"
+
+	v := url.Values{}
+	if lFileReq.BeforeId != nil {
+		v.Set("before_id", string(*lFileReq.BeforeId))
+	}
+	if lFileReq.AfterId != nil {
+		v.Set("after_id", string(*lFileReq.AfterId))
+	}
+	if lFileReq.Limit != nil {
+		v.Set("limit", fmt.Sprintf("%d", *lFileReq.Limit))
+	}
+
+	if encoded := v.Encode(); encoded != "" {
+		urlSuffix += "?" + encoded
+	}
+	setters := c.betaSetters(BetaFilesAPI20250414)
+	req, err := c.newRequest(ctx, http.MethodGet, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ListFilesResponse
+	err = c.sendRequest(req, &response)
+
+	return &response, err
+}
+
+func (c *Client) GetFileMetadata(
+	ctx context.Context,
+	fileId FileId,
+) (*FileResponse, error) {
+	urlSuffix := "/files/" + url.PathEscape(string(fileId))
+	setters := c.betaSetters(BetaFilesAPI20250414)
+	req, err := c.newRequest(ctx, http.MethodGet, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response FileResponse
+	err = c.sendRequest(req, &response)
+
+	return &response, err
+}
+
+type DownloadFileResponse struct {
+	httpHeader
+
+	// Body streams the file content. The caller must close it.
+	Body io.ReadCloser
+}
+
+// DownloadFile streams the content of a file. Only files created by tools,
+// such as code execution, are downloadable; see FileMetadata.Downloadable.
+func (c *Client) DownloadFile(
+	ctx context.Context,
+	fileId FileId,
+) (*DownloadFileResponse, error) {
+	urlSuffix := "/files/" + url.PathEscape(string(fileId)) + "/content"
+	setters := c.betaSetters(BetaFilesAPI20250414)
+	req, err := c.newRequest(ctx, http.MethodGet, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "*/*")
+
+	res, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DownloadFileResponse
+	response.SetHeader(res.Header)
+
+	if err := c.handlerRequestError(res); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	response.Body = res.Body
+
+	return &response, nil
+}
+
+type DeleteFileResponse struct {
+	httpHeader
+
+	ID   FileId `json:"id"`
+	Type string `json:"type"`
+}
+
+func (c *Client) DeleteFile(
+	ctx context.Context,
+	fileId FileId,
+) (*DeleteFileResponse, error) {
+	urlSuffix := "/files/" + url.PathEscape(string(fileId))
+	setters := c.betaSetters(BetaFilesAPI20250414)
+	req, err := c.newRequest(ctx, http.MethodDelete, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DeleteFileResponse
+	err = c.sendRequest(req, &response)
+
+	return &response, err
+}
+
+// betaSetters returns the client's beta versions with extra added, for
+// endpoints and features that always require a beta, such as the Files API.
+func (c *Client) betaSetters(extra ...BetaVersion) []requestSetter {
+	betas := c.config.BetaVersion
+	for _, beta := range extra {
+		if !slices.Contains(betas, beta) {
+			betas = append(slices.Clip(betas), beta)
+		}
+	}
+	if len(betas) == 0 {
+		return nil
+	}
+
+	return []requestSetter{withBetaVersion(betas...)}
+}
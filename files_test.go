@@ -0,0 +1,206 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestFiles(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/files", handleFilesEndpoint)
+	server.RegisterHandler("/v1/files/file_1", handleFileEndpoint)
+	server.RegisterHandler("/v1/files/file_1/content", handleFileContentEndpoint)
+
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithBetaVersion(anthropic.BetaPromptCaching20240731),
+	)
+	ctx := context.Background()
+
+	t.Run("upload", func(t *testing.T) {
+		resp, err := client.UploadFile(ctx, anthropic.UploadFileRequest{
+			Filename: "report.pdf",
+			File:     strings.NewReader("%PDF-1.7 content"),
+		})
+		if err != nil {
+			t.Fatalf("UploadFile error: %s", err)
+		}
+		if resp.ID != "file_1" || resp.Filename != "report.pdf" ||
+			resp.MimeType != "application/pdf" || resp.SizeBytes != 16 {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("upload without a file", func(t *testing.T) {
+		_, err := client.UploadFile(ctx, anthropic.UploadFileRequest{Filename: "report.pdf"})
+		if err == nil {
+			t.Fatal("UploadFile expected error, got nil")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		after := anthropic.FileId("file_0")
+		resp, err := client.ListFiles(ctx, anthropic.ListFilesRequest{
+			AfterId: &after,
+			Limit:   toPtr(1),
+		})
+		if err != nil {
+			t.Fatalf("ListFiles error: %s", err)
+		}
+		if len(resp.Data) != 1 || !resp.HasMore || *resp.LastId != "file_1" {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+
+		_, err = client.ListFiles(ctx, anthropic.ListFilesRequest{Limit: toPtr(1001)})
+		if err == nil {
+			t.Fatal("ListFiles expected error, got nil")
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		resp, err := client.GetFileMetadata(ctx, "file_1")
+		if err != nil {
+			t.Fatalf("GetFileMetadata error: %s", err)
+		}
+		if resp.ID != "file_1" || !resp.Downloadable {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+	})
+
+	t.Run("download", func(t *testing.T) {
+		resp, err := client.DownloadFile(ctx, "file_1")
+		if err != nil {
+			t.Fatalf("DownloadFile error: %s", err)
+		}
+		defer resp.Body.Close()
+		content, err := io.ReadAll(resp.Body)
+		if err != nil || string(content) != "file content" {
+			t.Fatalf("unexpected content %q, %v", content, err)
+		}
+
+		if _, err := client.DownloadFile(ctx, "file_2"); err == nil {
+			t.Fatal("DownloadFile expected error, got nil")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp, err := client.DeleteFile(ctx, "file_1")
+		if err != nil {
+			t.Fatalf("DeleteFile error: %s", err)
+		}
+		if resp.ID != "file_1" || resp.Type != "file_deleted" {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+	})
+}
+
+func TestFileMessageContent(t *testing.T) {
+	doc, _ := json.Marshal(anthropic.NewFileDocumentMessageContent("file_1", "", "", false))
+	img, _ := json.Marshal(anthropic.NewFileImageMessageContent("file_2"))
+
+	if !strings.Contains(string(doc), `"source":{"type":"file","file_id":"file_1"}`) {
+		t.Fatalf("unexpected document %s", doc)
+	}
+	if !strings.Contains(string(img), `"source":{"type":"file","file_id":"file_2"}`) {
+		t.Fatalf("unexpected image %s", img)
+	}
+}
+
+func checkFilesBeta(w http.ResponseWriter, r *http.Request) bool {
+	want := string(anthropic.BetaPromptCaching20240731) + "," +
+		string(anthropic.BetaFilesAPI20250414)
+	if r.Header.Get("anthropic-beta") != want {
+		http.Error(w, "unexpected beta header", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func forgeFileMetadata() anthropic.FileMetadata {
+	return anthropic.FileMetadata{
+		ID:           "file_1",
+		Type:         "file",
+		Filename:     "report.pdf",
+		MimeType:     "application/pdf",
+		SizeBytes:    16,
+		CreatedAt:    time.Date(2025, time.April, 14, 0, 0, 0, 0, time.UTC),
+		Downloadable: true,
+	}
+}
+
+func handleFilesEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !checkFilesBeta(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+
+		res := forgeFileMetadata()
+		res.Filename = header.Filename
+		res.MimeType = header.Header.Get("Content-Type")
+		res.SizeBytes = int64(len(content))
+		resBytes, _ := json.Marshal(res)
+		_, _ = w.Write(resBytes)
+	case http.MethodGet:
+		if r.URL.Query().Get("after_id") != "file_0" || r.URL.Query().Get("limit") != "1" {
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+			return
+		}
+		last := anthropic.FileId("file_1")
+		res := anthropic.ListFilesResponse{
+			Data:    []anthropic.FileMetadata{forgeFileMetadata()},
+			HasMore: true,
+			FirstId: &last,
+			LastId:  &last,
+		}
+		resBytes, _ := json.Marshal(res)
+		_, _ = w.Write(resBytes)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleFileEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !checkFilesBeta(w, r) {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		resBytes, _ := json.Marshal(forgeFileMetadata())
+		_, _ = w.Write(resBytes)
+	case http.MethodDelete:
+		_, _ = w.Write([]byte(`{"id":"file_1","type":"file_deleted"}`))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleFileContentEndpoint(w http.ResponseWriter, r *http.Request) {
+	if !checkFilesBeta(w, r) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write([]byte("file content"))
+}
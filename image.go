@@ -0,0 +1,305 @@
+package anthropic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the GIF decoder for image.Decode
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+)
+
+const (
+	// MaxImageBytes is the API limit on a single image, measured on its
+	// base64 encoding.
+	MaxImageBytes = 5 * 1024 * 1024
+	// MaxImageDimension is the API limit on either edge of an image, in pixels.
+	MaxImageDimension = 8000
+	// RecommendedImageLongEdge is the longest edge the API uses without
+	// resizing. Larger images are downscaled server-side, so sending them
+	// only adds latency.
+	RecommendedImageLongEdge = 1568
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrImageTooLarge        = errors.New("image exceeds the API limits")
+)
+
+type imageOptions struct {
+	downscale bool
+	longEdge  int
+	maxBytes  int
+}
+
+type ImageOption func(*imageOptions)
+
+// WithImageDownscale downscales images whose long edge exceeds longEdge, or
+// RecommendedImageLongEdge when longEdge is 0, and keeps shrinking them until
+// they fit MaxImageBytes. WebP images cannot be decoded with the standard
+// library and are only validated.
+func WithImageDownscale(longEdge int) ImageOption {
+	return func(o *imageOptions) {
+		o.downscale = true
+		if longEdge > 0 {
+			o.longEdge = longEdge
+		}
+	}
+}
+
+// WithImageMaxBytes lowers the base64-encoded size limit below MaxImageBytes.
+func WithImageMaxBytes(n int) ImageOption {
+	return func(o *imageOptions) {
+		o.maxBytes = n
+	}
+}
+
+// DetectImageMediaType sniffs the media type of JPEG, PNG, GIF and WebP data.
+func DetectImageMediaType(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg", nil
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif", nil
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "image/webp", nil
+	}
+
+	return "", ErrUnsupportedImageType
+}
+
+// NewImageMessageContentFromBytes returns data as a base64 image block. The
+// media type is sniffed and the image is checked against MaxImageBytes and
+// MaxImageDimension, or downscaled to fit when WithImageDownscale is set.
+func NewImageMessageContentFromBytes(
+	data []byte,
+	opts ...ImageOption,
+) (MessageContent, error) {
+	o := imageOptions{longEdge: RecommendedImageLongEdge, maxBytes: MaxImageBytes}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	mediaType, err := DetectImageMediaType(data)
+	if err != nil {
+		return MessageContent{}, err
+	}
+	width, height, err := imageDimensions(data, mediaType)
+	if err != nil {
+		return MessageContent{}, fmt.Errorf("error, reading %s header: %w", mediaType, err)
+	}
+
+	longEdge := max(width, height)
+	tooBig := base64.StdEncoding.EncodedLen(len(data)) > o.maxBytes ||
+		longEdge > MaxImageDimension
+	if o.downscale && mediaType != "image/webp" && (tooBig || longEdge > o.longEdge) {
+		data, mediaType, err = downscaleImage(data, mediaType, o)
+		if err != nil {
+			return MessageContent{}, err
+		}
+	} else if tooBig {
+		return MessageContent{}, fmt.Errorf(
+			"%w: %dx%d pixels, %d bytes encoded; limits are %d pixels per edge and %d bytes",
+			ErrImageTooLarge,
+			width,
+			height,
+			base64.StdEncoding.EncodedLen(len(data)),
+			MaxImageDimension,
+			o.maxBytes,
+		)
+	}
+
+	return NewImageMessageContent(NewMessageContentSource(
+		MessagesContentSourceTypeBase64,
+		mediaType,
+		base64.StdEncoding.EncodeToString(data),
+	)), nil
+}
+
+// NewImageMessageContentFromReader reads r to the end and behaves like
+// NewImageMessageContentFromBytes.
+func NewImageMessageContentFromReader(
+	r io.Reader,
+	opts ...ImageOption,
+) (MessageContent, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return MessageContent{}, fmt.Errorf("error, reading image: %w", err)
+	}
+
+	return NewImageMessageContentFromBytes(data, opts...)
+}
+
+// NewImageMessageContentFromFile reads the image at path and behaves like
+// NewImageMessageContentFromBytes.
+func NewImageMessageContentFromFile(
+	path string,
+	opts ...ImageOption,
+) (MessageContent, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MessageContent{}, err
+	}
+
+	return NewImageMessageContentFromBytes(data, opts...)
+}
+
+// downscaleImage fits the image within o.longEdge and re-encodes it, JPEG as
+// JPEG and everything else as PNG, shrinking by a quarter until it fits
+// o.maxBytes.
+func downscaleImage(data []byte, mediaType string, o imageOptions) ([]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("error, decoding %s: %w", mediaType, err)
+	}
+
+	limit := min(o.longEdge, MaxImageDimension)
+	b := img.Bounds()
+	width, height := fitWithin(b.Dx(), b.Dy(), limit, limit)
+	for {
+		var buf bytes.Buffer
+		scaled := image.Image(img)
+		if width != b.Dx() || height != b.Dy() {
+			scaled = resizeImage(img, width, height)
+		}
+		if mediaType == "image/jpeg" {
+			err = jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, scaled)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if base64.StdEncoding.EncodedLen(buf.Len()) <= o.maxBytes {
+			if mediaType != "image/jpeg" {
+				mediaType = "image/png"
+			}
+			return buf.Bytes(), mediaType, nil
+		}
+		if width == 1 && height == 1 {
+			return nil, "", fmt.Errorf(
+				"%w: cannot downscale below %d bytes",
+				ErrImageTooLarge,
+				o.maxBytes,
+			)
+		}
+		width, height = max(1, width*3/4), max(1, height*3/4)
+	}
+}
+
+// imageDimensions reads the pixel size from the image header without
+// decoding the image.
+func imageDimensions(data []byte, mediaType string) (int, int, error) {
+	if mediaType == "image/webp" {
+		return webpDimensions(data)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// webpDimensions parses the first chunk of a WebP file, which the standard
+// library has no decoder for.
+func webpDimensions(data []byte) (int, int, error) {
+	if len(data) < 30 {
+		return 0, 0, io.ErrUnexpectedEOF
+	}
+
+	chunk := data[20:]
+	switch string(data[12:16]) {
+	case "VP8 ":
+		// lossy: 3 byte frame tag, 3 byte start code, then 14 bit sizes
+		width := binary.LittleEndian.Uint16(chunk[6:8]) & 0x3fff
+		height := binary.LittleEndian.Uint16(chunk[8:10]) & 0x3fff
+		return int(width), int(height), nil
+	case "VP8L":
+		// lossless: signature byte, then 14 bit sizes minus one
+		bits := binary.LittleEndian.Uint32(chunk[1:5])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case "VP8X":
+		// extended: 4 byte flags, then 24 bit sizes minus one
+		width := uint32(chunk[4]) | uint32(chunk[5])<<8 | uint32(chunk[6])<<16
+		height := uint32(chunk[7]) | uint32(chunk[8])<<8 | uint32(chunk[9])<<16
+		return int(width) + 1, int(height) + 1, nil
+	}
+
+	return 0, 0, errors.New("unknown WebP chunk")
+}
+
+// NewPNGImageMessageContent encodes img as PNG and returns it as a base64
+// image block.
+func NewPNGImageMessageContent(img image.Image) (MessageContent, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return MessageContent{}, err
+	}
+
+	return NewImageMessageContent(NewMessageContentSource(
+		MessagesContentSourceTypeBase64,
+		"image/png",
+		base64.StdEncoding.EncodeToString(buf.Bytes()),
+	)), nil
+}
+
+// resizeImage scales src to width x height. Each destination pixel averages
+// the source pixels it covers, which keeps small text legible when
+// downscaling; upscaling degrades to nearest-neighbor.
+func resizeImage(src image.Image, width, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW == 0 || srcH == 0 || width == 0 || height == 0 {
+		return dst
+	}
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*srcH/height
+		y1 := max(b.Min.Y+(y+1)*srcH/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*srcW/width
+			x1 := max(b.Min.X+(x+1)*srcW/width, x0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// fitWithin returns the largest size with the aspect ratio of width x height
+// that fits within maxWidth x maxHeight. It never upscales.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= maxWidth && height <= maxHeight {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, max(1, height*maxWidth/width)
+	}
+	return max(1, width*maxHeight/height), maxHeight
+}
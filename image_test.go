@@ -0,0 +1,155 @@
+package anthropic_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func encodeTestImage(t *testing.T, width, height int, asJPEG bool) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x ^ y), A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if asJPEG {
+		err = jpeg.Encode(&buf, img, nil)
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodeImageContent(t *testing.T, content anthropic.MessageContent) image.Config {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(content.Source.Data.(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestDetectImageMediaType(t *testing.T) {
+	webp := append([]byte("RIFF\x00\x00\x00\x00WEBP"), make([]byte, 18)...)
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{encodeTestImage(t, 1, 1, true), "image/jpeg"},
+		{encodeTestImage(t, 1, 1, false), "image/png"},
+		{[]byte("GIF89a\x01\x00"), "image/gif"},
+		{webp, "image/webp"},
+	}
+	for _, tt := range tests {
+		if got, err := anthropic.DetectImageMediaType(tt.data); err != nil || got != tt.want {
+			t.Errorf("got %q, %v, want %q", got, err, tt.want)
+		}
+	}
+
+	_, err := anthropic.DetectImageMediaType([]byte("%PDF-1.7"))
+	if !errors.Is(err, anthropic.ErrUnsupportedImageType) {
+		t.Fatalf("expected ErrUnsupportedImageType, got %v", err)
+	}
+}
+
+func TestNewImageMessageContentFromBytes(t *testing.T) {
+	t.Run("within limits", func(t *testing.T) {
+		content, err := anthropic.NewImageMessageContentFromBytes(encodeTestImage(t, 40, 20, true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content.Type != anthropic.MessagesContentTypeImage ||
+			content.Source.MediaType != "image/jpeg" {
+			t.Fatalf("unexpected content %+v", content)
+		}
+	})
+
+	t.Run("too many pixels", func(t *testing.T) {
+		_, err := anthropic.NewImageMessageContentFromBytes(encodeTestImage(t, 8001, 1, false))
+		if !errors.Is(err, anthropic.ErrImageTooLarge) {
+			t.Fatalf("expected ErrImageTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("downscale to the recommended long edge", func(t *testing.T) {
+		content, err := anthropic.NewImageMessageContentFromBytes(
+			encodeTestImage(t, 3000, 1000, true),
+			anthropic.WithImageDownscale(0),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := decodeImageContent(t, content)
+		if cfg.Width != anthropic.RecommendedImageLongEdge || cfg.Height != 522 ||
+			content.Source.MediaType != "image/jpeg" {
+			t.Fatalf("unexpected image %+v, %s", cfg, content.Source.MediaType)
+		}
+	})
+
+	t.Run("downscale to fit the byte limit", func(t *testing.T) {
+		data := encodeTestImage(t, 200, 200, false)
+		limit := base64.StdEncoding.EncodedLen(len(data)) / 2
+		content, err := anthropic.NewImageMessageContentFromBytes(
+			data,
+			anthropic.WithImageDownscale(0),
+			anthropic.WithImageMaxBytes(limit),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg := decodeImageContent(t, content)
+		if cfg.Width >= 200 || len(content.Source.Data.(string)) > limit {
+			t.Fatalf("image was not shrunk: %+v", cfg)
+		}
+	})
+
+	t.Run("webp is validated from its header", func(t *testing.T) {
+		// VP8X chunk declaring a 9000x100 canvas
+		webp := []byte("RIFF\x00\x00\x00\x00WEBPVP8X\x0a\x00\x00\x00" +
+			"\x00\x00\x00\x00\x27\x23\x00\x63\x00\x00")
+		_, err := anthropic.NewImageMessageContentFromBytes(webp, anthropic.WithImageDownscale(0))
+		if !errors.Is(err, anthropic.ErrImageTooLarge) {
+			t.Fatalf("expected ErrImageTooLarge, got %v", err)
+		}
+	})
+}
+
+func TestNewImageMessageContentFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screenshot.png")
+	if err := os.WriteFile(path, encodeTestImage(t, 10, 10, false), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := anthropic.NewImageMessageContentFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.Source.MediaType != "image/png" {
+		t.Fatalf("unexpected content %+v", content)
+	}
+
+	if _, err := anthropic.NewImageMessageContentFromFile(path + ".missing"); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+}
@@ -28,12 +28,18 @@ type Definition struct {
 	// Enum is used to restrict a value to a fixed set of values. It must be an array with at least
 	// one element, where each element is unique. You will probably only use this with strings.
 	Enum []string `json:"enum,omitempty"`
+	// ContentEncoding names the encoding of a string's content, such as "base64".
+	ContentEncoding string `json:"contentEncoding,omitempty"`
 	// Properties describes the properties of an object, if the schema type is Object.
 	Properties map[string]Definition `json:"properties"`
 	// Required specifies which properties are required, if the schema type is Object.
 	Required []string `json:"required,omitempty"`
 	// Items specifies which data type an array contains, if the schema type is Array.
 	Items *Definition `json:"items,omitempty"`
+	// AdditionalProperties is used to control the handling of properties in an object
+	// that are not explicitly defined in the properties section of the schema. Structured
+	// outputs require it to be false on every object.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
 }
 
 func (d Definition) MarshalJSON() ([]byte, error) {
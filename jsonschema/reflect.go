@@ -0,0 +1,139 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// GenerateSchemaForType derives a Definition from the Go type of v, following
+// encoding/json field naming. Fields are required unless tagged omitempty,
+// objects disallow additional properties, and the `description` and `enum`
+// (comma separated) struct tags fill in the matching keywords. Recursive
+// types are not supported.
+func GenerateSchemaForType(v any) (*Definition, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, fmt.Errorf("cannot generate a schema for a nil value")
+	}
+	return reflectSchema(t, map[reflect.Type]bool{})
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+)
+
+func reflectSchema(t reflect.Type, seen map[reflect.Type]bool) (*Definition, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Definition{Type: String, Description: "RFC 3339 date-time"}, nil
+	case t == rawMessageType, t.Kind() == reflect.Interface:
+		return &Definition{}, nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return nil, fmt.Errorf("cannot derive a schema for %s, which has a custom MarshalJSON", t)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Definition{Type: String}, nil
+	case reflect.Bool:
+		return &Definition{Type: Boolean}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Definition{Type: Integer}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Definition{Type: Number}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json marshals []byte as a base64 string.
+			return &Definition{Type: String, ContentEncoding: "base64"}, nil
+		}
+		items, err := reflectSchema(t.Elem(), seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Definition{Type: Array, Items: items}, nil
+	case reflect.Struct:
+		return reflectObject(t, seen)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+func reflectObject(t reflect.Type, seen map[reflect.Type]bool) (*Definition, error) {
+	if seen[t] {
+		return nil, fmt.Errorf("recursive type %s is not supported", t)
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	d := &Definition{
+		Type:                 Object,
+		Properties:           map[string]Definition{},
+		Required:             []string{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened, as encoding/json does.
+		embedded := field.Type
+		if embedded.Kind() == reflect.Pointer {
+			embedded = embedded.Elem()
+		}
+		if field.Anonymous && name == "" && embedded.Kind() == reflect.Struct {
+			inner, err := reflectObject(embedded, seen)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range inner.Properties {
+				d.Properties[k] = v
+			}
+			d.Required = append(d.Required, inner.Required...)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := reflectSchema(field.Type, seen)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			prop.Description = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			prop.Enum = strings.Split(enum, ",")
+		}
+
+		d.Properties[name] = *prop
+		if !strings.Contains(opts, "omitempty") {
+			d.Required = append(d.Required, name)
+		}
+	}
+
+	return d, nil
+}
@@ -0,0 +1,99 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2/jsonschema"
+)
+
+type address struct {
+	City string `json:"city" description:"City name"`
+}
+
+type base struct {
+	ID int `json:"id"`
+}
+
+type contact struct {
+	base
+	Name     string    `json:"name"`
+	Email    *string   `json:"email,omitempty"`
+	Plan     string    `json:"plan" enum:"free,pro"`
+	Score    float64   `json:"score"`
+	Tags     []string  `json:"tags"`
+	Address  address   `json:"address"`
+	Internal string    `json:"-"`
+	hidden   string    //nolint:unused
+	Friends  []address `json:"friends,omitempty"`
+	Avatar   []byte    `json:"avatar"`
+}
+
+func TestGenerateSchemaForType(t *testing.T) {
+	def, err := jsonschema.GenerateSchemaForType(contact{})
+	if err != nil {
+		t.Fatalf("GenerateSchemaForType error: %v", err)
+	}
+
+	got := structToMap(t, def)
+	want := structToMap(t, json.RawMessage(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["id", "name", "plan", "score", "tags", "address", "avatar"],
+		"properties": {
+			"id": {"type": "integer", "properties": {}},
+			"name": {"type": "string", "properties": {}},
+			"email": {"type": "string", "properties": {}},
+			"plan": {"type": "string", "enum": ["free", "pro"], "properties": {}},
+			"score": {"type": "number", "properties": {}},
+			"tags": {
+				"type": "array",
+				"items": {"type": "string", "properties": {}},
+				"properties": {}
+			},
+			"address": {
+				"type": "object",
+				"additionalProperties": false,
+				"required": ["city"],
+				"properties": {
+					"city": {"type": "string", "description": "City name", "properties": {}}
+				}
+			},
+			"friends": {
+				"type": "array",
+				"properties": {},
+				"items": {
+					"type": "object",
+					"additionalProperties": false,
+					"required": ["city"],
+					"properties": {
+						"city": {"type": "string", "description": "City name", "properties": {}}
+					}
+				}
+			},
+			"avatar": {"type": "string", "contentEncoding": "base64", "properties": {}}
+		}
+	}`))
+
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Fatalf("got %s\nwant %s", gotJSON, wantJSON)
+	}
+}
+
+type node struct {
+	Children []node `json:"children"`
+}
+
+func TestGenerateSchemaForTypeErrors(t *testing.T) {
+	if _, err := jsonschema.GenerateSchemaForType(node{}); err == nil {
+		t.Fatal("expected an error for a recursive type")
+	}
+	if _, err := jsonschema.GenerateSchemaForType(map[string]chan int{}); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+	if _, err := jsonschema.GenerateSchemaForType(nil); err == nil {
+		t.Fatal("expected an error for nil")
+	}
+}
@@ -0,0 +1,122 @@
+// Package mcp bridges Model Context Protocol servers and the Messages API.
+//
+// A Client connects to a server over stdio or streamable HTTP, lists its
+// tools as []anthropic.ToolDefinition and executes the tool_use blocks Claude
+// returns through tools/call, producing tool_result content.
+//
+// For MCP servers that the API reaches directly (the MCP connector), use
+// anthropic.MessagesRequest.MCPServers instead.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+)
+
+// ProtocolVersion is the MCP protocol revision requested by the client.
+const ProtocolVersion = "2025-06-18"
+
+const (
+	methodInitialize  = "initialize"
+	methodInitialized = "notifications/initialized"
+	methodToolsList   = "tools/list"
+	methodToolsCall   = "tools/call"
+)
+
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type InitializeResult struct {
+	ProtocolVersion string          `json:"protocolVersion"`
+	Capabilities    json.RawMessage `json:"capabilities,omitempty"`
+	ServerInfo      Implementation  `json:"serverInfo"`
+	Instructions    string          `json:"instructions,omitempty"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    struct{}       `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// Client is an MCP client bound to a single server.
+type Client struct {
+	transport  Transport
+	clientInfo Implementation
+	nextID     atomic.Int64
+}
+
+type ClientOption func(c *Client)
+
+// WithClientInfo sets the name and version the client reports to the server.
+func WithClientInfo(name, version string) ClientOption {
+	return func(c *Client) {
+		c.clientInfo = Implementation{Name: name, Version: version}
+	}
+}
+
+func NewClient(transport Transport, opts ...ClientOption) *Client {
+	c := &Client{
+		transport:  transport,
+		clientInfo: Implementation{Name: "go-anthropic", Version: "v2"},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Initialize performs the MCP handshake. It must be called before any other
+// request.
+func (c *Client) Initialize(ctx context.Context) (*InitializeResult, error) {
+	params := initializeParams{
+		ProtocolVersion: ProtocolVersion,
+		ClientInfo:      c.clientInfo,
+	}
+
+	var result InitializeResult
+	if err := c.call(ctx, methodInitialize, params, &result); err != nil {
+		return nil, err
+	}
+
+	notification, err := newMessage(methodInitialized, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.transport.Notify(ctx, notification); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Close closes the underlying transport.
+func (c *Client) Close() error {
+	return c.transport.Close()
+}
+
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	request, err := newMessage(method, params)
+	if err != nil {
+		return err
+	}
+	request.ID = json.RawMessage(strconv.FormatInt(c.nextID.Add(1), 10))
+
+	response, err := c.transport.Call(ctx, request)
+	if err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
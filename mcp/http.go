@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "Mcp-Protocol-Version"
+)
+
+var sseDataPrefix = []byte("data:")
+
+var _ Transport = (*HTTPTransport)(nil)
+
+// HTTPTransport talks to an MCP server using the streamable HTTP transport:
+// every message is POSTed to a single endpoint and the server answers either
+// with a JSON body or with a server-sent event stream.
+type HTTPTransport struct {
+	url        string
+	httpClient *http.Client
+	header     http.Header
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+}
+
+type HTTPTransportOption func(t *HTTPTransport)
+
+func WithHTTPClient(cli *http.Client) HTTPTransportOption {
+	return func(t *HTTPTransport) {
+		t.httpClient = cli
+	}
+}
+
+// WithHeader adds a header, such as Authorization, to every request.
+func WithHeader(key, value string) HTTPTransportOption {
+	return func(t *HTTPTransport) {
+		t.header.Add(key, value)
+	}
+}
+
+func NewHTTPTransport(url string, opts ...HTTPTransportOption) *HTTPTransport {
+	t := &HTTPTransport{
+		url:        url,
+		httpClient: &http.Client{},
+		header:     make(http.Header),
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+func (t *HTTPTransport) Call(ctx context.Context, request Message) (Message, error) {
+	res, err := t.post(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+	defer res.Body.Close()
+
+	if sessionID := res.Header.Get(headerSessionID); sessionID != "" {
+		t.mu.Lock()
+		t.sessionID = sessionID
+		t.mu.Unlock()
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	var response Message
+	if mediaType == "text/event-stream" {
+		response, err = readEventStreamResponse(res.Body, request.ID)
+	} else {
+		err = json.NewDecoder(res.Body).Decode(&response)
+	}
+	if err != nil {
+		return Message{}, err
+	}
+
+	if request.Method == methodInitialize && response.Error == nil {
+		var result InitializeResult
+		if err := json.Unmarshal(response.Result, &result); err == nil {
+			t.mu.Lock()
+			t.protocolVersion = result.ProtocolVersion
+			t.mu.Unlock()
+		}
+	}
+
+	return response, nil
+}
+
+func (t *HTTPTransport) Notify(ctx context.Context, notification Message) error {
+	res, err := t.post(ctx, notification)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	return nil
+}
+
+// Close terminates the session on the server, if one was established.
+func (t *HTTPTransport) Close() error {
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.sessionID = ""
+	t.mu.Unlock()
+
+	if sessionID == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, t.url, nil)
+	if err != nil {
+		return err
+	}
+	t.setHeaders(req, sessionID)
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (t *HTTPTransport) post(ctx context.Context, msg Message) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	t.setHeaders(req, sessionID)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		defer res.Body.Close()
+		resBody, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf(
+			"error, mcp server status code: %d, body: %s",
+			res.StatusCode,
+			bytes.TrimSpace(resBody),
+		)
+	}
+
+	return res, nil
+}
+
+func (t *HTTPTransport) setHeaders(req *http.Request, sessionID string) {
+	for k, v := range t.header {
+		req.Header[k] = v
+	}
+	if sessionID != "" {
+		req.Header.Set(headerSessionID, sessionID)
+	}
+
+	t.mu.Lock()
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+	t.mu.Unlock()
+}
+
+// readEventStreamResponse reads server-sent events until the response to the
+// request with the given ID arrives. Notifications and server requests sent on
+// the same stream are skipped.
+func readEventStreamResponse(r io.Reader, id json.RawMessage) (Message, error) {
+	reader := bufio.NewReader(r)
+	var data bytes.Buffer
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+
+		if bytes.HasPrefix(line, sseDataPrefix) {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.Write(bytes.TrimSpace(bytes.TrimPrefix(line, sseDataPrefix)))
+		}
+
+		// A blank line, or the end of the stream, dispatches the pending event.
+		if (len(line) == 0 || readErr != nil) && data.Len() > 0 {
+			var msg Message
+			if err := json.Unmarshal(data.Bytes(), &msg); err != nil {
+				return Message{}, err
+			}
+			data.Reset()
+			if msg.isResponse() && bytes.Equal(msg.ID, id) {
+				return msg, nil
+			}
+		}
+
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return Message{}, fmt.Errorf("mcp event stream ended without a response")
+			}
+			return Message{}, readErr
+		}
+	}
+}
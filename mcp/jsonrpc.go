@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const jsonrpcVersion = "2.0"
+
+const (
+	// JSON-RPC error codes used by MCP servers.
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+var ErrTransportClosed = errors.New("mcp transport closed")
+
+// Message is a JSON-RPC 2.0 message. Depending on which fields are set it is a
+// request (ID and Method), a notification (Method only) or a response (ID and
+// either Result or Error).
+type Message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+func (m Message) isResponse() bool {
+	return len(m.ID) > 0 && m.Method == ""
+}
+
+func (m Message) isRequest() bool {
+	return len(m.ID) > 0 && m.Method != ""
+}
+
+// Error is a JSON-RPC error object returned by the server.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("mcp error code: %d, message: %s", e.Code, e.Message)
+}
+
+// Transport carries JSON-RPC messages between the client and an MCP server.
+type Transport interface {
+	// Call sends a request and waits for the response carrying the same ID.
+	Call(ctx context.Context, request Message) (Message, error)
+	// Notify sends a notification, which never gets a response.
+	Notify(ctx context.Context, notification Message) error
+	// Close releases the connection and, when owned, the server process.
+	Close() error
+}
+
+func newMessage(method string, params any) (Message, error) {
+	msg := Message{
+		JSONRPC: jsonrpcVersion,
+		Method:  method,
+	}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return msg, err
+		}
+		msg.Params = raw
+	}
+	return msg, nil
+}
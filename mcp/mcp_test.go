@@ -0,0 +1,379 @@
+package mcp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/mcp"
+)
+
+const fakeServerEnv = "GO_ANTHROPIC_FAKE_MCP_SERVER"
+
+// TestMain lets the test binary double as a fake stdio MCP server process.
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeServerEnv) == "1" {
+		serveFakeStdio()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func serveFakeStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	// Servers may write log lines to stdout; the client must skip them.
+	fmt.Println("fake mcp server starting")
+	for scanner.Scan() {
+		var msg mcp.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		if msg.Method == "tools/call" {
+			// Exercise server-initiated requests before answering.
+			_ = enc.Encode(mcp.Message{
+				JSONRPC: "2.0",
+				ID:      json.RawMessage(`"srv-1"`),
+				Method:  "ping",
+			})
+		}
+		if reply, ok := fakeReply(msg); ok {
+			_ = enc.Encode(reply)
+		}
+	}
+}
+
+func fakeReply(msg mcp.Message) (mcp.Message, bool) {
+	if len(msg.ID) == 0 || msg.Method == "" {
+		return mcp.Message{}, false
+	}
+
+	reply := mcp.Message{JSONRPC: "2.0", ID: msg.ID}
+	var result any
+	switch msg.Method {
+	case "initialize":
+		result = map[string]any{
+			"protocolVersion": mcp.ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake", "version": "1.0.0"},
+		}
+	case "tools/list":
+		var params struct {
+			Cursor string `json:"cursor"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		if params.Cursor == "" {
+			result = map[string]any{
+				"tools": []map[string]any{{
+					"name":        "echo",
+					"description": "Echo the input text",
+					"inputSchema": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"text": map[string]any{"type": "string"}},
+						"required":   []string{"text"},
+					},
+				}},
+				"nextCursor": "page-2",
+			}
+		} else {
+			result = map[string]any{
+				"tools": []map[string]any{
+					{"name": "add", "title": "Add numbers"},
+					{"name": "fail", "description": "Always fails"},
+				},
+			}
+		}
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		_ = json.Unmarshal(msg.Params, &params)
+		switch params.Name {
+		case "echo":
+			var args struct {
+				Text string `json:"text"`
+			}
+			_ = json.Unmarshal(params.Arguments, &args)
+			result = map[string]any{
+				"content": []map[string]any{{"type": "text", "text": args.Text}},
+			}
+		case "add":
+			var args struct {
+				A, B int
+			}
+			_ = json.Unmarshal(params.Arguments, &args)
+			result = map[string]any{
+				"content":           []any{},
+				"structuredContent": map[string]any{"sum": args.A + args.B},
+			}
+		case "fail":
+			result = map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "something broke"}},
+				"isError": true,
+			}
+		default:
+			reply.Error = &mcp.Error{Code: mcp.ErrCodeInvalidParams, Message: "unknown tool"}
+		}
+	default:
+		reply.Error = &mcp.Error{Code: mcp.ErrCodeMethodNotFound, Message: "method not found"}
+	}
+
+	if result != nil {
+		reply.Result, _ = json.Marshal(result)
+	}
+	return reply, true
+}
+
+func newFakeHTTPServer(t *testing.T) *httptest.Server {
+	const sessionID = "session-123"
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		var msg mcp.Message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		if msg.Method != "initialize" && r.Header.Get("Mcp-Session-Id") != sessionID {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+
+		reply, ok := fakeReply(msg)
+		if !ok {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		body, _ := json.Marshal(reply)
+
+		if msg.Method == "initialize" {
+			w.Header().Set("Mcp-Session-Id", sessionID)
+		}
+		if msg.Method == "tools/call" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			progress := `{"jsonrpc":"2.0","method":"notifications/progress"}`
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", progress)
+			fmt.Fprintf(w, "event: message\ndata: %s\n\n", body)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+}
+
+func newStdioClient(t *testing.T) *mcp.Client {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(), fakeServerEnv+"=1")
+	transport, err := mcp.NewCommandTransport(cmd)
+	if err != nil {
+		t.Fatalf("NewCommandTransport error: %v", err)
+	}
+	return mcp.NewClient(transport)
+}
+
+func TestClient(t *testing.T) {
+	ts := newFakeHTTPServer(t)
+	defer ts.Close()
+
+	clients := map[string]*mcp.Client{
+		"stdio": newStdioClient(t),
+		"http":  mcp.NewClient(mcp.NewHTTPTransport(ts.URL)),
+	}
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			defer client.Close()
+			ctx := context.Background()
+
+			info, err := client.Initialize(ctx)
+			if err != nil {
+				t.Fatalf("Initialize error: %v", err)
+			}
+			if info.ServerInfo.Name != "fake" {
+				t.Fatalf("unexpected server info: %+v", info.ServerInfo)
+			}
+
+			t.Run("lists tool definitions across pages", func(t *testing.T) {
+				defs, err := client.ToolDefinitions(ctx)
+				if err != nil {
+					t.Fatalf("ToolDefinitions error: %v", err)
+				}
+				if len(defs) != 3 {
+					t.Fatalf("expected 3 tools, got %d", len(defs))
+				}
+				if defs[0].Name != "echo" || defs[0].Description != "Echo the input text" {
+					t.Fatalf("unexpected tool definition: %+v", defs[0])
+				}
+				schema, _ := json.Marshal(defs[0].InputSchema)
+				var parsed map[string]any
+				err = json.Unmarshal(schema, &parsed)
+				if err != nil || parsed["type"] != "object" {
+					t.Fatalf("unexpected input schema: %s", schema)
+				}
+				if defs[1].Description != "Add numbers" {
+					t.Fatalf("expected title fallback, got %q", defs[1].Description)
+				}
+				if string(defs[1].InputSchema.(json.RawMessage)) != `{"type":"object"}` {
+					t.Fatalf("expected default schema, got %s", defs[1].InputSchema)
+				}
+			})
+
+			t.Run("dispatches tool use", func(t *testing.T) {
+				toolUse := anthropic.NewMessageContentToolUse(
+					"toolu_1",
+					"echo",
+					json.RawMessage(`{"text":"hello"}`),
+				)
+				result := client.HandleToolUse(ctx, *toolUse)
+				if result.Type != anthropic.MessagesContentTypeToolResult {
+					t.Fatalf("expected tool_result, got %s", result.Type)
+				}
+				if *result.MessageContentToolResult.ToolUseID != "toolu_1" {
+					t.Fatalf("unexpected tool_use_id")
+				}
+				if *result.IsError {
+					t.Fatalf("unexpected error result")
+				}
+				if got := result.MessageContentToolResult.Content[0].GetText(); got != "hello" {
+					t.Fatalf("got %q, want %q", got, "hello")
+				}
+			})
+
+			t.Run("structured content falls back to text", func(t *testing.T) {
+				result, err := client.CallTool(ctx, "add", json.RawMessage(`{"A":1,"B":2}`))
+				if err != nil {
+					t.Fatalf("CallTool error: %v", err)
+				}
+				content := result.MessageContent()
+				if len(content) != 1 || content[0].GetText() != `{"sum":3}` {
+					t.Fatalf("unexpected content: %+v", content)
+				}
+			})
+
+			t.Run("tool errors become error results", func(t *testing.T) {
+				toolUse := anthropic.NewMessageContentToolUse("toolu_2", "fail", nil)
+				result := client.HandleToolUse(ctx, *toolUse)
+				if !*result.IsError {
+					t.Fatalf("expected an error result")
+				}
+
+				toolUse = anthropic.NewMessageContentToolUse("toolu_3", "missing", nil)
+				result = client.HandleToolUse(ctx, *toolUse)
+				if !*result.IsError {
+					t.Fatalf("expected an error result")
+				}
+			})
+
+			t.Run("works with the tool executor", func(t *testing.T) {
+				executor := anthropic.NewToolExecutor(
+					anthropic.WithToolHandler("echo", client.ToolFunc()),
+					anthropic.WithToolHandler("fail", client.ToolFunc()),
+				)
+				input := json.RawMessage(`{"text":"x"}`)
+				results := executor.Execute(ctx, []anthropic.MessageContentToolUse{
+					*anthropic.NewMessageContentToolUse("a", "echo", input),
+					*anthropic.NewMessageContentToolUse("b", "fail", nil),
+				})
+				if *results[0].IsError || !*results[1].IsError {
+					t.Fatalf("unexpected results: %+v", results)
+				}
+				errText := results[1].MessageContentToolResult.Content[0].GetText()
+				if errText != "something broke" {
+					t.Fatalf("got %q, want %q", errText, "something broke")
+				}
+			})
+		})
+	}
+}
+
+func TestMCPConnectorContent(t *testing.T) {
+	t.Run("request carries mcp_servers", func(t *testing.T) {
+		server := anthropic.NewMCPServerDefinition("example", "https://example.com/mcp")
+		server.AuthorizationToken = "token"
+		server.ToolConfiguration = &anthropic.MCPToolConfiguration{AllowedTools: []string{"echo"}}
+
+		b, err := json.Marshal(anthropic.MessagesRequest{
+			Model:      anthropic.ModelClaudeSonnet4Dot5,
+			MaxTokens:  100,
+			MCPServers: []anthropic.MCPServerDefinition{server},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var raw struct {
+			MCPServers []map[string]any `json:"mcp_servers"`
+		}
+		if err := json.Unmarshal(b, &raw); err != nil {
+			t.Fatal(err)
+		}
+		if len(raw.MCPServers) != 1 || raw.MCPServers[0]["type"] != "url" ||
+			raw.MCPServers[0]["name"] != "example" ||
+			raw.MCPServers[0]["authorization_token"] != "token" {
+			t.Fatalf("unexpected mcp_servers: %s", b)
+		}
+	})
+
+	t.Run("mcp_tool_use round trips", func(t *testing.T) {
+		data := `{"type":"mcp_tool_use","id":"mcptoolu_1","name":"echo",` +
+			`"server_name":"example","input":{"text":"hi"}}`
+		var c anthropic.MessageContent
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatal(err)
+		}
+		if c.MessageContentMCPToolUse == nil ||
+			c.MessageContentMCPToolUse.ServerName != "example" ||
+			c.MessageContentMCPToolUse.ID != "mcptoolu_1" {
+			t.Fatalf("unexpected mcp_tool_use: %+v", c.MessageContentMCPToolUse)
+		}
+
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want map[string]any
+		_ = json.Unmarshal(b, &got)
+		_ = json.Unmarshal([]byte(data), &want)
+		for k, v := range want {
+			if fmt.Sprint(got[k]) != fmt.Sprint(v) {
+				t.Fatalf("field %s: got %v, want %v", k, got[k], v)
+			}
+		}
+	})
+
+	t.Run("mcp_tool_result round trips", func(t *testing.T) {
+		data := `{"type":"mcp_tool_result","tool_use_id":"mcptoolu_1","is_error":false,` +
+			`"content":[{"type":"text","text":"hi"}]}`
+		var c anthropic.MessageContent
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			t.Fatal(err)
+		}
+		if c.MessageContentToolResult == nil ||
+			*c.MessageContentToolResult.ToolUseID != "mcptoolu_1" ||
+			c.MessageContentToolResult.Content[0].GetText() != "hi" {
+			t.Fatalf("unexpected mcp_tool_result: %+v", c.MessageContentToolResult)
+		}
+
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got map[string]any
+		_ = json.Unmarshal(b, &got)
+		if got["tool_use_id"] != "mcptoolu_1" || got["type"] != "mcp_tool_result" {
+			t.Fatalf("unexpected marshaled block: %s", b)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// processShutdownTimeout is how long Close waits for a server process to exit
+// after its stdin is closed before killing it.
+const processShutdownTimeout = 2 * time.Second
+
+var _ Transport = (*StdioTransport)(nil)
+
+// StdioTransport talks to an MCP server using newline-delimited JSON-RPC
+// messages, as specified by the MCP stdio transport.
+type StdioTransport struct {
+	writeMu sync.Mutex
+	w       io.WriteCloser
+	cmd     *exec.Cmd
+
+	mu      sync.Mutex
+	pending map[string]chan Message
+	err     error
+	done    chan struct{}
+}
+
+// NewStdioTransport connects to an MCP server that is already running, reading
+// its messages from r and writing requests to w.
+func NewStdioTransport(r io.Reader, w io.WriteCloser) *StdioTransport {
+	t := &StdioTransport{
+		w:       w,
+		pending: make(map[string]chan Message),
+		done:    make(chan struct{}),
+	}
+	go t.readLoop(r)
+	return t
+}
+
+// NewCommandTransport launches cmd and speaks MCP over its stdin and stdout.
+// The process is stopped when the transport is closed. The caller may set
+// cmd.Env, cmd.Dir or cmd.Stderr before calling.
+func NewCommandTransport(cmd *exec.Cmd) (*StdioTransport, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error, starting mcp server: %w", err)
+	}
+
+	t := NewStdioTransport(stdout, stdin)
+	t.cmd = cmd
+	return t, nil
+}
+
+func (t *StdioTransport) Call(ctx context.Context, request Message) (Message, error) {
+	ch := make(chan Message, 1)
+	key := string(request.ID)
+
+	t.mu.Lock()
+	if t.err != nil {
+		t.mu.Unlock()
+		return Message{}, t.err
+	}
+	t.pending[key] = ch
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, key)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(request); err != nil {
+		return Message{}, err
+	}
+
+	select {
+	case res := <-ch:
+		return res, nil
+	case <-t.done:
+		return Message{}, t.closedErr()
+	case <-ctx.Done():
+		return Message{}, ctx.Err()
+	}
+}
+
+func (t *StdioTransport) Notify(ctx context.Context, notification Message) error {
+	return t.write(notification)
+}
+
+// Close closes the server's stdin and, if the transport launched the server,
+// waits for the process to exit, killing it if it does not.
+func (t *StdioTransport) Close() error {
+	t.fail(ErrTransportClosed)
+	err := t.w.Close()
+
+	if t.cmd == nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- t.cmd.Wait()
+	}()
+
+	select {
+	case <-exited:
+	case <-time.After(processShutdownTimeout):
+		_ = t.cmd.Process.Kill()
+		<-exited
+	}
+
+	return err
+}
+
+func (t *StdioTransport) write(msg Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	if _, err := t.w.Write(data); err != nil {
+		return fmt.Errorf("error, writing to mcp server: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) readLoop(r io.Reader) {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			t.handleLine(line)
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = ErrTransportClosed
+			}
+			t.fail(err)
+			return
+		}
+	}
+}
+
+func (t *StdioTransport) handleLine(line []byte) {
+	var msg Message
+	if err := json.Unmarshal(line, &msg); err != nil {
+		// Servers may log non-protocol lines; they carry nothing to dispatch.
+		return
+	}
+
+	switch {
+	case msg.isResponse():
+		t.mu.Lock()
+		ch, ok := t.pending[string(msg.ID)]
+		t.mu.Unlock()
+		if ok {
+			ch <- msg
+		}
+	case msg.isRequest():
+		// Reply to server-initiated requests so the server never blocks on us.
+		_ = t.write(serverRequestReply(msg))
+	}
+}
+
+func (t *StdioTransport) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return
+	}
+	t.err = err
+	close(t.done)
+}
+
+func (t *StdioTransport) closedErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// serverRequestReply answers a request sent by the server to the client. Only
+// ping is supported; the client declares no other capabilities.
+func serverRequestReply(request Message) Message {
+	reply := Message{
+		JSONRPC: jsonrpcVersion,
+		ID:      request.ID,
+	}
+	if request.Method == "ping" {
+		reply.Result = json.RawMessage(`{}`)
+	} else {
+		reply.Error = &Error{
+			Code:    ErrCodeMethodNotFound,
+			Message: "method not found: " + request.Method,
+		}
+	}
+	return reply
+}
@@ -0,0 +1,246 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+// Tool is a tool exposed by an MCP server.
+type Tool struct {
+	Name         string          `json:"name"`
+	Title        string          `json:"title,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	InputSchema  json.RawMessage `json:"inputSchema"`
+	OutputSchema json.RawMessage `json:"outputSchema,omitempty"`
+}
+
+// ToolDefinition converts the MCP tool to a Messages API tool definition.
+func (t Tool) ToolDefinition() anthropic.ToolDefinition {
+	schema := t.InputSchema
+	if len(schema) == 0 {
+		schema = json.RawMessage(`{"type":"object"}`)
+	}
+
+	description := t.Description
+	if description == "" {
+		description = t.Title
+	}
+
+	return anthropic.ToolDefinition{
+		Name:        t.Name,
+		Description: description,
+		InputSchema: schema,
+	}
+}
+
+type listToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type listToolsResult struct {
+	Tools      []Tool `json:"tools"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// ListTools returns every tool of the server, following pagination cursors.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var (
+		tools  []Tool
+		params listToolsParams
+	)
+	for {
+		var result listToolsResult
+		if err := c.call(ctx, methodToolsList, params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		params.Cursor = result.NextCursor
+	}
+}
+
+// ToolDefinitions lists the server's tools as Messages API tool definitions.
+func (c *Client) ToolDefinitions(ctx context.Context) ([]anthropic.ToolDefinition, error) {
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := make([]anthropic.ToolDefinition, 0, len(tools))
+	for _, tool := range tools {
+		definitions = append(definitions, tool.ToolDefinition())
+	}
+	return definitions, nil
+}
+
+type ContentType string
+
+const (
+	ContentTypeText         ContentType = "text"
+	ContentTypeImage        ContentType = "image"
+	ContentTypeAudio        ContentType = "audio"
+	ContentTypeResource     ContentType = "resource"
+	ContentTypeResourceLink ContentType = "resource_link"
+)
+
+// Content is a single item of a tools/call result.
+type Content struct {
+	Type ContentType `json:"type"`
+
+	// For text content
+	Text string `json:"text,omitempty"`
+
+	// For image and audio content, base64 encoded
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+
+	// For resource_link content
+	URI  string `json:"uri,omitempty"`
+	Name string `json:"name,omitempty"`
+
+	// For embedded resource content
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+type CallToolResult struct {
+	Content           []Content       `json:"content"`
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	IsError           bool            `json:"isError,omitempty"`
+}
+
+type callToolParams struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+// CallTool invokes a tool on the server with the given JSON arguments.
+func (c *Client) CallTool(
+	ctx context.Context,
+	name string,
+	arguments json.RawMessage,
+) (*CallToolResult, error) {
+	params := callToolParams{
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	var result CallToolResult
+	if err := c.call(ctx, methodToolsCall, params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// MessageContent converts the result to the content of a tool_result block.
+// Text and images map directly; embedded text resources and resource links are
+// rendered as text. Content the Messages API cannot carry is described as text.
+func (r CallToolResult) MessageContent() []anthropic.MessageContent {
+	var content []anthropic.MessageContent
+	for _, c := range r.Content {
+		switch c.Type {
+		case ContentTypeText:
+			content = append(content, anthropic.NewTextMessageContent(c.Text))
+		case ContentTypeImage:
+			content = append(content, anthropic.NewImageMessageContent(
+				anthropic.NewMessageContentSource(
+					anthropic.MessagesContentSourceTypeBase64,
+					c.MimeType,
+					c.Data,
+				),
+			))
+		case ContentTypeResource:
+			if c.Resource == nil {
+				continue
+			}
+			text := c.Resource.Text
+			if text == "" {
+				text = fmt.Sprintf("[binary resource %s (%s)]", c.Resource.URI, c.Resource.MimeType)
+			}
+			content = append(content, anthropic.NewTextMessageContent(text))
+		case ContentTypeResourceLink:
+			content = append(content, anthropic.NewTextMessageContent(
+				fmt.Sprintf("[resource %s: %s]", c.Name, c.URI),
+			))
+		default:
+			content = append(content, anthropic.NewTextMessageContent(
+				fmt.Sprintf("[unsupported %s content (%s)]", c.Type, c.MimeType),
+			))
+		}
+	}
+
+	if len(content) == 0 && len(r.StructuredContent) > 0 {
+		content = append(content, anthropic.NewTextMessageContent(string(r.StructuredContent)))
+	}
+
+	return content
+}
+
+// HandleToolUse dispatches a tool_use block to tools/call and returns the
+// matching tool_result block. Protocol and transport errors are reported to
+// Claude as an is_error result rather than returned.
+func (c *Client) HandleToolUse(
+	ctx context.Context,
+	toolUse anthropic.MessageContentToolUse,
+) anthropic.MessageContent {
+	result, err := c.CallTool(ctx, toolUse.Name, toolUse.Input)
+	if err != nil {
+		return anthropic.NewToolResultMessageContent(toolUse.ID, err.Error(), true)
+	}
+	return anthropic.NewToolResultBlocksMessageContent(
+		toolUse.ID,
+		result.MessageContent(),
+		result.IsError,
+	)
+}
+
+// ToolFunc adapts the client to anthropic.ToolFunc so the server's tools can be
+// registered with an anthropic.ToolExecutor.
+func (c *Client) ToolFunc() anthropic.ToolFunc {
+	return func(
+		ctx context.Context,
+		toolUse anthropic.MessageContentToolUse,
+	) ([]anthropic.MessageContent, error) {
+		result, err := c.CallTool(ctx, toolUse.Name, toolUse.Input)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsError {
+			return nil, &ToolError{Content: result.MessageContent()}
+		}
+		return result.MessageContent(), nil
+	}
+}
+
+// ToolError is returned by ToolFunc when the server reports a tool-level
+// failure (isError). Its text is the concatenated text of the result content.
+type ToolError struct {
+	Content []anthropic.MessageContent
+}
+
+func (e *ToolError) Error() string {
+	var text string
+	for _, c := range e.Content {
+		if c.Type == anthropic.MessagesContentTypeText {
+			if text != "" {
+				text += "\n"
+			}
+			text += c.GetText()
+		}
+	}
+	if text == "" {
+		text = "tool call failed"
+	}
+	return text
+}
@@ -0,0 +1,575 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemoryRoot is the virtual directory every memory tool path lives under.
+const MemoryRoot = "/memories"
+
+var (
+	// ErrMemoryNotExist is returned by a MemoryStore for missing paths.
+	ErrMemoryNotExist = errors.New("memory path does not exist")
+	// ErrMemoryExist is returned by a MemoryStore when a rename target exists.
+	ErrMemoryExist = errors.New("memory path already exists")
+)
+
+type MemoryCommand string
+
+const (
+	MemoryCommandView       MemoryCommand = "view"
+	MemoryCommandCreate     MemoryCommand = "create"
+	MemoryCommandStrReplace MemoryCommand = "str_replace"
+	MemoryCommandInsert     MemoryCommand = "insert"
+	MemoryCommandDelete     MemoryCommand = "delete"
+	MemoryCommandRename     MemoryCommand = "rename"
+)
+
+// MemoryToolInput is the input of the Anthropic-defined memory tool. Only the
+// fields relevant to Command are set.
+type MemoryToolInput struct {
+	Command    MemoryCommand `json:"command"`
+	Path       string        `json:"path,omitempty"`
+	ViewRange  []int         `json:"view_range,omitempty"`
+	FileText   *string       `json:"file_text,omitempty"`
+	OldStr     *string       `json:"old_str,omitempty"`
+	NewStr     *string       `json:"new_str,omitempty"`
+	InsertLine *int          `json:"insert_line,omitempty"`
+	InsertText *string       `json:"insert_text,omitempty"`
+	OldPath    string        `json:"old_path,omitempty"`
+	NewPath    string        `json:"new_path,omitempty"`
+}
+
+// DecodeMemoryToolInput decodes the input of a memory tool_use block and
+// checks that the parameters required by its command are present.
+func DecodeMemoryToolInput(toolUse MessageContentToolUse) (MemoryToolInput, error) {
+	var in MemoryToolInput
+	if err := json.Unmarshal(toolUse.Input, &in); err != nil {
+		return in, err
+	}
+	return in, in.Validate()
+}
+
+// Validate checks that the parameters required by the command are present.
+func (in MemoryToolInput) Validate() error {
+	required := func(name string, ok bool) error {
+		if !ok {
+			return fmt.Errorf("Parameter `%s` is required for command: %s", name, in.Command)
+		}
+		return nil
+	}
+
+	switch in.Command {
+	case MemoryCommandView, MemoryCommandDelete:
+		return required("path", in.Path != "")
+	case MemoryCommandCreate:
+		return errors.Join(
+			required("path", in.Path != ""),
+			required("file_text", in.FileText != nil),
+		)
+	case MemoryCommandStrReplace:
+		return errors.Join(
+			required("path", in.Path != ""),
+			required("old_str", in.OldStr != nil),
+			required("new_str", in.NewStr != nil),
+		)
+	case MemoryCommandInsert:
+		return errors.Join(
+			required("path", in.Path != ""),
+			required("insert_line", in.InsertLine != nil),
+			required("insert_text", in.InsertText != nil),
+		)
+	case MemoryCommandRename:
+		return errors.Join(
+			required("old_path", in.OldPath != ""),
+			required("new_path", in.NewPath != ""),
+		)
+	}
+
+	return fmt.Errorf(
+		"Unrecognized command %s. The allowed commands for the memory tool are: "+
+			"view, create, str_replace, insert, delete, rename",
+		in.Command,
+	)
+}
+
+// MemoryFileInfo describes a file or directory in a MemoryStore.
+type MemoryFileInfo struct {
+	// Path is the virtual path, such as "/memories/notes/todo.md".
+	Path  string
+	IsDir bool
+	Size  int64
+}
+
+// MemoryStore persists the files of the memory tool. Paths are always
+// cleaned virtual paths under MemoryRoot; MemoryTool rejects anything else
+// before it reaches the store. Implementations return ErrMemoryNotExist for
+// missing paths.
+type MemoryStore interface {
+	Stat(ctx context.Context, path string) (MemoryFileInfo, error)
+	// List returns the direct children of a directory.
+	List(ctx context.Context, dir string) ([]MemoryFileInfo, error)
+	ReadFile(ctx context.Context, path string) (string, error)
+	// WriteFile creates or replaces a file, creating parent directories.
+	WriteFile(ctx context.Context, path, content string) error
+	// Delete removes a file or a directory with its contents.
+	Delete(ctx context.Context, path string) error
+	// Rename moves a file or directory. It fails with ErrMemoryExist if
+	// newPath exists.
+	Rename(ctx context.Context, oldPath, newPath string) error
+}
+
+// MemoryTool executes memory tool commands against a MemoryStore.
+type MemoryTool struct {
+	store  MemoryStore
+	maxLen int
+
+	mu sync.Mutex
+}
+
+type MemoryToolOption func(t *MemoryTool)
+
+// WithMemoryMaxCharacters truncates view output to the given length.
+func WithMemoryMaxCharacters(maxCharacters int) MemoryToolOption {
+	return func(t *MemoryTool) {
+		t.maxLen = maxCharacters
+	}
+}
+
+func NewMemoryTool(store MemoryStore, opts ...MemoryToolOption) *MemoryTool {
+	t := &MemoryTool{
+		store:  store,
+		maxLen: defaultTextEditorMaxLen,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return t
+}
+
+// Execute runs a memory command and returns the text for its tool_result.
+func (t *MemoryTool) Execute(ctx context.Context, input MemoryToolInput) (string, error) {
+	if err := input.Validate(); err != nil {
+		return "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if input.Command == MemoryCommandRename {
+		return t.rename(ctx, input.OldPath, input.NewPath)
+	}
+
+	p, err := cleanMemoryPath(input.Path)
+	if err != nil {
+		return "", err
+	}
+
+	switch input.Command {
+	case MemoryCommandView:
+		return t.view(ctx, p, input.ViewRange)
+	case MemoryCommandCreate:
+		if p == MemoryRoot {
+			return "", fmt.Errorf("Cannot create a file at %s", MemoryRoot)
+		}
+		if info, err := t.store.Stat(ctx, p); err == nil && info.IsDir {
+			return "", fmt.Errorf("The path %s is a directory", p)
+		}
+		if err := t.store.WriteFile(ctx, p, *input.FileText); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("File created successfully at: %s", p), nil
+	case MemoryCommandStrReplace:
+		return t.strReplace(ctx, p, *input.OldStr, *input.NewStr)
+	case MemoryCommandInsert:
+		return t.insert(ctx, p, *input.InsertLine, *input.InsertText)
+	case MemoryCommandDelete:
+		if p == MemoryRoot {
+			return "", fmt.Errorf("Cannot delete the %s directory itself", MemoryRoot)
+		}
+		if err := t.store.Delete(ctx, p); err != nil {
+			return "", t.notExist(p, err)
+		}
+		return fmt.Sprintf("Successfully deleted %s", p), nil
+	}
+
+	return "", fmt.Errorf("Unrecognized command %s", input.Command)
+}
+
+// HandleToolUse executes a memory tool_use block and returns its tool_result.
+func (t *MemoryTool) HandleToolUse(
+	ctx context.Context,
+	toolUse MessageContentToolUse,
+) MessageContent {
+	return textToolResult(toolUse, func(input json.RawMessage) (string, error) {
+		var in MemoryToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return t.Execute(ctx, in)
+	})
+}
+
+// ToolFunc adapts the tool to a ToolFunc for use with a ToolExecutor.
+func (t *MemoryTool) ToolFunc() ToolFunc {
+	return textToolFunc(func(ctx context.Context, input json.RawMessage) (string, error) {
+		var in MemoryToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return t.Execute(ctx, in)
+	})
+}
+
+func (t *MemoryTool) view(ctx context.Context, p string, viewRange []int) (string, error) {
+	info, err := t.store.Stat(ctx, p)
+	if err != nil {
+		return "", t.notExist(p, err)
+	}
+
+	if !info.IsDir {
+		content, err := t.store.ReadFile(ctx, p)
+		if err != nil {
+			return "", t.notExist(p, err)
+		}
+		content, initLine, err := applyViewRange(content, viewRange)
+		if err != nil {
+			return "", err
+		}
+		return catN(content, p, initLine, t.maxLen), nil
+	}
+
+	if viewRange != nil {
+		return "", errors.New(
+			"The `view_range` parameter is not allowed when `path` points to a directory.",
+		)
+	}
+
+	entries, err := t.listDir(ctx, p, 2)
+	if err != nil {
+		return "", err
+	}
+	lines := []string{p}
+	for _, entry := range entries {
+		line := entry.Path
+		if entry.IsDir {
+			line += "/"
+		}
+		lines = append(lines, line)
+	}
+
+	return fmt.Sprintf(
+		"Here're the files and directories up to 2 levels deep in %s, "+
+			"excluding hidden items:\n%s\n",
+		p,
+		strings.Join(lines, "\n"),
+	), nil
+}
+
+// listDir lists dir recursively up to depth levels, skipping hidden entries.
+func (t *MemoryTool) listDir(
+	ctx context.Context,
+	dir string,
+	depth int,
+) ([]MemoryFileInfo, error) {
+	children, err := t.store.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Path < children[j].Path
+	})
+
+	var entries []MemoryFileInfo
+	for _, child := range children {
+		if strings.HasPrefix(path.Base(child.Path), ".") {
+			continue
+		}
+		entries = append(entries, child)
+		if child.IsDir && depth > 1 {
+			sub, err := t.listDir(ctx, child.Path, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, sub...)
+		}
+	}
+	return entries, nil
+}
+
+func (t *MemoryTool) readFile(ctx context.Context, p string) (string, error) {
+	info, err := t.store.Stat(ctx, p)
+	if err != nil {
+		return "", t.notExist(p, err)
+	}
+	if info.IsDir {
+		return "", fmt.Errorf("The path %s is a directory", p)
+	}
+	content, err := t.store.ReadFile(ctx, p)
+	if err != nil {
+		return "", t.notExist(p, err)
+	}
+	return content, nil
+}
+
+func (t *MemoryTool) strReplace(ctx context.Context, p, oldStr, newStr string) (string, error) {
+	content, err := t.readFile(ctx, p)
+	if err != nil {
+		return "", err
+	}
+
+	switch occurrences := strings.Count(content, oldStr); {
+	case occurrences == 0 || oldStr == "":
+		return "", fmt.Errorf(
+			"No replacement was performed, old_str `%s` did not appear verbatim in %s.",
+			oldStr,
+			p,
+		)
+	case occurrences > 1:
+		return "", fmt.Errorf(
+			"No replacement was performed. Multiple occurrences of old_str `%s` in %s. "+
+				"Please ensure it is unique",
+			oldStr,
+			p,
+		)
+	}
+
+	newContent := strings.Replace(content, oldStr, newStr, 1)
+	if err := t.store.WriteFile(ctx, p, newContent); err != nil {
+		return "", err
+	}
+	return "The memory file has been edited.", nil
+}
+
+func (t *MemoryTool) insert(
+	ctx context.Context,
+	p string,
+	insertLine int,
+	text string,
+) (string, error) {
+	content, err := t.readFile(ctx, p)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(content, "\n")
+	if insertLine < 0 || insertLine > len(lines) {
+		return "", fmt.Errorf(
+			"Invalid `insert_line` parameter: %d. It should be within the range of lines of the "+
+				"file: [0, %d]",
+			insertLine,
+			len(lines),
+		)
+	}
+
+	textLines := strings.Split(text, "\n")
+	newLines := make([]string, 0, len(lines)+len(textLines))
+	newLines = append(newLines, lines[:insertLine]...)
+	newLines = append(newLines, textLines...)
+	newLines = append(newLines, lines[insertLine:]...)
+
+	if err := t.store.WriteFile(ctx, p, strings.Join(newLines, "\n")); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Text inserted at line %d in %s.", insertLine, p), nil
+}
+
+func (t *MemoryTool) rename(ctx context.Context, oldPath, newPath string) (string, error) {
+	from, err := cleanMemoryPath(oldPath)
+	if err != nil {
+		return "", err
+	}
+	to, err := cleanMemoryPath(newPath)
+	if err != nil {
+		return "", err
+	}
+	if from == MemoryRoot || to == MemoryRoot {
+		return "", fmt.Errorf("Cannot rename the %s directory itself", MemoryRoot)
+	}
+
+	if err := t.store.Rename(ctx, from, to); err != nil {
+		if errors.Is(err, ErrMemoryExist) {
+			return "", fmt.Errorf("The destination %s already exists", to)
+		}
+		return "", t.notExist(from, err)
+	}
+	return fmt.Sprintf("Successfully renamed %s to %s", from, to), nil
+}
+
+func (t *MemoryTool) notExist(p string, err error) error {
+	if errors.Is(err, ErrMemoryNotExist) {
+		return fmt.Errorf("The path %s does not exist. Please provide a valid path.", p)
+	}
+	return err
+}
+
+// cleanMemoryPath normalizes a model supplied path and rejects anything that
+// is not MemoryRoot or inside it.
+func cleanMemoryPath(p string) (string, error) {
+	if strings.ContainsAny(p, "\\\x00") {
+		return "", fmt.Errorf("The path %s contains invalid characters", p)
+	}
+
+	cleaned := path.Clean(p)
+	if cleaned != MemoryRoot && !strings.HasPrefix(cleaned, MemoryRoot+"/") {
+		return "", fmt.Errorf("The path %s is outside of the %s directory", p, MemoryRoot)
+	}
+	return cleaned, nil
+}
+
+// LocalMemoryStore is a MemoryStore backed by a directory on disk, which
+// stands in for MemoryRoot. Resolved paths, including symlinks, never leave
+// the directory.
+type LocalMemoryStore struct {
+	root string
+}
+
+var _ MemoryStore = (*LocalMemoryStore)(nil)
+
+// NewLocalMemoryStore creates the directory if needed and returns a store
+// rooted at it.
+func NewLocalMemoryStore(dir string) (*LocalMemoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalMemoryStore{root: resolved}, nil
+}
+
+// Root returns the directory backing the store.
+func (s *LocalMemoryStore) Root() string {
+	return s.root
+}
+
+func (s *LocalMemoryStore) Stat(ctx context.Context, p string) (MemoryFileInfo, error) {
+	full, err := s.resolve(p)
+	if err != nil {
+		return MemoryFileInfo{}, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		return MemoryFileInfo{}, localMemoryError(err)
+	}
+	return MemoryFileInfo{Path: p, IsDir: info.IsDir(), Size: info.Size()}, nil
+}
+
+func (s *LocalMemoryStore) List(ctx context.Context, dir string) ([]MemoryFileInfo, error) {
+	full, err := s.resolve(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(full)
+	if err != nil {
+		return nil, localMemoryError(err)
+	}
+
+	infos := make([]MemoryFileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, MemoryFileInfo{
+			Path:  path.Join(dir, entry.Name()),
+			IsDir: entry.IsDir(),
+			Size:  info.Size(),
+		})
+	}
+	return infos, nil
+}
+
+func (s *LocalMemoryStore) ReadFile(ctx context.Context, p string) (string, error) {
+	full, err := s.resolve(p)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(full)
+	if err != nil {
+		return "", localMemoryError(err)
+	}
+	return string(b), nil
+}
+
+func (s *LocalMemoryStore) WriteFile(ctx context.Context, p, content string) error {
+	full, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(full, []byte(content), 0o644)
+}
+
+func (s *LocalMemoryStore) Delete(ctx context.Context, p string) error {
+	full, err := s.resolve(p)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(full); err != nil {
+		return localMemoryError(err)
+	}
+	return os.RemoveAll(full)
+}
+
+func (s *LocalMemoryStore) Rename(ctx context.Context, oldPath, newPath string) error {
+	from, err := s.resolve(oldPath)
+	if err != nil {
+		return err
+	}
+	to, err := s.resolve(newPath)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(from); err != nil {
+		return localMemoryError(err)
+	}
+	if _, err := os.Lstat(to); err == nil {
+		return ErrMemoryExist
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// resolve maps a virtual path under MemoryRoot to a path inside the root
+// directory.
+func (s *LocalMemoryStore) resolve(p string) (string, error) {
+	cleaned, err := cleanMemoryPath(p)
+	if err != nil {
+		return "", err
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(cleaned, MemoryRoot), "/")
+	full, err := resolveInRoot(s.root, filepath.Join(s.root, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", fmt.Errorf("The path %s is outside of the %s directory", p, MemoryRoot)
+	}
+	return full, nil
+}
+
+func localMemoryError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrMemoryNotExist
+	}
+	return err
+}
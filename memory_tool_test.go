@@ -0,0 +1,181 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestMemoryTool(t *testing.T) {
+	store, err := anthropic.NewLocalMemoryStore(filepath.Join(t.TempDir(), "memories"))
+	if err != nil {
+		t.Fatalf("NewLocalMemoryStore error: %v", err)
+	}
+	tool := anthropic.NewMemoryTool(store)
+	ctx := context.Background()
+
+	run := func(t *testing.T, input anthropic.MemoryToolInput) string {
+		t.Helper()
+		out, err := tool.Execute(ctx, input)
+		if err != nil {
+			t.Fatalf("Execute(%s) error: %v", input.Command, err)
+		}
+		return out
+	}
+	runErr := func(t *testing.T, input anthropic.MemoryToolInput, want string) {
+		t.Helper()
+		_, err := tool.Execute(ctx, input)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("got error %v, want %q", err, want)
+		}
+	}
+
+	t.Run("create and view", func(t *testing.T) {
+		out := run(t, anthropic.MemoryToolInput{
+			Command:  anthropic.MemoryCommandCreate,
+			Path:     "/memories/projects/notes.md",
+			FileText: toPtr("# Notes\nprefers tabs"),
+		})
+		if out != "File created successfully at: /memories/projects/notes.md" {
+			t.Fatalf("unexpected output %q", out)
+		}
+		b, err := os.ReadFile(filepath.Join(store.Root(), "projects", "notes.md"))
+		if err != nil || string(b) != "# Notes\nprefers tabs" {
+			t.Fatalf("file not written: %q, %v", b, err)
+		}
+
+		out = run(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandView,
+			Path:    "/memories",
+		})
+		if !strings.Contains(out, "/memories/projects/\n/memories/projects/notes.md") {
+			t.Fatalf("unexpected directory listing %q", out)
+		}
+
+		out = run(t, anthropic.MemoryToolInput{
+			Command:   anthropic.MemoryCommandView,
+			Path:      "/memories/projects/notes.md",
+			ViewRange: []int{2, -1},
+		})
+		if !strings.Contains(out, "     2\tprefers tabs\n") || strings.Contains(out, "# Notes") {
+			t.Fatalf("unexpected file view %q", out)
+		}
+	})
+
+	t.Run("edit", func(t *testing.T) {
+		run(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandStrReplace,
+			Path:    "/memories/projects/notes.md",
+			OldStr:  toPtr("tabs"),
+			NewStr:  toPtr("spaces"),
+		})
+		run(t, anthropic.MemoryToolInput{
+			Command:    anthropic.MemoryCommandInsert,
+			Path:       "/memories/projects/notes.md",
+			InsertLine: toPtr(1),
+			InsertText: toPtr("- uses Go"),
+		})
+		b, _ := os.ReadFile(filepath.Join(store.Root(), "projects", "notes.md"))
+		if string(b) != "# Notes\n- uses Go\nprefers spaces" {
+			t.Fatalf("unexpected content %q", b)
+		}
+
+		runErr(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandStrReplace,
+			Path:    "/memories/projects/notes.md",
+			OldStr:  toPtr("missing"),
+			NewStr:  toPtr(""),
+		}, "did not appear verbatim")
+	})
+
+	t.Run("rename and delete", func(t *testing.T) {
+		out := run(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandRename,
+			OldPath: "/memories/projects",
+			NewPath: "/memories/archive/projects",
+		})
+		if out != "Successfully renamed /memories/projects to /memories/archive/projects" {
+			t.Fatalf("unexpected output %q", out)
+		}
+
+		run(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandDelete,
+			Path:    "/memories/archive",
+		})
+		runErr(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandView,
+			Path:    "/memories/archive/projects/notes.md",
+		}, "does not exist")
+		runErr(t, anthropic.MemoryToolInput{
+			Command: anthropic.MemoryCommandDelete,
+			Path:    "/memories",
+		}, "Cannot delete")
+	})
+
+	t.Run("rejects paths outside of /memories", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(store.Root(), "escape")); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{
+			"/etc/passwd",
+			"/memories/../etc/passwd",
+			"/memoriesx/file",
+			"memories/file",
+			`/memories/..\..\file`,
+			"/memories/escape/file.txt",
+		} {
+			_, err := tool.Execute(ctx, anthropic.MemoryToolInput{
+				Command:  anthropic.MemoryCommandCreate,
+				Path:     path,
+				FileText: toPtr("x"),
+			})
+			if err == nil {
+				t.Fatalf("expected %s to be rejected", path)
+			}
+		}
+		if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+			t.Fatalf("a file was written outside of the store: %v", entries)
+		}
+	})
+
+	t.Run("decodes tool use blocks", func(t *testing.T) {
+		toolUse := *anthropic.NewMessageContentToolUse(
+			"toolu_1",
+			anthropic.ToolNameMemory,
+			json.RawMessage(`{"command":"create","path":"/memories/a.md"}`),
+		)
+		if _, err := anthropic.DecodeMemoryToolInput(toolUse); err == nil ||
+			err.Error() != "Parameter `file_text` is required for command: create" {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		toolUse.Input = json.RawMessage(`{"command":"view","path":"/memories"}`)
+		result := tool.HandleToolUse(ctx, toolUse)
+		if *result.IsError {
+			t.Fatalf("unexpected error result")
+		}
+		if got := result.MessageContentToolResult.Content[0].GetText(); !strings.HasPrefix(
+			got,
+			"Here're the files and directories up to 2 levels deep in /memories",
+		) {
+			t.Fatalf("unexpected result %q", got)
+		}
+	})
+}
+
+func TestMemoryToolDefinition(t *testing.T) {
+	b, err := json.Marshal(anthropic.NewMemoryToolDefinition())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"memory","type":"memory_20250818"}` {
+		t.Fatalf("unexpected definition %s", b)
+	}
+}
@@ -1,9 +1,10 @@
 package anthropic
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"net/http"
+	"time"
 )
 
 type MessagesResponseType string
@@ -30,15 +31,29 @@ const (
 	MessagesContentTypeRedactedThinking    MessagesContentType = "redacted_thinking"
 	MessagesContentTypeServerToolUse       MessagesContentType = "server_tool_use"
 	MessagesContentTypeWebSearchToolResult MessagesContentType = "web_search_tool_result"
+	MessagesContentTypeWebFetchToolResult  MessagesContentType = "web_fetch_tool_result"
+	MessagesContentTypeMCPToolUse          MessagesContentType = "mcp_tool_use"
+	MessagesContentTypeMCPToolResult       MessagesContentType = "mcp_tool_result"
+	MessagesContentTypeSearchResult        MessagesContentType = "search_result"
+
+	MessagesContentTypeCodeExecutionToolResult     MessagesContentType = "code_execution_tool_result"
+	MessagesContentTypeBashCodeExecutionToolResult MessagesContentType = "bash_code_execution_tool_result"
+
+	MessagesContentTypeTextEditorCodeExecutionToolResult MessagesContentType = "text_editor_code_execution_tool_result"
 )
 
 type CitationType string
 
 const (
-	CitationTypeCharLocation            CitationType = "char_location"
-	CitationTypePageLocation            CitationType = "page_location"
-	CitationTypeBlockIndex              CitationType = "block_index"
+	CitationTypeCharLocation CitationType = "char_location"
+	CitationTypePageLocation CitationType = "page_location"
+	CitationTypeBlockIndex   CitationType = "block_index"
+	// CitationTypeContentBlockLocation is what the API sends for citations of
+	// documents with a content source; CitationTypeBlockIndex is kept for
+	// compatibility.
+	CitationTypeContentBlockLocation    CitationType = "content_block_location"
 	CitationTypeWebSearchResultLocation CitationType = "web_search_result_location"
+	CitationTypeSearchResultLocation    CitationType = "search_result_location"
 )
 
 type ThinkingType string
@@ -67,6 +82,7 @@ const (
 	MessagesContentSourceTypeText    MessagesContentSourceType = "text"
 	MessagesContentSourceTypeContent MessagesContentSourceType = "content"
 	MessagesContentSourceTypeUrl     MessagesContentSourceType = "url"
+	MessagesContentSourceTypeFile    MessagesContentSourceType = "file"
 )
 
 type OutputFormatType string
@@ -110,6 +126,14 @@ type MessagesRequest struct {
 	Tools         []ToolDefinition    `json:"tools,omitempty"`
 	ToolChoice    *ToolChoice         `json:"tool_choice,omitempty"`
 	Thinking      *Thinking           `json:"thinking,omitempty"`
+	// MCPServers requires the BetaMCPClient20250404 beta.
+	MCPServers []MCPServerDefinition `json:"mcp_servers,omitempty"`
+	// Container is the ID of a code execution container to reuse, as returned
+	// in MessagesResponse.Container by a previous turn.
+	Container *string `json:"container,omitempty"`
+	// ContextManagement configures server-side context editing. It requires
+	// the BetaContextManagement20250627 beta.
+	ContextManagement *ContextManagement `json:"context_management,omitempty"`
 	// Deprecated: Use output_config.format instead.
 	OutputFormat *OutputFormat        `json:"output_format,omitempty"`
 	OutputConfig *OutputConfig        `json:"output_config,omitempty"`
@@ -134,6 +158,32 @@ func (m MessagesRequest) MarshalJSON() ([]byte, error) {
 	return json.Marshal(aux)
 }
 
+// UnmarshalJSON restores System or MultiSystem from the "system" field, so a
+// marshaled request can be read back, e.g. when persisting a Conversation.
+func (m *MessagesRequest) UnmarshalJSON(data []byte) error {
+	type Alias MessagesRequest
+	aux := struct {
+		System json.RawMessage `json:"system,omitempty"`
+		*Alias
+	}{
+		Alias: (*Alias)(m),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	m.System, m.MultiSystem = "", nil
+	system := bytes.TrimSpace(aux.System)
+	switch {
+	case len(system) == 0:
+		return nil
+	case system[0] == '[':
+		return json.Unmarshal(system, &m.MultiSystem)
+	default:
+		return json.Unmarshal(system, &m.System)
+	}
+}
+
 var _ VertexAISupport = (*MessagesRequest)(nil)
 
 func (m MessagesRequest) GetModel() Model {
@@ -255,6 +305,99 @@ type Citation struct {
 	EncryptedIndex *string `json:"encrypted_index,omitempty"`
 	Url            *string `json:"url,omitempty"`
 	Title          *string `json:"title,omitempty"`
+
+	// For search_result_location citations, together with Title and the
+	// block indices, which index the text blocks of the search result.
+	Source            *string `json:"source,omitempty"`
+	SearchResultIndex *int    `json:"search_result_index,omitempty"`
+
+	// raw holds the citation as received when its type is unknown.
+	raw json.RawMessage
+}
+
+var knownCitationTypes = map[CitationType]bool{
+	CitationTypeCharLocation:            true,
+	CitationTypePageLocation:            true,
+	CitationTypeBlockIndex:              true,
+	CitationTypeContentBlockLocation:    true,
+	CitationTypeWebSearchResultLocation: true,
+	CitationTypeSearchResultLocation:    true,
+}
+
+// IsUnknown reports whether the citation was decoded from a type this package
+// does not know. Only Type is set on such a citation; it marshals back to the
+// JSON it was decoded from, byte for byte.
+func (c Citation) IsUnknown() bool {
+	return c.raw != nil
+}
+
+func (c Citation) MarshalJSON() ([]byte, error) {
+	if c.raw != nil {
+		return c.raw, nil
+	}
+	type Alias Citation
+	return json.Marshal(Alias(c))
+}
+
+func (c *Citation) UnmarshalJSON(data []byte) error {
+	type Alias Citation
+	var typeCheck struct {
+		Type CitationType `json:"type"`
+	}
+	if err := json.Unmarshal(data, &typeCheck); err != nil {
+		return err
+	}
+	if !knownCitationTypes[typeCheck.Type] {
+		*c = Citation{Type: typeCheck.Type, raw: bytes.Clone(data)}
+		return nil
+	}
+
+	var alias Alias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*c = Citation(alias)
+	return nil
+}
+
+// SearchResultLocation is a citation of a search_result block.
+type SearchResultLocation struct {
+	CitedText string
+	Source    string
+	Title     string
+	// SearchResultIndex counts the search_result blocks of the request, in
+	// order, across messages and tool results.
+	SearchResultIndex int
+	// StartBlockIndex and EndBlockIndex select the cited text blocks of the
+	// search result; EndBlockIndex is exclusive.
+	StartBlockIndex int
+	EndBlockIndex   int
+}
+
+// SearchResultLocation returns the citation as a SearchResultLocation if it
+// is a search_result_location citation.
+func (c Citation) SearchResultLocation() (SearchResultLocation, bool) {
+	if c.Type != CitationTypeSearchResultLocation {
+		return SearchResultLocation{}, false
+	}
+
+	loc := SearchResultLocation{CitedText: c.CitedText}
+	if c.Source != nil {
+		loc.Source = *c.Source
+	}
+	if c.Title != nil {
+		loc.Title = *c.Title
+	}
+	if c.SearchResultIndex != nil {
+		loc.SearchResultIndex = *c.SearchResultIndex
+	}
+	if c.StartBlockIndex != nil {
+		loc.StartBlockIndex = *c.StartBlockIndex
+	}
+	if c.EndBlockIndex != nil {
+		loc.EndBlockIndex = *c.EndBlockIndex
+	}
+	return loc, true
 }
 
 type MessageContent struct {
@@ -270,8 +413,18 @@ type MessageContent struct {
 
 	*MessageContentWebSearchToolResult
 
+	*MessageContentWebFetchToolResult
+
 	*MessageContentServerToolUse
 
+	*MessageContentMCPToolUse
+
+	*MessageContentCodeExecutionToolResult
+
+	*MessageContentTextEditorCodeExecutionToolResult
+
+	*MessageContentSearchResult
+
 	PartialJson *string `json:"partial_json,omitempty"`
 
 	CacheControl *MessageCacheControl `json:"cache_control,omitempty"`
@@ -293,12 +446,52 @@ type MessageContent struct {
 	*MessageContentThinking
 
 	*MessageContentRedactedThinking
+
+	// raw holds the block as received when its type is not one this package
+	// knows, so that it can be sent back unchanged.
+	raw json.RawMessage
+}
+
+var knownMessagesContentTypes = map[MessagesContentType]bool{
+	MessagesContentTypeText:                              true,
+	MessagesContentTypeTextDelta:                         true,
+	MessagesContentTypeImage:                             true,
+	MessagesContentTypeToolResult:                        true,
+	MessagesContentTypeToolUse:                           true,
+	MessagesContentTypeInputJsonDelta:                    true,
+	MessagesContentTypeDocument:                          true,
+	MessagesContentTypeCitationsDelta:                    true,
+	MessagesContentTypeThinking:                          true,
+	MessagesContentTypeThinkingDelta:                     true,
+	MessagesContentTypeSignatureDelta:                    true,
+	MessagesContentTypeRedactedThinking:                  true,
+	MessagesContentTypeServerToolUse:                     true,
+	MessagesContentTypeWebSearchToolResult:               true,
+	MessagesContentTypeWebFetchToolResult:                true,
+	MessagesContentTypeMCPToolUse:                        true,
+	MessagesContentTypeMCPToolResult:                     true,
+	MessagesContentTypeSearchResult:                      true,
+	MessagesContentTypeCodeExecutionToolResult:           true,
+	MessagesContentTypeBashCodeExecutionToolResult:       true,
+	MessagesContentTypeTextEditorCodeExecutionToolResult: true,
+}
+
+// IsUnknown reports whether the block was decoded from a type this package
+// does not know. Only Type is set on such a block; it marshals back to the
+// JSON it was decoded from, byte for byte.
+//
+// Unknown blocks are not streamed: in CreateMessagesStream such a block keeps
+// the JSON of its content_block_start event, and deltas for it are not merged,
+// so use CreateMessages when the full block must be sent back.
+func (m MessageContent) IsUnknown() bool {
+	return m.raw != nil
 }
 
 // MarshalJSON implements custom JSON marshaling for MessageContent.
 //
 // MessageContent embeds several pointer structs (tool_use, server_tool_use,
-// tool_result, web_search_tool_result) that declare overlapping JSON field
+// mcp_tool_use, tool_result, web_search_tool_result, web_fetch_tool_result,
+// search_result and the code execution results) that declare overlapping JSON field
 // names — for example both MessageContentToolResult and
 // MessageContentWebSearchToolResult define "tool_use_id" and "content", and
 // both MessageContentToolUse and MessageContentServerToolUse define "id",
@@ -309,8 +502,13 @@ type MessageContent struct {
 // To produce a correct payload we marshal the base struct (which omits the
 // ambiguous fields) and then merge back the fields of whichever embedded tool
 // struct is actually populated. Blocks without an ambiguous embedded struct
-// (text, image, document, thinking, …) are marshaled exactly as before.
+// (text, image, document, thinking, …) are marshaled exactly as before, and
+// blocks of an unknown type as they were received.
 func (m MessageContent) MarshalJSON() ([]byte, error) {
+	if m.raw != nil {
+		return m.raw, nil
+	}
+
 	type Alias MessageContent
 	base, err := json.Marshal(Alias(m))
 	if err != nil {
@@ -325,8 +523,18 @@ func (m MessageContent) MarshalJSON() ([]byte, error) {
 		extra = m.MessageContentToolUse
 	case m.MessageContentServerToolUse != nil:
 		extra = m.MessageContentServerToolUse
+	case m.MessageContentMCPToolUse != nil:
+		extra = m.MessageContentMCPToolUse
 	case m.MessageContentWebSearchToolResult != nil:
 		extra = m.MessageContentWebSearchToolResult
+	case m.MessageContentWebFetchToolResult != nil:
+		extra = m.MessageContentWebFetchToolResult
+	case m.MessageContentCodeExecutionToolResult != nil:
+		extra = m.MessageContentCodeExecutionToolResult
+	case m.MessageContentTextEditorCodeExecutionToolResult != nil:
+		extra = m.MessageContentTextEditorCodeExecutionToolResult
+	case m.MessageContentSearchResult != nil:
+		extra = m.MessageContentSearchResult
 	}
 
 	if extra == nil {
@@ -364,11 +572,19 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &typeCheck); err != nil {
 		return err
 	}
+	if !knownMessagesContentTypes[typeCheck.Type] {
+		*m = MessageContent{Type: typeCheck.Type, raw: bytes.Clone(data)}
+		return nil
+	}
+	m.raw = nil
 
 	// Create an alias to avoid infinite recursion
 	type Alias MessageContent
 	aux := &struct {
-		Citations []Citation `json:"citations"`
+		Citations json.RawMessage `json:"citations"`
+		// "source" is an object on images and documents but a string on
+		// search results.
+		Source json.RawMessage `json:"source"`
 		*Alias
 	}{
 		Alias: (*Alias)(m),
@@ -379,8 +595,25 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	// Copy citations
-	m.Citations = aux.Citations
+	// "citations" is a list of citations on text blocks, but the citations
+	// config object on documents (e.g. inside web_fetch_tool_result).
+	m.Citations = nil
+	if trimmed := bytes.TrimSpace(aux.Citations); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &m.DocumentCitations); err != nil {
+			return err
+		}
+	} else if len(trimmed) > 0 {
+		if err := json.Unmarshal(trimmed, &m.Citations); err != nil {
+			return err
+		}
+	}
+
+	m.Source = nil
+	if trimmed := bytes.TrimSpace(aux.Source); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &m.Source); err != nil {
+			return err
+		}
+	}
 
 	// Based on type, create and populate the appropriate embedded struct
 	switch typeCheck.Type {
@@ -398,7 +631,14 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 		}
 		m.MessageContentServerToolUse = &serverToolUse
 
-	case MessagesContentTypeToolResult:
+	case MessagesContentTypeMCPToolUse:
+		var mcpToolUse MessageContentMCPToolUse
+		if err := json.Unmarshal(data, &mcpToolUse); err != nil {
+			return err
+		}
+		m.MessageContentMCPToolUse = &mcpToolUse
+
+	case MessagesContentTypeToolResult, MessagesContentTypeMCPToolResult:
 		var toolResult MessageContentToolResult
 		if err := json.Unmarshal(data, &toolResult); err != nil {
 			return err
@@ -412,6 +652,35 @@ func (m *MessageContent) UnmarshalJSON(data []byte) error {
 		}
 		m.MessageContentWebSearchToolResult = &webSearchResult
 
+	case MessagesContentTypeWebFetchToolResult:
+		var webFetchResult MessageContentWebFetchToolResult
+		if err := json.Unmarshal(data, &webFetchResult); err != nil {
+			return err
+		}
+		m.MessageContentWebFetchToolResult = &webFetchResult
+
+	case MessagesContentTypeCodeExecutionToolResult,
+		MessagesContentTypeBashCodeExecutionToolResult:
+		var codeExecutionResult MessageContentCodeExecutionToolResult
+		if err := json.Unmarshal(data, &codeExecutionResult); err != nil {
+			return err
+		}
+		m.MessageContentCodeExecutionToolResult = &codeExecutionResult
+
+	case MessagesContentTypeTextEditorCodeExecutionToolResult:
+		var textEditorResult MessageContentTextEditorCodeExecutionToolResult
+		if err := json.Unmarshal(data, &textEditorResult); err != nil {
+			return err
+		}
+		m.MessageContentTextEditorCodeExecutionToolResult = &textEditorResult
+
+	case MessagesContentTypeSearchResult:
+		var searchResult MessageContentSearchResult
+		if err := json.Unmarshal(data, &searchResult); err != nil {
+			return err
+		}
+		m.MessageContentSearchResult = &searchResult
+
 	case MessagesContentTypeThinking,
 		MessagesContentTypeThinkingDelta,
 		MessagesContentTypeSignatureDelta:
@@ -457,6 +726,17 @@ func NewImageUrlMessageContent(url string) MessageContent {
 	}
 }
 
+// NewFileImageMessageContent references an image uploaded with Client.UploadFile.
+func NewFileImageMessageContent(fileId FileId) MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeImage,
+		Source: &MessageContentSource{
+			Type:   MessagesContentSourceTypeFile,
+			FileID: fileId,
+		},
+	}
+}
+
 func NewDocumentMessageContent(
 	source MessageContentSource,
 	title, context string,
@@ -489,6 +769,24 @@ func NewPDFDocumentMessageContent(
 	)
 }
 
+// NewFileDocumentMessageContent references a PDF or text document uploaded
+// with Client.UploadFile instead of inlining its content.
+func NewFileDocumentMessageContent(
+	fileId FileId,
+	title, context string,
+	enableCitations bool,
+) MessageContent {
+	return NewDocumentMessageContent(
+		MessageContentSource{
+			Type:   MessagesContentSourceTypeFile,
+			FileID: fileId,
+		},
+		title,
+		context,
+		enableCitations,
+	)
+}
+
 func NewTextDocumentMessageContent(
 	text, title, context string,
 	enableCitations bool,
@@ -528,6 +826,49 @@ func NewToolResultMessageContent(toolUseID, content string, isError bool) Messag
 	}
 }
 
+// NewToolResultBlocksMessageContent creates a tool_result block whose content is
+// made of arbitrary blocks (text, image, document, ...), instead of a single text.
+func NewToolResultBlocksMessageContent(
+	toolUseID string,
+	content []MessageContent,
+	isError bool,
+) MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeToolResult,
+		MessageContentToolResult: &MessageContentToolResult{
+			ToolUseID: &toolUseID,
+			Content:   content,
+			IsError:   &isError,
+		},
+	}
+}
+
+// NewSearchResultMessageContent creates a search_result block with one text
+// block per chunk. Search results can be sent as user content or as the
+// content of a tool_result, see NewToolResultBlocksMessageContent.
+func NewSearchResultMessageContent(
+	source, title string,
+	chunks []string,
+	enableCitations bool,
+) MessageContent {
+	content := make([]MessageContent, 0, len(chunks))
+	for _, chunk := range chunks {
+		content = append(content, MessageContent{Type: MessagesContentTypeText, Text: &chunk})
+	}
+
+	return MessageContent{
+		Type:  MessagesContentTypeSearchResult,
+		Title: title,
+		MessageContentSearchResult: &MessageContentSearchResult{
+			Source:  source,
+			Content: content,
+		},
+		DocumentCitations: &DocumentCitations{
+			Enabled: enableCitations,
+		},
+	}
+}
+
 func NewToolUseMessageContent(toolUseID, name string, input json.RawMessage) MessageContent {
 	return MessageContent{
 		Type:                  MessagesContentTypeToolUse,
@@ -542,6 +883,28 @@ func NewServerToolUseContent(toolUseID, name string, input json.RawMessage) Mess
 	}
 }
 
+func NewMCPToolUseMessageContent(
+	toolUseID, name, serverName string,
+	input json.RawMessage,
+) MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeMCPToolUse,
+		MessageContentMCPToolUse: NewMessageContentMCPToolUse(
+			toolUseID,
+			name,
+			serverName,
+			input,
+		),
+	}
+}
+
+func NewMCPToolResultMessageContent(toolUseID, content string, isError bool) MessageContent {
+	return MessageContent{
+		Type:                     MessagesContentTypeMCPToolResult,
+		MessageContentToolResult: NewMessageContentToolResult(toolUseID, content, isError),
+	}
+}
+
 func NewServerWebSearchToolResultContent(
 	toolUseID string,
 	content []WebSearchResult,
@@ -555,6 +918,47 @@ func NewServerWebSearchToolResultContent(
 	}
 }
 
+func NewServerWebFetchToolResultContent(toolUseID string, result WebFetchResult) MessageContent {
+	return MessageContent{
+		Type: MessagesContentTypeWebFetchToolResult,
+		MessageContentWebFetchToolResult: &MessageContentWebFetchToolResult{
+			ToolUseID: &toolUseID,
+			Content:   &result,
+		},
+	}
+}
+
+// NewCodeExecutionToolResultContent builds a code execution result block.
+// contentType is MessagesContentTypeCodeExecutionToolResult or
+// MessagesContentTypeBashCodeExecutionToolResult.
+func NewCodeExecutionToolResultContent(
+	contentType MessagesContentType,
+	toolUseID string,
+	result CodeExecutionResult,
+) MessageContent {
+	return MessageContent{
+		Type: contentType,
+		MessageContentCodeExecutionToolResult: &MessageContentCodeExecutionToolResult{
+			ToolUseID: &toolUseID,
+			Content:   &result,
+		},
+	}
+}
+
+func NewTextEditorCodeExecutionToolResultContent(
+	toolUseID string,
+	result TextEditorCodeExecutionResult,
+) MessageContent {
+	content := &MessageContentTextEditorCodeExecutionToolResult{
+		ToolUseID: &toolUseID,
+		Content:   &result,
+	}
+	return MessageContent{
+		Type: MessagesContentTypeTextEditorCodeExecutionToolResult,
+		MessageContentTextEditorCodeExecutionToolResult: content,
+	}
+}
+
 func (m *MessageContent) SetCacheControl(ts ...CacheControlType) {
 	t := CacheControlTypeEphemeral
 	if len(ts) > 0 {
@@ -593,7 +997,7 @@ func (m *MessageContent) MergeContentDelta(mc MessageContent) {
 		m.ConcatText(mc.GetText())
 	case MessagesContentTypeImage:
 		m.Source = mc.Source
-	case MessagesContentTypeToolResult:
+	case MessagesContentTypeToolResult, MessagesContentTypeMCPToolResult:
 		m.MessageContentToolResult = mc.MessageContentToolResult
 	case MessagesContentTypeToolUse:
 		if mc.MessageContentToolUse != nil {
@@ -609,8 +1013,24 @@ func (m *MessageContent) MergeContentDelta(mc MessageContent) {
 				Name: mc.MessageContentServerToolUse.Name,
 			}
 		}
+	case MessagesContentTypeMCPToolUse:
+		if mc.MessageContentMCPToolUse != nil {
+			m.MessageContentMCPToolUse = &MessageContentMCPToolUse{
+				ID:         mc.MessageContentMCPToolUse.ID,
+				Name:       mc.MessageContentMCPToolUse.Name,
+				ServerName: mc.MessageContentMCPToolUse.ServerName,
+			}
+		}
 	case MessagesContentTypeWebSearchToolResult:
 		m.MessageContentWebSearchToolResult = mc.MessageContentWebSearchToolResult
+	case MessagesContentTypeWebFetchToolResult:
+		m.MessageContentWebFetchToolResult = mc.MessageContentWebFetchToolResult
+	case MessagesContentTypeCodeExecutionToolResult,
+		MessagesContentTypeBashCodeExecutionToolResult:
+		m.MessageContentCodeExecutionToolResult = mc.MessageContentCodeExecutionToolResult
+	case MessagesContentTypeTextEditorCodeExecutionToolResult:
+		m.MessageContentTextEditorCodeExecutionToolResult =
+			mc.MessageContentTextEditorCodeExecutionToolResult
 	case MessagesContentTypeInputJsonDelta:
 		if m.PartialJson == nil {
 			m.PartialJson = mc.PartialJson
@@ -697,12 +1117,171 @@ func NewMessageContentWebSearchToolResult(
 	}
 }
 
+type WebFetchResultType string
+
+const (
+	WebFetchResultTypeWebFetchResult WebFetchResultType = "web_fetch_result"
+	WebFetchResultTypeError          WebFetchResultType = "web_fetch_tool_result_error"
+)
+
+type WebFetchErrorCode string
+
+const (
+	WebFetchErrorCodeInvalidInput           WebFetchErrorCode = "invalid_input"
+	WebFetchErrorCodeURLTooLong             WebFetchErrorCode = "url_too_long"
+	WebFetchErrorCodeURLNotAllowed          WebFetchErrorCode = "url_not_allowed"
+	WebFetchErrorCodeURLNotAccessible       WebFetchErrorCode = "url_not_accessible"
+	WebFetchErrorCodeTooManyRequests        WebFetchErrorCode = "too_many_requests"
+	WebFetchErrorCodeUnsupportedContentType WebFetchErrorCode = "unsupported_content_type"
+	WebFetchErrorCodeMaxUsesExceeded        WebFetchErrorCode = "max_uses_exceeded"
+	WebFetchErrorCodeUnavailable            WebFetchErrorCode = "unavailable"
+)
+
+// WebFetchResult is the content of a web_fetch_tool_result block. On success
+// Content is a document block whose source holds the fetched text or PDF;
+// errors only set ErrorCode.
+type WebFetchResult struct {
+	Type        WebFetchResultType `json:"type"`
+	URL         string             `json:"url,omitempty"`
+	Content     *MessageContent    `json:"content,omitempty"`
+	RetrievedAt *time.Time         `json:"retrieved_at,omitempty"`
+	ErrorCode   WebFetchErrorCode  `json:"error_code,omitempty"`
+}
+
+func (r WebFetchResult) IsError() bool {
+	return r.Type == WebFetchResultTypeError
+}
+
+type MessageContentWebFetchToolResult struct {
+	ToolUseID *string         `json:"tool_use_id,omitempty"`
+	Content   *WebFetchResult `json:"content,omitempty"`
+}
+
+type CodeExecutionResultType string
+
+const (
+	CodeExecutionResultTypeCodeExecution      CodeExecutionResultType = "code_execution_result"
+	CodeExecutionResultTypeCodeExecutionError CodeExecutionResultType = "code_execution_tool_result_error"
+	CodeExecutionResultTypeBash               CodeExecutionResultType = "bash_code_execution_result"
+	CodeExecutionResultTypeBashError          CodeExecutionResultType = "bash_code_execution_tool_result_error"
+	CodeExecutionResultTypeTextEditor         CodeExecutionResultType = "text_editor_code_execution_result"
+	CodeExecutionResultTypeTextEditorError    CodeExecutionResultType = "text_editor_code_execution_tool_result_error"
+)
+
+// CodeExecutionOutput references a file created by the executed code. Its
+// FileID can be downloaded through the Files API.
+type CodeExecutionOutput struct {
+	Type   string `json:"type"`
+	FileID string `json:"file_id"`
+}
+
+// CodeExecutionResult is the content of a code_execution_tool_result or
+// bash_code_execution_tool_result block. Error results only set ErrorCode.
+type CodeExecutionResult struct {
+	Type       CodeExecutionResultType `json:"type"`
+	Stdout     string                  `json:"stdout,omitempty"`
+	Stderr     string                  `json:"stderr,omitempty"`
+	ReturnCode *int                    `json:"return_code,omitempty"`
+	Content    []CodeExecutionOutput   `json:"content,omitempty"`
+	ErrorCode  string                  `json:"error_code,omitempty"`
+}
+
+// MarshalJSON writes only the fields of the result's variant, so that a
+// result sent back to the API matches the one it returned: error results
+// have just type and error_code, other results always have stdout, stderr
+// and return_code, which is 0 when ReturnCode is nil.
+func (r CodeExecutionResult) MarshalJSON() ([]byte, error) {
+	if r.IsError() {
+		return json.Marshal(struct {
+			Type      CodeExecutionResultType `json:"type"`
+			ErrorCode string                  `json:"error_code"`
+		}{r.Type, r.ErrorCode})
+	}
+
+	var returnCode int
+	if r.ReturnCode != nil {
+		returnCode = *r.ReturnCode
+	}
+	var content *[]CodeExecutionOutput
+	if r.Content != nil {
+		content = &r.Content
+	}
+	return json.Marshal(struct {
+		Type       CodeExecutionResultType `json:"type"`
+		Stdout     string                  `json:"stdout"`
+		Stderr     string                  `json:"stderr"`
+		ReturnCode int                     `json:"return_code"`
+		Content    *[]CodeExecutionOutput  `json:"content,omitempty"`
+	}{r.Type, r.Stdout, r.Stderr, returnCode, content})
+}
+
+func (r CodeExecutionResult) IsError() bool {
+	return r.Type == CodeExecutionResultTypeCodeExecutionError ||
+		r.Type == CodeExecutionResultTypeBashError
+}
+
+// FileIDs returns the IDs of the files created by the executed code.
+func (r CodeExecutionResult) FileIDs() []string {
+	ids := make([]string, 0, len(r.Content))
+	for _, output := range r.Content {
+		ids = append(ids, output.FileID)
+	}
+	return ids
+}
+
+type MessageContentCodeExecutionToolResult struct {
+	ToolUseID *string              `json:"tool_use_id,omitempty"`
+	Content   *CodeExecutionResult `json:"content,omitempty"`
+}
+
+// TextEditorCodeExecutionResult is the content of a
+// text_editor_code_execution_tool_result block. Which fields are set depends
+// on the command the model ran: view sets FileType, FileContent and the line
+// counts, create sets IsFileUpdate and str_replace sets the diff fields.
+type TextEditorCodeExecutionResult struct {
+	Type         CodeExecutionResultType `json:"type"`
+	ErrorCode    string                  `json:"error_code,omitempty"`
+	ErrorMessage *string                 `json:"error_message,omitempty"`
+
+	FileType    string `json:"file_type,omitempty"`
+	FileContent string `json:"content,omitempty"`
+	NumLines    *int   `json:"numLines,omitempty"`
+	StartLine   *int   `json:"startLine,omitempty"`
+	TotalLines  *int   `json:"totalLines,omitempty"`
+
+	IsFileUpdate *bool `json:"is_file_update,omitempty"`
+
+	OldStart *int     `json:"oldStart,omitempty"`
+	OldLines *int     `json:"oldLines,omitempty"`
+	NewStart *int     `json:"newStart,omitempty"`
+	NewLines *int     `json:"newLines,omitempty"`
+	Lines    []string `json:"lines,omitempty"`
+}
+
+func (r TextEditorCodeExecutionResult) IsError() bool {
+	return r.Type == CodeExecutionResultTypeTextEditorError
+}
+
+type MessageContentTextEditorCodeExecutionToolResult struct {
+	ToolUseID *string                        `json:"tool_use_id,omitempty"`
+	Content   *TextEditorCodeExecutionResult `json:"content,omitempty"`
+}
+
+// MessageContentSearchResult holds the fields of a search_result block; its
+// title and citations settings are MessageContent.Title and
+// MessageContent.DocumentCitations.
+type MessageContentSearchResult struct {
+	Source  string           `json:"source"`
+	Content []MessageContent `json:"content"`
+}
+
 type MessageContentSource struct {
 	Type      MessagesContentSourceType `json:"type"`
 	MediaType string                    `json:"media_type,omitempty"`
 	Data      any                       `json:"data,omitempty"`
 	Content   []MessageContent          `json:"content,omitempty"`
 	Url       string                    `json:"url,omitempty"`
+	FileID    FileId                    `json:"file_id,omitempty"`
 }
 
 func NewMessageContentSource(
@@ -767,6 +1346,35 @@ func (c *MessageContentToolUse) UnmarshalInput(v any) error {
 	return json.Unmarshal(c.Input, v)
 }
 
+// MessageContentMCPToolUse is a tool call Claude made against a remote MCP
+// server declared in MessagesRequest.MCPServers.
+type MessageContentMCPToolUse struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	ServerName string          `json:"server_name"`
+	Input      json.RawMessage `json:"input"`
+}
+
+func NewMessageContentMCPToolUse(
+	toolUseId, name, serverName string,
+	input json.RawMessage,
+) *MessageContentMCPToolUse {
+	if input == nil {
+		input = json.RawMessage(`{}`)
+	}
+
+	return &MessageContentMCPToolUse{
+		ID:         toolUseId,
+		Name:       name,
+		ServerName: serverName,
+		Input:      input,
+	}
+}
+
+func (c *MessageContentMCPToolUse) UnmarshalInput(v any) error {
+	return json.Unmarshal(c.Input, v)
+}
+
 type MessageContentThinking struct {
 	Thinking  string `json:"thinking"`
 	Signature string `json:"signature"`
@@ -787,6 +1395,27 @@ type MessagesResponse struct {
 	StopReason   MessagesStopReason   `json:"stop_reason"`
 	StopSequence string               `json:"stop_sequence"`
 	Usage        MessagesUsage        `json:"usage"`
+	// Container is set when the code execution tool ran; pass its ID as
+	// MessagesRequest.Container to reuse the sandbox on the next turn.
+	Container *MessagesContainer `json:"container,omitempty"`
+	// ContextManagement reports the context edits the server applied.
+	ContextManagement *MessagesContextManagement `json:"context_management,omitempty"`
+}
+
+type MessagesContainer struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// GetToolUses returns the tool_use blocks of the response, in order.
+func (m MessagesResponse) GetToolUses() []MessageContentToolUse {
+	var toolUses []MessageContentToolUse
+	for _, c := range m.Content {
+		if c.Type == MessagesContentTypeToolUse && c.MessageContentToolUse != nil {
+			toolUses = append(toolUses, *c.MessageContentToolUse)
+		}
+	}
+	return toolUses
 }
 
 // GetFirstContentText get Content[0].Text avoid panic
@@ -858,6 +1487,9 @@ type ToolDefinition struct {
 	DisplayHeightPx int `json:"display_height_px,omitempty"`
 	// DisplayNumber is an optional parameter of the Computer Use tool.
 	DisplayNumber *int `json:"display_number,omitempty"`
+	// MaxCharacters is an optional parameter of the text_editor_20250728 tool
+	// that truncates the output of the view command.
+	MaxCharacters *int `json:"max_characters,omitempty"`
 
 	// Required for web search tool configuration.
 	MaxUses           *int          `json:"max_uses,omitempty"`
@@ -865,8 +1497,44 @@ type ToolDefinition struct {
 	BlockedDomains    []string      `json:"blocked_domains,omitempty"`
 	UserLocation      *UserLocation `json:"user_location,omitempty"`
 	ResponseInclusion *string       `json:"response_inclusion,omitempty"`
+
+	// MaxContentTokens limits the size of documents fetched by the web fetch
+	// tool.
+	MaxContentTokens *int `json:"max_content_tokens,omitempty"`
+	// Citations enables citations on documents fetched by the web fetch tool.
+	Citations *DocumentCitations `json:"citations,omitempty"`
 }
 
+const (
+	ToolTypeComputer20241022   = "computer_20241022"
+	ToolTypeComputer20250124   = "computer_20250124"
+	ToolTypeTextEditor20241022 = "text_editor_20241022"
+	ToolTypeTextEditor20250124 = "text_editor_20250124"
+	ToolTypeTextEditor20250429 = "text_editor_20250429"
+	ToolTypeTextEditor20250728 = "text_editor_20250728"
+	ToolTypeBash20241022       = "bash_20241022"
+	ToolTypeBash20250124       = "bash_20250124"
+	ToolTypeMemory20250818     = "memory_20250818"
+
+	ToolTypeCodeExecution20250522 = "code_execution_20250522"
+	ToolTypeCodeExecution20250825 = "code_execution_20250825"
+	ToolTypeWebFetch20250910      = "web_fetch_20250910"
+)
+
+const (
+	// ToolNameBash is the fixed name of the bash tool.
+	ToolNameBash = "bash"
+	// ToolNameTextEditor is the fixed name of the text_editor_20250429 and
+	// text_editor_20250728 tools.
+	ToolNameTextEditor = "str_replace_based_edit_tool"
+	// ToolNameMemory is the fixed name of the memory tool.
+	ToolNameMemory = "memory"
+	// ToolNameCodeExecution is the fixed name of the code execution tool.
+	ToolNameCodeExecution = "code_execution"
+	// ToolNameWebFetch is the fixed name of the web fetch tool.
+	ToolNameWebFetch = "web_fetch"
+)
+
 func NewComputerUseToolDefinition(
 	name string,
 	displayWidthPx int,
@@ -874,7 +1542,7 @@ func NewComputerUseToolDefinition(
 	displayNumber *int,
 ) ToolDefinition {
 	return ToolDefinition{
-		Type:            "computer_20241022",
+		Type:            ToolTypeComputer20241022,
 		Name:            name,
 		DisplayWidthPx:  displayWidthPx,
 		DisplayHeightPx: displayHeightPx,
@@ -882,24 +1550,122 @@ func NewComputerUseToolDefinition(
 	}
 }
 
+// NewComputerUse20250124ToolDefinition declares the computer_20250124 tool,
+// which adds scroll, triple_click, mouse down/up, hold_key and wait actions.
+func NewComputerUse20250124ToolDefinition(
+	name string,
+	displayWidthPx int,
+	displayHeightPx int,
+	displayNumber *int,
+) ToolDefinition {
+	def := NewComputerUseToolDefinition(name, displayWidthPx, displayHeightPx, displayNumber)
+	def.Type = ToolTypeComputer20250124
+	return def
+}
+
 func NewTextEditorToolDefinition(name string) ToolDefinition {
 	return ToolDefinition{
-		Type: "text_editor_20241022",
+		Type: ToolTypeTextEditor20241022,
 		Name: name,
 	}
 }
 
+// NewTextEditor20250728ToolDefinition declares the text editor tool for Claude 4
+// models. maxCharacters optionally truncates the output of the view command.
+func NewTextEditor20250728ToolDefinition(maxCharacters *int) ToolDefinition {
+	return ToolDefinition{
+		Type:          ToolTypeTextEditor20250728,
+		Name:          ToolNameTextEditor,
+		MaxCharacters: maxCharacters,
+	}
+}
+
 func NewBashToolDefinition(name string) ToolDefinition {
 	return ToolDefinition{
-		Type: "bash_20241022",
+		Type: ToolTypeBash20241022,
+		Name: name,
+	}
+}
+
+func NewBash20250124ToolDefinition() ToolDefinition {
+	return ToolDefinition{
+		Type: ToolTypeBash20250124,
+		Name: ToolNameBash,
+	}
+}
+
+// NewMemoryToolDefinition declares the memory tool. It requires the
+// BetaContextManagement20250627 beta.
+func NewMemoryToolDefinition() ToolDefinition {
+	return ToolDefinition{
+		Type: ToolTypeMemory20250818,
+		Name: ToolNameMemory,
+	}
+}
+
+// NewCodeExecutionToolDefinition declares the code_execution_20250825 server
+// tool, which runs bash commands and edits files in a sandboxed container. It
+// requires the BetaCodeExecution20250825 beta.
+func NewCodeExecutionToolDefinition() ToolDefinition {
+	return ToolDefinition{
+		Type: ToolTypeCodeExecution20250825,
+		Name: ToolNameCodeExecution,
+	}
+}
+
+// NewWebFetchToolDefinition declares the web_fetch_20250910 server tool. Set
+// MaxUses, MaxContentTokens, AllowedDomains, BlockedDomains or Citations on
+// the result to configure it. It requires the BetaWebFetch20250910 beta.
+func NewWebFetchToolDefinition() ToolDefinition {
+	return ToolDefinition{
+		Type: ToolTypeWebFetch20250910,
+		Name: ToolNameWebFetch,
+	}
+}
+
+const (
+	ToolChoiceTypeAuto = "auto"
+	ToolChoiceTypeAny  = "any"
+	ToolChoiceTypeTool = "tool"
+	ToolChoiceTypeNone = "none"
+)
+
+type MCPServerType string
+
+const (
+	MCPServerTypeURL MCPServerType = "url"
+)
+
+// MCPServerDefinition declares a remote MCP server that the API connects to
+// on behalf of the caller (MCP connector).
+type MCPServerDefinition struct {
+	Type               MCPServerType         `json:"type"`
+	URL                string                `json:"url"`
+	Name               string                `json:"name"`
+	AuthorizationToken string                `json:"authorization_token,omitempty"`
+	ToolConfiguration  *MCPToolConfiguration `json:"tool_configuration,omitempty"`
+}
+
+type MCPToolConfiguration struct {
+	Enabled      *bool    `json:"enabled,omitempty"`
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+}
+
+func NewMCPServerDefinition(name, url string) MCPServerDefinition {
+	return MCPServerDefinition{
+		Type: MCPServerTypeURL,
+		URL:  url,
 		Name: name,
 	}
 }
 
 type ToolChoice struct {
-	// oneof: auto(default) any tool
+	// oneof: auto(default) any tool none
 	Type string `json:"type"`
 	Name string `json:"name,omitempty"`
+	// DisableParallelToolUse makes Claude use at most one tool (auto) or exactly
+	// one tool (any, tool). It has no effect with the none type.
+	DisableParallelToolUse *bool `json:"disable_parallel_tool_use,omitempty"`
 }
 
 type Thinking struct {
@@ -915,20 +1681,43 @@ type OutputFormat struct {
 	Schema json.Marshaler   `json:"schema"`
 }
 
+// UnmarshalJSON keeps the schema as a json.RawMessage.
+func (f *OutputFormat) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type   OutputFormatType `json:"type"`
+		Schema json.RawMessage  `json:"schema"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	f.Type = aux.Type
+	f.Schema = nil
+	if len(aux.Schema) > 0 {
+		f.Schema = aux.Schema
+	}
+	return nil
+}
+
 func (c *Client) CreateMessages(
 	ctx context.Context,
 	request MessagesRequest,
+	opts ...RequestOption,
 ) (response MessagesResponse, err error) {
-	request.Stream = false
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
 
-	var setters []requestSetter
-	if len(c.config.BetaVersion) > 0 {
-		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
+	request.Stream = false
+	if err = c.checkModelRequest(request); err != nil {
+		return
+	}
+	if c.config.RequestValidation {
+		if err = request.validate(c.config.BetaVersion); err != nil {
+			return
+		}
 	}
 
-	urlSuffix := "/messages"
-
-	req, err := c.newRequest(ctx, http.MethodPost, urlSuffix, &request, setters...)
+	req, uploaded, err := c.newMessagesRequest(ctx, &request, &request)
+	defer c.deleteUploadedFiles(ctx, uploaded)
 	if err != nil {
 		return
 	}
@@ -8,7 +8,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
 )
 
 var (
@@ -44,6 +43,21 @@ type MessagesStreamRequest struct {
 	OnContentBlockStop  func(MessagesEventContentBlockStopData, MessageContent) `json:"-"`
 	OnMessageDelta      func(MessagesEventMessageDeltaData)                     `json:"-"`
 	OnMessageStop       func(MessagesEventMessageStopData)                      `json:"-"`
+	// OnUnknownEvent is called for events this package does not know. They
+	// are otherwise ignored.
+	OnUnknownEvent func(MessagesEventUnknownData) `json:"-"`
+}
+
+// MessagesEventUnknownData is an event of a type this package does not know.
+type MessagesEventUnknownData struct {
+	Event MessagesEvent
+	// Data is the event data as received.
+	Data json.RawMessage
+}
+
+// MarshalJSON returns the event data as received.
+func (d MessagesEventUnknownData) MarshalJSON() ([]byte, error) {
+	return d.Data, nil
 }
 
 type MessagesEventMessageStartData struct {
@@ -127,9 +141,10 @@ type MessagesEventContentBlockStopData struct {
 }
 
 type MessagesEventMessageDeltaData struct {
-	Type  string           `json:"type"`
-	Delta MessagesResponse `json:"delta"`
-	Usage MessagesUsage    `json:"usage"`
+	Type              string                     `json:"type"`
+	Delta             MessagesResponse           `json:"delta"`
+	Usage             MessagesUsage              `json:"usage"`
+	ContextManagement *MessagesContextManagement `json:"context_management,omitempty"`
 }
 
 type MessagesEventMessageStopData struct {
@@ -139,20 +154,27 @@ type MessagesEventMessageStopData struct {
 func (c *Client) CreateMessagesStream(
 	ctx context.Context,
 	request MessagesStreamRequest,
+	opts ...RequestOption,
 ) (response MessagesResponse, err error) {
-	request.Stream = true
+	c, ctx, cancel := c.withRequestOptions(ctx, opts)
+	defer cancel()
 
-	var setters []requestSetter
-	if len(c.config.BetaVersion) > 0 {
-		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
+	request.Stream = true
+	if err = c.checkModelRequest(request.MessagesRequest); err != nil {
+		return
+	}
+	if c.config.RequestValidation {
+		if err = request.MessagesRequest.validate(c.config.BetaVersion); err != nil {
+			return
+		}
 	}
 
-	urlSuffix := "/messages"
-
-	req, err := c.newStreamRequest(ctx, http.MethodPost, urlSuffix, &request, setters...)
+	req, uploaded, err := c.newMessagesRequest(ctx, &request, &request.MessagesRequest)
+	defer c.deleteUploadedFiles(ctx, uploaded)
 	if err != nil {
 		return
 	}
+	setStreamHeaders(req)
 
 	resp, err := c.config.HTTPClient.Do(req)
 	if err != nil {
@@ -288,6 +310,15 @@ func (c *Client) CreateMessagesStream(
 						}
 						stopContent.PartialJson = nil
 						response.Content[d.Index] = stopContent
+					case MessagesContentTypeMCPToolUse:
+						if stopContent.PartialJson != nil &&
+							stopContent.MessageContentMCPToolUse != nil {
+							stopContent.MessageContentMCPToolUse.Input = json.RawMessage(
+								*stopContent.PartialJson,
+							)
+						}
+						stopContent.PartialJson = nil
+						response.Content[d.Index] = stopContent
 					}
 				}
 				if request.OnContentBlockStop != nil {
@@ -303,6 +334,12 @@ func (c *Client) CreateMessagesStream(
 					request.OnMessageDelta(d)
 				}
 				response.StopReason = d.Delta.StopReason
+				if d.Delta.Container != nil {
+					response.Container = d.Delta.Container
+				}
+				if d.ContextManagement != nil {
+					response.ContextManagement = d.ContextManagement
+				}
 				response.StopSequence = d.Delta.StopSequence
 				response.Usage.OutputTokens = d.Usage.OutputTokens
 				continue
@@ -315,6 +352,17 @@ func (c *Client) CreateMessagesStream(
 					request.OnMessageStop(d)
 				}
 				continue
+			default:
+				if len(event) == 0 {
+					break
+				}
+				if request.OnUnknownEvent != nil {
+					request.OnUnknownEvent(MessagesEventUnknownData{
+						Event: eventType,
+						Data:  bytes.TrimSpace(data),
+					})
+				}
+				continue
 			}
 		}
 		emptyMessageCount++
@@ -1066,3 +1066,60 @@ func TestVertexMessagesStreamError(t *testing.T) {
 
 	t.Logf("CreateMessagesStream error: %s", err)
 }
+
+func TestCreateMessagesStreamKeepsUnknownEvents(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		var b strings.Builder
+		b.WriteString("event: message_start\n" +
+			`data: {"type":"message_start","message":{"id":"1","type":"message",` +
+			`"role":"assistant","content":[]}}` + "\n\n")
+		b.WriteString("event: content_block_start\n" +
+			`data: {"type":"content_block_start","index":0,` +
+			`"content_block":{"type":"tool_search_result","hits":[1]}}` + "\n\n")
+		b.WriteString("event: content_block_stop\n" +
+			`data: {"type":"content_block_stop","index":0}` + "\n\n")
+		for i := 0; i < 3; i++ {
+			b.WriteString("event: progress\n" +
+				fmt.Sprintf(`data: {"type":"progress","step":%d}`, i) + "\n\n")
+		}
+		b.WriteString("event: message_stop\n" + `data: {"type":"message_stop"}` + "\n\n")
+		_, _ = w.Write([]byte(b.String()))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithEmptyMessagesLimit(1),
+	)
+	var events []anthropic.MessagesEventUnknownData
+	resp, err := client.CreateMessagesStream(context.Background(), anthropic.MessagesStreamRequest{
+		MessagesRequest: anthropic.MessagesRequest{
+			Model:     anthropic.ModelClaude3Haiku20240307,
+			Messages:  []anthropic.Message{anthropic.NewUserTextMessage("What is your name?")},
+			MaxTokens: 1000,
+		},
+		OnUnknownEvent: func(d anthropic.MessagesEventUnknownData) {
+			events = append(events, d)
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateMessagesStream error: %s", err)
+	}
+
+	if len(events) != 3 || events[2].Event != "progress" ||
+		string(events[2].Data) != `{"type":"progress","step":2}` {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if len(resp.Content) != 1 || !resp.Content[0].IsUnknown() {
+		t.Fatalf("unexpected content %+v", resp.Content)
+	}
+	raw, err := resp.Content[0].MarshalJSON()
+	if err != nil || string(raw) != `{"type":"tool_search_result","hits":[1]}` {
+		t.Fatalf("unexpected block JSON %s, %v", raw, err)
+	}
+}
@@ -1418,3 +1418,60 @@ func TestCitationPageNumberFields(t *testing.T) {
 		t.Fatalf("unexpected end_page_number: %v", c.EndPage)
 	}
 }
+
+func TestUnknownContentRoundTrip(t *testing.T) {
+	data := `{"role":"assistant","content":[` +
+		`{"type":"text","text":"hi","citations":[{"type":"chart_location","chart":{"x":1}}]},` +
+		`{"type":"tool_search_result","tool_use_id":"srvtoolu_1","content":{"hits":[1,2]}}]}`
+
+	var message anthropic.Message
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	text, unknown := message.Content[0], message.Content[1]
+	if text.IsUnknown() || !unknown.IsUnknown() || unknown.Type != "tool_search_result" {
+		t.Fatalf("unexpected content %+v", message.Content)
+	}
+	if !text.Citations[0].IsUnknown() || text.Citations[0].Type != "chart_location" {
+		t.Fatalf("unexpected citation %+v", text.Citations[0])
+	}
+
+	got, err := json.Marshal(unknown)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	want := `{"type":"tool_search_result","tool_use_id":"srvtoolu_1","content":{"hits":[1,2]}}`
+	if string(got) != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+	got, err = json.Marshal(text.Citations[0])
+	if err != nil || string(got) != `{"type":"chart_location","chart":{"x":1}}` {
+		t.Fatalf("unexpected citation JSON %s, %v", got, err)
+	}
+
+	var known anthropic.MessageContent
+	if err := json.Unmarshal([]byte(`{"type":"text","text":"hi"}`), &known); err != nil {
+		t.Fatal(err)
+	}
+	if known.IsUnknown() || known.GetText() != "hi" {
+		t.Fatalf("unexpected content %+v", known)
+	}
+}
+
+func TestContentBlockLocationCitation(t *testing.T) {
+	data := `{"type":"text","text":"The sky is blue","citations":[{` +
+		`"type":"content_block_location","cited_text":"The sky is blue.","document_index":0,` +
+		`"document_title":"Colors","start_block_index":1,"end_block_index":2}]}`
+
+	var content anthropic.MessageContent
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	c := content.Citations[0]
+	if c.IsUnknown() || c.Type != anthropic.CitationTypeContentBlockLocation ||
+		c.CitedText != "The sky is blue." || c.DocumentTitle != "Colors" ||
+		c.StartBlockIndex == nil || *c.StartBlockIndex != 1 ||
+		c.EndBlockIndex == nil || *c.EndBlockIndex != 2 {
+		t.Fatalf("unexpected citation %+v", c)
+	}
+}
@@ -0,0 +1,359 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrModelRetired reports a request to a model past its retirement date.
+	ErrModelRetired = errors.New("model is retired")
+	// ErrMaxTokensExceeded reports a max_tokens above the model's output
+	// limit.
+	ErrMaxTokensExceeded = errors.New("max_tokens exceeds the model's output limit")
+	// ErrInvalidThinkingBudget reports a thinking budget the API would reject.
+	ErrInvalidThinkingBudget = errors.New("invalid thinking budget")
+)
+
+// MinThinkingBudgetTokens is the smallest thinking budget the API accepts.
+const MinThinkingBudgetTokens = 1024
+
+// ModelInfo describes a model: its IDs on each platform, its limits and the
+// features it supports. Zero limits are unknown and not checked.
+type ModelInfo struct {
+	ID          Model
+	DisplayName string
+	// VertexID is the model ID on Vertex AI; the ID itself when empty.
+	VertexID string
+	// BedrockID is the model ID on Amazon Bedrock, if known.
+	BedrockID string
+
+	ContextWindow   int
+	MaxOutputTokens int
+	// ExtendedMaxOutputTokens is the output limit with the
+	// BetaOutput128k20250219 beta, if the model supports it.
+	ExtendedMaxOutputTokens int
+	// MinCacheableTokens is the shortest prompt prefix the model caches.
+	MinCacheableTokens int
+
+	ExtendedThinking  bool
+	AdaptiveThinking  bool
+	Effort            bool
+	CacheTTL1h        bool
+	StructuredOutputs bool
+
+	// RetiresAt is when requests to the model start failing; zero when no
+	// retirement is scheduled.
+	RetiresAt time.Time
+	// Replacement is the suggested model to migrate to.
+	Replacement Model
+}
+
+// Retired reports whether the model is retired at t.
+func (i ModelInfo) Retired(t time.Time) bool {
+	return !i.RetiresAt.IsZero() && !t.Before(i.RetiresAt)
+}
+
+// OutputLimit returns the max_tokens limit given the enabled betas, or 0
+// when unknown.
+func (i ModelInfo) OutputLimit(betas ...BetaVersion) int {
+	if i.ExtendedMaxOutputTokens > 0 && slices.Contains(betas, BetaOutput128k20250219) {
+		return i.ExtendedMaxOutputTokens
+	}
+	return i.MaxOutputTokens
+}
+
+func retiresAt(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+var (
+	modelRegistryMu sync.RWMutex
+	modelRegistry   = map[Model]ModelInfo{}
+)
+
+func init() {
+	legacy := ModelInfo{ContextWindow: 200_000, MaxOutputTokens: 4096, MinCacheableTokens: 1024}
+	claude35 := ModelInfo{ContextWindow: 200_000, MaxOutputTokens: 8192, MinCacheableTokens: 1024}
+	claude4 := ModelInfo{
+		ContextWindow:      200_000,
+		MaxOutputTokens:    64_000,
+		MinCacheableTokens: 1024,
+		ExtendedThinking:   true,
+		CacheTTL1h:         true,
+	}
+	with := func(base ModelInfo, set func(*ModelInfo)) ModelInfo {
+		set(&base)
+		return base
+	}
+
+	models := []ModelInfo{
+		with(legacy, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude2Dot0, "Claude 2.0"
+			i.ContextWindow = 100_000
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.July, 21), ModelClaudeOpus4Dot8
+		}),
+		with(legacy, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude2Dot1, "Claude 2.1"
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.July, 21), ModelClaudeOpus4Dot8
+		}),
+		with(legacy, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Opus20240229, "Claude 3 Opus"
+			i.VertexID = "claude-3-opus@20240229"
+			i.BedrockID = "anthropic.claude-3-opus-20240229-v1:0"
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.January, 5), ModelClaudeOpus4Dot8
+		}),
+		with(legacy, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Sonnet20240229, "Claude 3 Sonnet"
+			i.VertexID = "claude-3-sonnet@20240229"
+			i.BedrockID = "anthropic.claude-3-sonnet-20240229-v1:0"
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.July, 21), ModelClaudeSonnet4Dot6
+		}),
+		with(legacy, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Haiku20240307, "Claude 3 Haiku"
+			i.VertexID = "claude-3-haiku@20240307"
+			i.BedrockID = "anthropic.claude-3-haiku-20240307-v1:0"
+			i.MinCacheableTokens = 2048
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.April, 20), ModelClaudeHaiku4Dot5
+		}),
+		with(claude35, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot5Sonnet20240620, "Claude 3.5 Sonnet"
+			i.VertexID = "claude-3-5-sonnet@20240620"
+			i.BedrockID = "anthropic.claude-3-5-sonnet-20240620-v1:0"
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.October, 22), ModelClaudeSonnet4Dot6
+		}),
+		with(claude35, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot5Sonnet20241022, "Claude 3.5 Sonnet v2"
+			i.VertexID = "claude-3-5-sonnet-v2@20241022"
+			i.BedrockID = "anthropic.claude-3-5-sonnet-20241022-v2:0"
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.October, 22), ModelClaudeSonnet4Dot6
+		}),
+		with(claude35, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot5SonnetLatest, "Claude 3.5 Sonnet"
+			i.RetiresAt, i.Replacement = retiresAt(2025, time.October, 22), ModelClaudeSonnet4Dot6
+		}),
+		with(claude35, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot5Haiku20241022, "Claude 3.5 Haiku"
+			i.VertexID = "claude-3-5-haiku@20241022"
+			i.BedrockID = "anthropic.claude-3-5-haiku-20241022-v1:0"
+			i.MinCacheableTokens = 2048
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.February, 19), ModelClaudeHaiku4Dot5
+		}),
+		with(claude35, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot5HaikuLatest, "Claude 3.5 Haiku"
+			i.MinCacheableTokens = 2048
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.February, 19), ModelClaudeHaiku4Dot5
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot7Sonnet20250219, "Claude 3.7 Sonnet"
+			i.VertexID = "claude-3-7-sonnet@20250219"
+			i.BedrockID = "anthropic.claude-3-7-sonnet-20250219-v1:0"
+			i.ExtendedMaxOutputTokens = 128_000
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.February, 19), ModelClaudeSonnet4Dot6
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaude3Dot7SonnetLatest, "Claude 3.7 Sonnet"
+			i.ExtendedMaxOutputTokens = 128_000
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.February, 19), ModelClaudeSonnet4Dot6
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeHaiku4Dot5V20251001, "Claude Haiku 4.5"
+			i.VertexID = "claude-haiku-4-5@20251001"
+			i.BedrockID = "anthropic.claude-haiku-4-5-20251001-v1:0"
+			i.MinCacheableTokens = 4096
+			i.StructuredOutputs = true
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeOpus4V20250514, "Claude Opus 4"
+			i.VertexID = "claude-opus-4@20250514"
+			i.BedrockID = "anthropic.claude-opus-4-20250514-v1:0"
+			i.MaxOutputTokens = 32_000
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.June, 15), ModelClaudeOpus4Dot8
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeOpus4Dot1V20250805, "Claude Opus 4.1"
+			i.VertexID = "claude-opus-4-1@20250805"
+			i.BedrockID = "anthropic.claude-opus-4-1-20250805-v1:0"
+			i.MaxOutputTokens = 32_000
+			i.StructuredOutputs = true
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.August, 5), ModelClaudeOpus4Dot8
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeSonnet4V20250514, "Claude Sonnet 4"
+			i.VertexID = "claude-sonnet-4@20250514"
+			i.BedrockID = "anthropic.claude-sonnet-4-20250514-v1:0"
+			i.RetiresAt, i.Replacement = retiresAt(2026, time.June, 15), ModelClaudeSonnet4Dot6
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeSonnet4Dot5V20250929, "Claude Sonnet 4.5"
+			i.VertexID = "claude-sonnet-4-5@20250929"
+			i.BedrockID = "anthropic.claude-sonnet-4-5-20250929-v1:0"
+			i.StructuredOutputs = true
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeOpus4Dot5V20251101, "Claude Opus 4.5"
+			i.VertexID = "claude-opus-4-5@20251101"
+			i.BedrockID = "anthropic.claude-opus-4-5-20251101-v1:0"
+			i.MinCacheableTokens = 4096
+			i.Effort, i.StructuredOutputs = true, true
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeSonnet4Dot6, "Claude Sonnet 4.6"
+			i.AdaptiveThinking, i.Effort, i.StructuredOutputs = true, true, true
+		}),
+		with(claude4, func(i *ModelInfo) {
+			i.ID, i.DisplayName = ModelClaudeOpus4Dot6, "Claude Opus 4.6"
+			i.MaxOutputTokens = 128_000
+			i.MinCacheableTokens = 4096
+			i.AdaptiveThinking, i.Effort, i.StructuredOutputs = true, true, true
+		}),
+	}
+
+	// Newer models: limits are left unknown rather than guessed.
+	for _, m := range []struct {
+		id   Model
+		name string
+	}{
+		{ModelClaudeOpus4Dot7, "Claude Opus 4.7"},
+		{ModelClaudeOpus4Dot8, "Claude Opus 4.8"},
+		{ModelClaudeSonnet5, "Claude Sonnet 5"},
+		{ModelClaudeFable5, "Claude Fable 5"},
+		{ModelClaudeMythos5, "Claude Mythos 5"},
+	} {
+		models = append(models, ModelInfo{
+			ID:                 m.id,
+			DisplayName:        m.name,
+			MinCacheableTokens: 4096,
+			ExtendedThinking:   true,
+			AdaptiveThinking:   true,
+			Effort:             true,
+			CacheTTL1h:         true,
+			StructuredOutputs:  true,
+		})
+	}
+
+	for _, info := range models {
+		RegisterModel(info)
+	}
+
+	// Aliases share the information of the snapshot they point at.
+	for alias, snapshot := range map[Model]Model{
+		ModelClaudeHaiku4Dot5:  ModelClaudeHaiku4Dot5V20251001,
+		ModelClaudeOpus4Dot0:   ModelClaudeOpus4V20250514,
+		ModelClaudeOpus4Dot1:   ModelClaudeOpus4Dot1V20250805,
+		ModelClaudeSonnet4Dot0: ModelClaudeSonnet4V20250514,
+		ModelClaudeSonnet4Dot5: ModelClaudeSonnet4Dot5V20250929,
+		ModelClaudeOpus4Dot5:   ModelClaudeOpus4Dot5V20251101,
+	} {
+		info := modelRegistry[snapshot]
+		info.ID = alias
+		RegisterModel(info)
+	}
+}
+
+// RegisterModel adds or replaces the information of a model, e.g. for a
+// model released after this version of the package.
+func RegisterModel(info ModelInfo) {
+	modelRegistryMu.Lock()
+	defer modelRegistryMu.Unlock()
+	modelRegistry[info.ID] = info
+}
+
+// LookupModel returns the information of a model.
+func LookupModel(model Model) (ModelInfo, bool) {
+	modelRegistryMu.RLock()
+	defer modelRegistryMu.RUnlock()
+	info, ok := modelRegistry[model]
+	return info, ok
+}
+
+// Info returns the information of the model, see LookupModel.
+func (m Model) Info() (ModelInfo, bool) {
+	return LookupModel(m)
+}
+
+func (m Model) asVertexModel() string {
+	if info, ok := m.Info(); ok && info.VertexID != "" {
+		return info.VertexID
+	}
+	return string(m)
+}
+
+// minCacheableTokens returns the shortest prefix the model caches; shorter
+// prefixes are processed without caching even when marked.
+func (m Model) minCacheableTokens() int {
+	if info, ok := m.Info(); ok && info.MinCacheableTokens > 0 {
+		return info.MinCacheableTokens
+	}
+	return 1024
+}
+
+// ModelCheckMode sets how the client treats requests to retired models.
+type ModelCheckMode int
+
+const (
+	// ModelCheckWarn reports retired models to the warning handler and sends
+	// the request anyway.
+	ModelCheckWarn ModelCheckMode = iota
+	// ModelCheckReject fails requests to retired models with ErrModelRetired.
+	ModelCheckReject
+	// ModelCheckOff disables all model checks, limits included.
+	ModelCheckOff
+)
+
+// checkModelRequest checks a request against the model registry before it is
+// sent. Limit violations are always errors, since the API would reject them
+// too; retirement follows the configured ModelCheckMode. Unknown models are
+// not checked.
+func (c *Client) checkModelRequest(request MessagesRequest) error {
+	if c.config.ModelCheck == ModelCheckOff {
+		return nil
+	}
+	info, ok := request.Model.Info()
+	if !ok {
+		return nil
+	}
+
+	if info.Retired(time.Now()) {
+		err := fmt.Errorf("%w: %s retired on %s", ErrModelRetired, info.ID,
+			info.RetiresAt.Format(time.DateOnly))
+		if info.Replacement != "" {
+			err = fmt.Errorf("%w, use %s", err, info.Replacement)
+		}
+		if c.config.ModelCheck == ModelCheckReject {
+			return err
+		}
+		c.config.warnModel(err)
+	}
+
+	if limit := info.OutputLimit(c.config.BetaVersion...); limit > 0 && request.MaxTokens > limit {
+		return fmt.Errorf("%w: %d > %d for %s", ErrMaxTokensExceeded,
+			request.MaxTokens, limit, info.ID)
+	}
+
+	if t := request.Thinking; t != nil && t.Type == ThinkingTypeEnabled {
+		if t.BudgetTokens < MinThinkingBudgetTokens {
+			return fmt.Errorf("%w: budget_tokens %d is below %d", ErrInvalidThinkingBudget,
+				t.BudgetTokens, MinThinkingBudgetTokens)
+		}
+		// Interleaved thinking lets the budget span several turns of output.
+		interleaved := slices.Contains(c.config.BetaVersion, BetaInterleavedThinking20250514)
+		if !interleaved && t.BudgetTokens >= request.MaxTokens {
+			return fmt.Errorf("%w: budget_tokens %d must be less than max_tokens %d",
+				ErrInvalidThinkingBudget, t.BudgetTokens, request.MaxTokens)
+		}
+	}
+	return nil
+}
+
+func (c *ClientConfig) warnModel(err error) {
+	if c.ModelWarningHandler != nil {
+		c.ModelWarningHandler(err)
+		return
+	}
+	log.Printf("anthropic: %v", err)
+}
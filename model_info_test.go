@@ -0,0 +1,202 @@
+package anthropic_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestLookupModel(t *testing.T) {
+	info, ok := anthropic.LookupModel(anthropic.ModelClaudeSonnet4Dot5)
+	if !ok {
+		t.Fatal("claude-sonnet-4-5 is not registered")
+	}
+	if info.ID != anthropic.ModelClaudeSonnet4Dot5 ||
+		info.VertexID != "claude-sonnet-4-5@20250929" ||
+		info.BedrockID != "anthropic.claude-sonnet-4-5-20250929-v1:0" {
+		t.Fatalf("unexpected IDs %+v", info)
+	}
+	if info.ContextWindow != 200_000 || info.MaxOutputTokens != 64_000 || !info.ExtendedThinking {
+		t.Fatalf("unexpected limits %+v", info)
+	}
+
+	haiku, _ := anthropic.ModelClaude3Haiku20240307.Info()
+	if !haiku.Retired(time.Date(2026, time.April, 20, 0, 0, 0, 0, time.UTC)) ||
+		haiku.Retired(time.Date(2026, time.April, 19, 0, 0, 0, 0, time.UTC)) ||
+		haiku.Replacement != anthropic.ModelClaudeHaiku4Dot5 {
+		t.Fatalf("unexpected retirement %+v", haiku)
+	}
+
+	sonnet37, _ := anthropic.ModelClaude3Dot7Sonnet20250219.Info()
+	if sonnet37.OutputLimit() != 64_000 ||
+		sonnet37.OutputLimit(anthropic.BetaOutput128k20250219) != 128_000 {
+		t.Fatalf("unexpected output limits %+v", sonnet37)
+	}
+
+	anthropic.RegisterModel(anthropic.ModelInfo{ID: "claude-test-1", MaxOutputTokens: 10})
+	if custom, ok := anthropic.LookupModel("claude-test-1"); !ok || custom.MaxOutputTokens != 10 {
+		t.Fatalf("registered model not found: %+v", custom)
+	}
+	if _, ok := anthropic.LookupModel("claude-unknown"); ok {
+		t.Fatal("unexpected info for an unknown model")
+	}
+}
+
+func TestModelRetirementDates(t *testing.T) {
+	// The retirement dates of Anthropic's model deprecations page.
+	want := map[anthropic.Model]string{
+		anthropic.ModelClaude2Dot0:               "2025-07-21",
+		anthropic.ModelClaude2Dot1:               "2025-07-21",
+		anthropic.ModelClaude3Sonnet20240229:     "2025-07-21",
+		anthropic.ModelClaude3Dot5Sonnet20240620: "2025-10-22",
+		anthropic.ModelClaude3Dot5Sonnet20241022: "2025-10-22",
+		anthropic.ModelClaude3Opus20240229:       "2026-01-05",
+		anthropic.ModelClaude3Dot5Haiku20241022:  "2026-02-19",
+		anthropic.ModelClaude3Dot7Sonnet20250219: "2026-02-19",
+		anthropic.ModelClaude3Haiku20240307:      "2026-04-20",
+		anthropic.ModelClaudeOpus4V20250514:      "2026-06-15",
+		anthropic.ModelClaudeSonnet4V20250514:    "2026-06-15",
+		anthropic.ModelClaudeOpus4Dot1V20250805:  "2026-08-05",
+	}
+	for model, date := range want {
+		info, ok := model.Info()
+		if !ok || info.RetiresAt.Format(time.DateOnly) != date {
+			t.Errorf("%s: got retirement %v, want %s", model, info.RetiresAt, date)
+		}
+	}
+}
+
+func TestClientModelChecks(t *testing.T) {
+	requests := 0
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant",`+
+			`"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn",`+
+			`"usage":{"input_tokens":1,"output_tokens":1}}`)
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	newClient := func(opts ...anthropic.ClientOption) *anthropic.Client {
+		opts = append([]anthropic.ClientOption{anthropic.WithBaseURL(ts.URL + "/v1")}, opts...)
+		return anthropic.NewClient(test.GetTestToken(), opts...)
+	}
+	request := func(model anthropic.Model, maxTokens int) anthropic.MessagesRequest {
+		return anthropic.MessagesRequest{
+			Model:     model,
+			Messages:  []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+			MaxTokens: maxTokens,
+		}
+	}
+	thinking := func(maxTokens, budget int) anthropic.MessagesRequest {
+		r := request(anthropic.ModelClaudeSonnet4Dot5, maxTokens)
+		r.Thinking = &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: budget}
+		return r
+	}
+	ctx := context.Background()
+
+	t.Run("retired model warns", func(t *testing.T) {
+		var warnings []error
+		client := newClient(anthropic.WithModelWarningHandler(func(err error) {
+			warnings = append(warnings, err)
+		}))
+		before := requests
+		_, err := client.CreateMessages(ctx, request(anthropic.ModelClaude3Haiku20240307, 100))
+		if err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		if len(warnings) != 1 || !errors.Is(warnings[0], anthropic.ErrModelRetired) {
+			t.Fatalf("unexpected warnings %v", warnings)
+		}
+		if requests != before+1 {
+			t.Fatal("the request was not sent")
+		}
+	})
+
+	t.Run("retired model logs without a handler", func(t *testing.T) {
+		var logged bytes.Buffer
+		log.SetOutput(&logged)
+		defer log.SetOutput(os.Stderr)
+		_, err := newClient().CreateMessages(ctx, request(anthropic.ModelClaude3Haiku20240307, 100))
+		if err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		if !strings.Contains(logged.String(), "anthropic: model is retired") {
+			t.Fatalf("the warning was not logged: %q", logged.String())
+		}
+	})
+
+	t.Run("retired model rejected", func(t *testing.T) {
+		client := newClient(anthropic.WithModelCheck(anthropic.ModelCheckReject))
+		before := requests
+		_, err := client.CreateMessagesStream(ctx, anthropic.MessagesStreamRequest{
+			MessagesRequest: request(anthropic.ModelClaude3Haiku20240307, 100),
+		})
+		if !errors.Is(err, anthropic.ErrModelRetired) {
+			t.Fatalf("expected ErrModelRetired, got %v", err)
+		}
+		if requests != before {
+			t.Fatal("the request was sent")
+		}
+	})
+
+	tests := []struct {
+		name    string
+		opts    []anthropic.ClientOption
+		request anthropic.MessagesRequest
+		want    error
+	}{
+		{
+			name:    "max tokens",
+			request: request(anthropic.ModelClaudeSonnet4Dot5, 100_000),
+			want:    anthropic.ErrMaxTokensExceeded,
+		},
+		{
+			name:    "max tokens unchecked",
+			opts:    []anthropic.ClientOption{anthropic.WithModelCheck(anthropic.ModelCheckOff)},
+			request: request(anthropic.ModelClaudeSonnet4Dot5, 100_000),
+		},
+		{
+			name:    "unknown model",
+			request: request("claude-unknown", 1_000_000),
+		},
+		{
+			name:    "small thinking budget",
+			request: thinking(4000, 512),
+			want:    anthropic.ErrInvalidThinkingBudget,
+		},
+		{
+			name:    "thinking budget above max tokens",
+			request: thinking(2000, 4000),
+			want:    anthropic.ErrInvalidThinkingBudget,
+		},
+		{
+			name: "interleaved thinking budget above max tokens",
+			opts: []anthropic.ClientOption{
+				anthropic.WithBetaVersion(anthropic.BetaInterleavedThinking20250514),
+			},
+			request: thinking(2000, 4000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newClient(tt.opts...).CreateMessages(ctx, tt.request)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type ModelRespCore struct {
+	Type string `json:"type"`
+	// ID is the dated model ID, also when the model was requested by alias.
+	ID          Model     `json:"id"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type ModelResponse struct {
+	httpHeader
+
+	ModelRespCore
+}
+
+type ListModelsResponse struct {
+	httpHeader
+
+	Data    []ModelRespCore `json:"data"`
+	HasMore bool            `json:"has_more"`
+	FirstId *string         `json:"first_id"`
+	LastId  *string         `json:"last_id"`
+}
+
+type ListModelsRequest struct {
+	BeforeId *string `json:"before_id,omitempty"`
+	AfterId  *string `json:"after_id,omitempty"`
+	Limit    *int    `json:"limit,omitempty"`
+}
+
+func (l ListModelsRequest) validate() error {
+	if l.Limit != nil && (*l.Limit < 1 || *l.Limit > 1000) {
+		return errors.New("limit must be between 1 and 1000")
+	}
+
+	return nil
+}
+
+// ListModels lists the available models, most recently released first.
+func (c *Client) ListModels(
+	ctx context.Context,
+	lModelReq ListModelsRequest,
+) (*ListModelsResponse, error) {
+	var setters []requestSetter
+	if len(c.config.BetaVersion) > 0 {
+		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
+	}
+
+	if err := lModelReq.validate(); err != nil {
+		return nil, err
+	}
+
+	urlSuffix := "/models"
+
+	v := url.Values{}
+	if lModelReq.BeforeId != nil {
+		v.Set("before_id", *lModelReq.BeforeId)
+	}
+	if lModelReq.AfterId != nil {
+		v.Set("after_id", *lModelReq.AfterId)
+	}
+	if lModelReq.Limit != nil {
+		v.Set("limit", fmt.Sprintf("%d", *lModelReq.Limit))
+	}
+
+	if encoded := v.Encode(); encoded != "" {
+		urlSuffix += "?" + encoded
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ListModelsResponse
+	err = c.sendRequest(req, &response)
+
+	return &response, err
+}
+
+// GetModel returns a model by ID or alias. For an alias such as
+// ModelClaudeSonnet4Dot5 the response holds the dated ID it currently
+// points at.
+func (c *Client) GetModel(
+	ctx context.Context,
+	model Model,
+) (*ModelResponse, error) {
+	var setters []requestSetter
+	if len(c.config.BetaVersion) > 0 {
+		setters = append(setters, withBetaVersion(c.config.BetaVersion...))
+	}
+
+	urlSuffix := "/models/" + url.PathEscape(string(model))
+	req, err := c.newRequest(ctx, http.MethodGet, urlSuffix, nil, setters...)
+	if err != nil {
+		return nil, err
+	}
+
+	var response ModelResponse
+	err = c.sendRequest(req, &response)
+
+	return &response, err
+}
@@ -0,0 +1,123 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestListModels(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/models", handleListModelsEndpoint)
+
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+
+	t.Run("list models success", func(t *testing.T) {
+		resp, err := client.ListModels(context.Background(), anthropic.ListModelsRequest{
+			AfterId: toPtr("claude-opus-4-5-20251101"),
+			Limit:   toPtr(2),
+		})
+		if err != nil {
+			t.Fatalf("ListModels error: %s", err)
+		}
+		if len(resp.Data) != 2 || !resp.HasMore || *resp.LastId != "claude-haiku-4-5-20251001" {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+		if resp.Data[0].DisplayName != "Claude Sonnet 4.5" || resp.Data[0].CreatedAt.IsZero() {
+			t.Fatalf("unexpected model %+v", resp.Data[0])
+		}
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		_, err := client.ListModels(context.Background(), anthropic.ListModelsRequest{
+			Limit: toPtr(0),
+		})
+		if err == nil {
+			t.Fatal("ListModels expected error, got nil")
+		}
+	})
+}
+
+func TestGetModel(t *testing.T) {
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/models/claude-sonnet-4-5", handleGetModelEndpoint)
+
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+
+	t.Run("resolves alias", func(t *testing.T) {
+		resp, err := client.GetModel(context.Background(), anthropic.ModelClaudeSonnet4Dot5)
+		if err != nil {
+			t.Fatalf("GetModel error: %s", err)
+		}
+		if resp.ID != anthropic.ModelClaudeSonnet4Dot5V20250929 || resp.Type != "model" {
+			t.Fatalf("unexpected model %+v", resp)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetModel(context.Background(), "claude-unknown")
+		if err == nil {
+			t.Fatal("GetModel expected error, got nil")
+		}
+	})
+}
+
+func forgeModelResponse(id anthropic.Model, name string) anthropic.ModelRespCore {
+	return anthropic.ModelRespCore{
+		Type:        "model",
+		ID:          id,
+		DisplayName: name,
+		CreatedAt:   time.Date(2025, time.September, 29, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func handleListModelsEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Query().Get("after_id") != "claude-opus-4-5-20251101" ||
+		r.URL.Query().Get("limit") != "2" {
+		http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+		return
+	}
+
+	first := "claude-sonnet-4-5-20250929"
+	last := "claude-haiku-4-5-20251001"
+	res := anthropic.ListModelsResponse{
+		Data: []anthropic.ModelRespCore{
+			forgeModelResponse(anthropic.ModelClaudeSonnet4Dot5V20250929, "Claude Sonnet 4.5"),
+			forgeModelResponse(anthropic.ModelClaudeHaiku4Dot5V20251001, "Claude Haiku 4.5"),
+		},
+		HasMore: true,
+		FirstId: &first,
+		LastId:  &last,
+	}
+
+	resBytes, _ := json.Marshal(res)
+	_, _ = w.Write(resBytes)
+}
+
+func handleGetModelEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res := forgeModelResponse(anthropic.ModelClaudeSonnet4Dot5V20250929, "Claude Sonnet 4.5")
+	resBytes, _ := json.Marshal(res)
+	_, _ = w.Write(resBytes)
+}
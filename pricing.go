@@ -0,0 +1,309 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownModelPricing is returned by Cost for a model without pricing.
+// Register it with PricingRegistry.Set.
+var ErrUnknownModelPricing = errors.New("no pricing for model")
+
+const (
+	defaultCacheWrite5mMultiplier = 1.25
+	defaultCacheWrite1hMultiplier = 2
+	defaultCacheReadMultiplier    = 0.1
+	defaultWebSearchPerThousand   = 10
+	batchDiscount                 = 0.5
+)
+
+// ModelPricing holds the USD list prices of a model. Token prices are per
+// million tokens. Zero multipliers and fees use the API defaults.
+type ModelPricing struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+
+	// CacheWrite5mMultiplier applies to the input price for 5 minute cache
+	// writes; 1.25 by default.
+	CacheWrite5mMultiplier float64
+	// CacheWrite1hMultiplier applies to the input price for 1 hour cache
+	// writes; 2 by default.
+	CacheWrite1hMultiplier float64
+	// CacheReadMultiplier applies to the input price for cache reads; 0.1 by
+	// default.
+	CacheReadMultiplier float64
+
+	// LongContextThreshold, if set, is the input size (cached tokens
+	// included) above which the LongContext prices apply to the request.
+	LongContextThreshold     int
+	LongContextInputPerMTok  float64
+	LongContextOutputPerMTok float64
+
+	// WebSearchPerThousand is the fee per thousand web searches; $10 by
+	// default.
+	WebSearchPerThousand float64
+}
+
+func sonnetPricing(longContext bool) ModelPricing {
+	p := ModelPricing{InputPerMTok: 3, OutputPerMTok: 15}
+	if longContext {
+		p.LongContextThreshold = 200_000
+		p.LongContextInputPerMTok = 6
+		p.LongContextOutputPerMTok = 22.5
+	}
+	return p
+}
+
+var defaultModelPricing = map[Model]ModelPricing{
+	ModelClaude3Haiku20240307:      {InputPerMTok: 0.25, OutputPerMTok: 1.25},
+	ModelClaude3Dot5HaikuLatest:    {InputPerMTok: 0.8, OutputPerMTok: 4},
+	ModelClaude3Dot5Haiku20241022:  {InputPerMTok: 0.8, OutputPerMTok: 4},
+	ModelClaudeHaiku4Dot5:          {InputPerMTok: 1, OutputPerMTok: 5},
+	ModelClaudeHaiku4Dot5V20251001: {InputPerMTok: 1, OutputPerMTok: 5},
+
+	ModelClaude3Sonnet20240229:      sonnetPricing(false),
+	ModelClaude3Dot5Sonnet20240620:  sonnetPricing(false),
+	ModelClaude3Dot5Sonnet20241022:  sonnetPricing(false),
+	ModelClaude3Dot5SonnetLatest:    sonnetPricing(false),
+	ModelClaude3Dot7SonnetLatest:    sonnetPricing(false),
+	ModelClaude3Dot7Sonnet20250219:  sonnetPricing(false),
+	ModelClaudeSonnet4Dot0:          sonnetPricing(true),
+	ModelClaudeSonnet4V20250514:     sonnetPricing(true),
+	ModelClaudeSonnet4Dot5:          sonnetPricing(true),
+	ModelClaudeSonnet4Dot5V20250929: sonnetPricing(true),
+	ModelClaudeSonnet4Dot6:          sonnetPricing(true),
+
+	ModelClaude3Opus20240229:      {InputPerMTok: 15, OutputPerMTok: 75},
+	ModelClaudeOpus4Dot0:          {InputPerMTok: 15, OutputPerMTok: 75},
+	ModelClaudeOpus4V20250514:     {InputPerMTok: 15, OutputPerMTok: 75},
+	ModelClaudeOpus4Dot1:          {InputPerMTok: 15, OutputPerMTok: 75},
+	ModelClaudeOpus4Dot1V20250805: {InputPerMTok: 15, OutputPerMTok: 75},
+	ModelClaudeOpus4Dot5:          {InputPerMTok: 5, OutputPerMTok: 25},
+	ModelClaudeOpus4Dot5V20251101: {InputPerMTok: 5, OutputPerMTok: 25},
+	ModelClaudeOpus4Dot6:          {InputPerMTok: 5, OutputPerMTok: 25},
+}
+
+// PricingRegistry maps models to their prices. It is safe for concurrent
+// use.
+type PricingRegistry struct {
+	mu     sync.RWMutex
+	prices map[Model]ModelPricing
+}
+
+// DefaultPricingRegistry is used by Cost unless WithPricingRegistry is given.
+// It holds the published list prices of the Anthropic API; models without
+// published prices must be registered with Set.
+var DefaultPricingRegistry = NewPricingRegistry()
+
+// NewPricingRegistry returns a registry holding the default prices.
+func NewPricingRegistry() *PricingRegistry {
+	r := &PricingRegistry{prices: make(map[Model]ModelPricing, len(defaultModelPricing))}
+	for model, pricing := range defaultModelPricing {
+		r.prices[model] = pricing
+	}
+	return r
+}
+
+// Set adds or overrides the pricing of a model.
+func (r *PricingRegistry) Set(model Model, pricing ModelPricing) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prices[model] = pricing
+}
+
+func (r *PricingRegistry) Lookup(model Model) (ModelPricing, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	pricing, ok := r.prices[model]
+	return pricing, ok
+}
+
+// UsageCost is a cost in USD, broken down by what was billed.
+type UsageCost struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cache_write"`
+	CacheRead  float64 `json:"cache_read"`
+	WebSearch  float64 `json:"web_search"`
+}
+
+func (c UsageCost) Total() float64 {
+	return c.Input + c.Output + c.CacheWrite + c.CacheRead + c.WebSearch
+}
+
+// Add returns the sum of two costs.
+func (c UsageCost) Add(other UsageCost) UsageCost {
+	return UsageCost{
+		Input:      c.Input + other.Input,
+		Output:     c.Output + other.Output,
+		CacheWrite: c.CacheWrite + other.CacheWrite,
+		CacheRead:  c.CacheRead + other.CacheRead,
+		WebSearch:  c.WebSearch + other.WebSearch,
+	}
+}
+
+type costOptions struct {
+	registry *PricingRegistry
+	pricing  *ModelPricing
+	batch    bool
+}
+
+type CostOption func(*costOptions)
+
+// WithPricingRegistry looks prices up in registry instead of
+// DefaultPricingRegistry.
+func WithPricingRegistry(registry *PricingRegistry) CostOption {
+	return func(o *costOptions) {
+		o.registry = registry
+	}
+}
+
+// WithModelPricing uses pricing instead of looking the model up.
+func WithModelPricing(pricing ModelPricing) CostOption {
+	return func(o *costOptions) {
+		o.pricing = &pricing
+	}
+}
+
+// WithBatchDiscount applies the 50% Message Batches discount to token
+// prices.
+func WithBatchDiscount() CostOption {
+	return func(o *costOptions) {
+		o.batch = true
+	}
+}
+
+// Cost computes the list price of usage for model. Cache writes are split by
+// TTL when the usage reports it and priced as 5 minute writes otherwise.
+func Cost(usage MessagesUsage, model Model, opts ...CostOption) (UsageCost, error) {
+	o := costOptions{registry: DefaultPricingRegistry}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var p ModelPricing
+	if o.pricing != nil {
+		p = *o.pricing
+	} else {
+		var ok bool
+		if p, ok = o.registry.Lookup(model); !ok {
+			return UsageCost{}, fmt.Errorf("%w %q", ErrUnknownModelPricing, model)
+		}
+	}
+
+	input, output := p.InputPerMTok, p.OutputPerMTok
+	totalInput := usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens
+	if p.LongContextThreshold > 0 && totalInput > p.LongContextThreshold {
+		input, output = p.LongContextInputPerMTok, p.LongContextOutputPerMTok
+	}
+	if o.batch {
+		input *= batchDiscount
+		output *= batchDiscount
+	}
+
+	write5m := usage.CacheCreation.Ephemeral5mInputTokens
+	write1h := usage.CacheCreation.Ephemeral1hInputTokens
+	if write5m+write1h == 0 {
+		write5m = usage.CacheCreationInputTokens
+	}
+
+	perToken := func(tokens int, price float64) float64 {
+		return float64(tokens) * price / 1_000_000
+	}
+	write5mPrice := input * orDefault(p.CacheWrite5mMultiplier, defaultCacheWrite5mMultiplier)
+	write1hPrice := input * orDefault(p.CacheWrite1hMultiplier, defaultCacheWrite1hMultiplier)
+	readPrice := input * orDefault(p.CacheReadMultiplier, defaultCacheReadMultiplier)
+	cost := UsageCost{
+		Input:      perToken(usage.InputTokens, input),
+		Output:     perToken(usage.OutputTokens, output),
+		CacheWrite: perToken(write5m, write5mPrice) + perToken(write1h, write1hPrice),
+		CacheRead:  perToken(usage.CacheReadInputTokens, readPrice),
+	}
+	if usage.ServerToolUse != nil {
+		fee := orDefault(p.WebSearchPerThousand, defaultWebSearchPerThousand)
+		cost.WebSearch = float64(usage.ServerToolUse.WebSearchRequests) * fee / 1000
+	}
+	return cost, nil
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// CostAggregator sums costs across responses, in total and per key, e.g. a
+// feature or customer name. It is safe for concurrent use.
+type CostAggregator struct {
+	opts []CostOption
+
+	mu    sync.Mutex
+	total UsageCost
+	byKey map[string]UsageCost
+}
+
+// NewCostAggregator returns an aggregator that prices every usage with opts.
+func NewCostAggregator(opts ...CostOption) *CostAggregator {
+	return &CostAggregator{opts: opts, byKey: make(map[string]UsageCost)}
+}
+
+// Add prices usage and adds it to the total and to key. opts are applied
+// after the aggregator's own, e.g. WithBatchDiscount for a batch result.
+func (a *CostAggregator) Add(
+	key string,
+	usage MessagesUsage,
+	model Model,
+	opts ...CostOption,
+) (UsageCost, error) {
+	cost, err := Cost(usage, model, append(append([]CostOption(nil), a.opts...), opts...)...)
+	if err != nil {
+		return cost, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.total = a.total.Add(cost)
+	a.byKey[key] = a.byKey[key].Add(cost)
+	return cost, nil
+}
+
+// AddResponse adds the usage of resp, priced for the model that served it.
+func (a *CostAggregator) AddResponse(
+	key string,
+	resp MessagesResponse,
+	opts ...CostOption,
+) (UsageCost, error) {
+	return a.Add(key, resp.Usage, resp.Model, opts...)
+}
+
+func (a *CostAggregator) Total() UsageCost {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.total
+}
+
+// ByKey returns a copy of the costs per key.
+func (a *CostAggregator) ByKey() map[string]UsageCost {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make(map[string]UsageCost, len(a.byKey))
+	for k, v := range a.byKey {
+		out[k] = v
+	}
+	return out
+}
+
+// Keys returns the keys costs were added under, sorted.
+func (a *CostAggregator) Keys() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	keys := make([]string, 0, len(a.byKey))
+	for k := range a.byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,128 @@
+package anthropic_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCost(t *testing.T) {
+	usage := anthropic.MessagesUsage{
+		InputTokens:              1_000_000,
+		OutputTokens:             100_000,
+		CacheCreationInputTokens: 300_000,
+		CacheReadInputTokens:     2_000_000,
+		CacheCreation: anthropic.MessageUsageCacheCreation{
+			Ephemeral5mInputTokens: 200_000,
+			Ephemeral1hInputTokens: 100_000,
+		},
+		ServerToolUse: &anthropic.ServerToolUsage{WebSearchRequests: 3},
+	}
+
+	t.Run("list price", func(t *testing.T) {
+		cost, err := anthropic.Cost(usage, anthropic.ModelClaudeOpus4Dot5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := anthropic.UsageCost{
+			Input:      5,
+			Output:     2.5,
+			CacheWrite: 0.2*5*1.25 + 0.1*5*2,
+			CacheRead:  2 * 5 * 0.1,
+			WebSearch:  0.03,
+		}
+		if !almostEqual(cost.Total(), want.Total()) ||
+			!almostEqual(cost.CacheWrite, want.CacheWrite) {
+			t.Fatalf("got %+v, want %+v", cost, want)
+		}
+	})
+
+	t.Run("long context and batch", func(t *testing.T) {
+		cost, err := anthropic.Cost(
+			usage,
+			anthropic.ModelClaudeSonnet4Dot5,
+			anthropic.WithBatchDiscount(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Over 200k input tokens: $6/$22.50, halved by the batch discount.
+		if !almostEqual(cost.Input, 3) || !almostEqual(cost.Output, 0.1*11.25) ||
+			!almostEqual(cost.CacheRead, 2*3*0.1) || !almostEqual(cost.WebSearch, 0.03) {
+			t.Fatalf("unexpected cost %+v", cost)
+		}
+
+		small := anthropic.MessagesUsage{InputTokens: 1000, OutputTokens: 1000}
+		cost, _ = anthropic.Cost(small, anthropic.ModelClaudeSonnet4Dot5)
+		if !almostEqual(cost.Total(), 0.003+0.015) {
+			t.Fatalf("unexpected cost %+v", cost)
+		}
+	})
+
+	t.Run("cache writes without a breakdown are 5m writes", func(t *testing.T) {
+		u := anthropic.MessagesUsage{CacheCreationInputTokens: 1_000_000}
+		cost, _ := anthropic.Cost(u, anthropic.ModelClaudeHaiku4Dot5)
+		if !almostEqual(cost.CacheWrite, 1.25) {
+			t.Fatalf("unexpected cost %+v", cost)
+		}
+	})
+
+	t.Run("registry", func(t *testing.T) {
+		_, err := anthropic.Cost(usage, "claude-unknown")
+		if !errors.Is(err, anthropic.ErrUnknownModelPricing) {
+			t.Fatalf("expected ErrUnknownModelPricing, got %v", err)
+		}
+
+		registry := anthropic.NewPricingRegistry()
+		registry.Set("claude-unknown", anthropic.ModelPricing{InputPerMTok: 1, OutputPerMTok: 1})
+		cost, err := anthropic.Cost(
+			anthropic.MessagesUsage{InputTokens: 1_000_000},
+			"claude-unknown",
+			anthropic.WithPricingRegistry(registry),
+		)
+		if err != nil || !almostEqual(cost.Total(), 1) {
+			t.Fatalf("unexpected cost %+v, %v", cost, err)
+		}
+		if _, ok := anthropic.DefaultPricingRegistry.Lookup("claude-unknown"); ok {
+			t.Fatal("the default registry was changed")
+		}
+	})
+}
+
+func TestCostAggregator(t *testing.T) {
+	agg := anthropic.NewCostAggregator()
+	resp := anthropic.MessagesResponse{
+		Model: anthropic.ModelClaudeHaiku4Dot5,
+		Usage: anthropic.MessagesUsage{InputTokens: 1_000_000, OutputTokens: 200_000},
+	}
+
+	for _, key := range []string{"search", "search", "summaries"} {
+		if _, err := agg.AddResponse(key, resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := agg.AddResponse("batch", resp, anthropic.WithBatchDiscount()); err != nil {
+		t.Fatal(err)
+	}
+
+	byKey := agg.ByKey()
+	if !almostEqual(byKey["search"].Total(), 4) || !almostEqual(byKey["batch"].Total(), 1) {
+		t.Fatalf("unexpected costs %+v", byKey)
+	}
+	if !almostEqual(agg.Total().Total(), 7) || len(agg.Keys()) != 3 {
+		t.Fatalf("unexpected total %+v, keys %v", agg.Total(), agg.Keys())
+	}
+
+	if _, err := agg.Add("x", resp.Usage, "claude-unknown"); err == nil {
+		t.Fatal("expected an error for an unknown model")
+	}
+	if !almostEqual(agg.Total().Total(), 7) {
+		t.Fatal("a failed Add changed the total")
+	}
+}
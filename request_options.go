@@ -0,0 +1,131 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// RequestOption changes the client's settings for a single call.
+type RequestOption func(o *requestOptions)
+
+type requestOptions struct {
+	betas     []BetaVersion
+	header    http.Header
+	timeout   time.Duration
+	baseURL   string
+	extraBody map[string]any
+}
+
+// WithRequestBetaVersion adds beta versions to the client's for one call.
+func WithRequestBetaVersion(betaVersion ...BetaVersion) RequestOption {
+	return func(o *requestOptions) {
+		o.betas = append(o.betas, betaVersion...)
+	}
+}
+
+// WithRequestHeader sets a header on one call. It replaces any header the
+// client sets, including anthropic-beta.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.header == nil {
+			o.header = make(http.Header)
+		}
+		o.header.Set(key, value)
+	}
+}
+
+// WithRequestTimeout limits how long one call may take, including reading a
+// streamed response.
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithRequestBaseURL sends one call to baseUrl instead of the client's.
+func WithRequestBaseURL(baseUrl string) RequestOption {
+	return func(o *requestOptions) {
+		o.baseURL = baseUrl
+	}
+}
+
+// WithExtraBody merges fields into the top level of the JSON body of one
+// call, replacing fields of the same name. It allows API parameters that have
+// no field in the request types yet.
+func WithExtraBody(fields map[string]any) RequestOption {
+	return func(o *requestOptions) {
+		if o.extraBody == nil {
+			o.extraBody = make(map[string]any, len(fields))
+		}
+		maps.Copy(o.extraBody, fields)
+	}
+}
+
+// withRequestOptions returns a copy of the client with opts applied, and ctx
+// with the call's timeout. The returned cancel func must be called when the
+// call is done.
+func (c *Client) withRequestOptions(
+	ctx context.Context,
+	opts []RequestOption,
+) (*Client, context.Context, context.CancelFunc) {
+	if len(opts) == 0 {
+		return c, ctx, func() {}
+	}
+
+	var o requestOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	config := c.config
+	for _, beta := range o.betas {
+		if !slices.Contains(config.BetaVersion, beta) {
+			config.BetaVersion = append(slices.Clip(config.BetaVersion), beta)
+		}
+	}
+	if o.baseURL != "" {
+		config.BaseURL = o.baseURL
+	}
+	config.header = o.header
+	config.extraBody = o.extraBody
+
+	cancel := context.CancelFunc(func() {})
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	}
+	return &Client{config: config}, ctx, cancel
+}
+
+// withoutCallOptions returns the client without the headers and body fields
+// of WithRequestHeader and WithExtraBody, which belong to the call's primary
+// request only, for the secondary requests it makes, such as Files API
+// uploads.
+func (c *Client) withoutCallOptions() *Client {
+	if c.config.header == nil && c.config.extraBody == nil {
+		return c
+	}
+	config := c.config
+	config.header, config.extraBody = nil, nil
+	return &Client{config: config}
+}
+
+// mergeExtraBody adds extra to the JSON object body.
+func mergeExtraBody(body []byte, extra map[string]any) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, fmt.Errorf("error, merging extra body fields: %w", err)
+	}
+	for key, value := range extra {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("error, marshaling extra body field %q: %w", key, err)
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,153 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestRequestOptions(t *testing.T) {
+	var (
+		path   string
+		header http.Header
+		body   map[string]any
+	)
+	record := func(w http.ResponseWriter, r *http.Request) {
+		path, header = r.URL.Path, r.Header
+		body = nil
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &body)
+		}
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+			`"content":[{"type":"text","text":"ok"}],"input_tokens":3}`))
+	}
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", record)
+	server.RegisterHandler("/v2/messages", record)
+	server.RegisterHandler("/v1/messages/count_tokens", record)
+	server.RegisterHandler("/v1/messages/batches/batch_1", record)
+	server.RegisterHandler("/v1/slow/messages", func(_ http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithBetaVersion(anthropic.BetaTokenCounting20241101),
+	)
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaudeSonnet4Dot5,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+		MaxTokens: 100,
+	}
+	ctx := context.Background()
+
+	_, err := client.CreateMessages(ctx, request,
+		anthropic.WithRequestBetaVersion(anthropic.BetaWebFetch20250910),
+		anthropic.WithRequestHeader("X-Trace-Id", "trace-1"),
+		anthropic.WithExtraBody(map[string]any{"max_tokens": 50, "service_tier": "auto"}),
+	)
+	if err != nil {
+		t.Fatalf("CreateMessages error: %v", err)
+	}
+	wantBeta := "token-counting-2024-11-01,web-fetch-2025-09-10"
+	if got := header.Get("anthropic-beta"); got != wantBeta {
+		t.Errorf("got beta header %q, want %q", got, wantBeta)
+	}
+	if header.Get("X-Trace-Id") != "trace-1" {
+		t.Errorf("missing request header: %v", header)
+	}
+	if body["service_tier"] != "auto" || body["max_tokens"] != float64(50) || body["model"] == nil {
+		t.Errorf("unexpected body %v", body)
+	}
+
+	_, err = client.CreateMessagesStream(ctx, anthropic.MessagesStreamRequest{
+		MessagesRequest: request,
+	}, anthropic.WithRequestBaseURL(ts.URL+"/v2"))
+	if err != nil || path != "/v2/messages" {
+		t.Fatalf("CreateMessagesStream went to %q: %v", path, err)
+	}
+
+	if _, err = client.CountTokens(ctx, request); err != nil {
+		t.Fatalf("CountTokens error: %v", err)
+	}
+	if header.Get("anthropic-beta") != string(anthropic.BetaTokenCounting20241101) ||
+		header.Get("X-Trace-Id") != "" || body["service_tier"] != nil {
+		t.Errorf("options leaked into a later call: %v %v", header, body)
+	}
+
+	_, err = client.RetrieveBatch(ctx, "batch_1",
+		anthropic.WithRequestHeader("anthropic-beta", "custom"))
+	if err != nil || header.Get("anthropic-beta") != "custom" {
+		t.Fatalf("unexpected beta header %q: %v", header.Get("anthropic-beta"), err)
+	}
+
+	_, err = client.CreateMessages(ctx, request,
+		anthropic.WithRequestBaseURL(ts.URL+"/v1/slow"),
+		anthropic.WithRequestTimeout(50*time.Millisecond),
+	)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+}
+
+func TestRequestOptionsSkipUploads(t *testing.T) {
+	var uploadBeta, messagesBeta string
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		uploadBeta = r.Header.Get("anthropic-beta")
+		if _, _, err := r.FormFile("file"); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"file_1","type":"file"}`))
+	})
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		messagesBeta = r.Header.Get("anthropic-beta")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+			`"content":[{"type":"text","text":"ok"}]}`))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithMaxRequestBytes(1_000),
+		anthropic.WithFilesAPIFallback(),
+	)
+	request := anthropic.MessagesRequest{
+		Model: anthropic.ModelClaudeSonnet4Dot5,
+		Messages: []anthropic.Message{{
+			Role: anthropic.RoleUser,
+			Content: []anthropic.MessageContent{anthropic.NewTextDocumentMessageContent(
+				strings.Repeat("text ", 1_000), "", "", false,
+			)},
+		}},
+		MaxTokens: 100,
+	}
+	_, err := client.CreateMessages(context.Background(), request,
+		anthropic.WithRequestHeader("anthropic-beta", "custom"),
+		anthropic.WithRequestHeader("Content-Type", "application/json"),
+		anthropic.WithExtraBody(map[string]any{"service_tier": "auto"}),
+	)
+	if err != nil {
+		t.Fatalf("CreateMessages error: %v", err)
+	}
+	if uploadBeta != string(anthropic.BetaFilesAPI20250414) || messagesBeta != "custom" {
+		t.Fatalf("unexpected beta headers: upload %q, messages %q", uploadBeta, messagesBeta)
+	}
+}
@@ -0,0 +1,361 @@
+package anthropic
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxMessagesRequestBytes is the Messages API limit on the serialized
+// request body.
+const MaxMessagesRequestBytes = 32 * 1024 * 1024
+
+// deleteUploadedFilesTimeout limits how long deleting the files uploaded for
+// an oversized request may take.
+const deleteUploadedFilesTimeout = 30 * time.Second
+
+// ErrRequestTooLarge is wrapped by RequestTooLargeError.
+var ErrRequestTooLarge = errors.New("request exceeds the size limit")
+
+// RequestBlockSize is the serialized size of one content block.
+type RequestBlockSize struct {
+	// Path locates the block, e.g. "messages[2].content[0]", or
+	// "messages[2].content[1].content[0]" for a block inside a tool result.
+	Path  string
+	Type  MessagesContentType
+	Bytes int64
+}
+
+// RequestTooLargeError is returned before a request is sent when its
+// serialized body is over the limit, instead of waiting for the API to
+// answer with ErrTypeTooLarge.
+type RequestTooLargeError struct {
+	Size  int64
+	Limit int64
+	// Largest holds up to three of the largest content blocks, largest first.
+	Largest []RequestBlockSize
+}
+
+func (e *RequestTooLargeError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s, limit %s", ErrRequestTooLarge,
+		formatBytes(e.Size), formatBytes(e.Limit))
+	for i, block := range e.Largest {
+		sep := ", "
+		if i == 0 {
+			sep = "; largest blocks: "
+		}
+		fmt.Fprintf(&b, "%s%s (%s, %s)", sep, block.Path, block.Type, formatBytes(block.Bytes))
+	}
+	return b.String()
+}
+
+func (e *RequestTooLargeError) Unwrap() error {
+	return ErrRequestTooLarge
+}
+
+func formatBytes(n int64) string {
+	if n < 1024*1024 {
+		return strconv.FormatInt(n, 10) + " bytes"
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1024*1024))
+}
+
+// serializedSize returns the length of data once every Base64Stream
+// placeholder is replaced by its encoding. Streams of unknown length count as
+// their placeholder.
+func serializedSize(v any, data []byte) int64 {
+	size := int64(len(data))
+	matches := base64StreamPattern.FindAllSubmatchIndex(data, -1)
+	if len(matches) == 0 {
+		return size
+	}
+
+	streams := make(map[uint64]*Base64Stream)
+	collectBase64Streams(reflect.ValueOf(v), streams, make(map[visitedPointer]bool))
+	for _, m := range matches {
+		id, _ := strconv.ParseUint(string(data[m[2]:m[3]]), 10, 64)
+		if s := streams[id]; s != nil {
+			if n, ok := s.encodedSize(); ok {
+				size += n + 2 - int64(m[1]-m[0])
+			}
+		}
+	}
+	return size
+}
+
+func jsonSize(v any) (int64, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	return serializedSize(v, data), nil
+}
+
+// requestBlock is a content block of a request, addressed so it can be
+// replaced in place.
+type requestBlock struct {
+	RequestBlockSize
+	content *MessageContent
+}
+
+// requestBlocks lists the blocks of messages. Tool results are listed as the
+// blocks they contain.
+func requestBlocks(messages []Message) ([]requestBlock, error) {
+	var blocks []requestBlock
+	var walk func(path string, content []MessageContent) error
+	walk = func(path string, content []MessageContent) error {
+		for i := range content {
+			c := &content[i]
+			p := fmt.Sprintf("%s.content[%d]", path, i)
+			if c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil &&
+				len(c.MessageContentToolResult.Content) > 0 {
+				if err := walk(p, c.MessageContentToolResult.Content); err != nil {
+					return err
+				}
+				continue
+			}
+			size, err := jsonSize(c)
+			if err != nil {
+				return err
+			}
+			blocks = append(blocks, requestBlock{
+				RequestBlockSize: RequestBlockSize{Path: p, Type: c.Type, Bytes: size},
+				content:          c,
+			})
+		}
+		return nil
+	}
+
+	for i, m := range messages {
+		if err := walk(fmt.Sprintf("messages[%d]", i), m.Content); err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortStableFunc(blocks, func(a, b requestBlock) int {
+		return cmp.Compare(b.Bytes, a.Bytes)
+	})
+	return blocks, nil
+}
+
+// cloneMessages copies messages deep enough that blocks, including those in
+// tool results, can be replaced without changing the caller's request.
+func cloneMessages(messages []Message) []Message {
+	messages = slices.Clone(messages)
+	for i := range messages {
+		content := slices.Clone(messages[i].Content)
+		for j, c := range content {
+			if c.Type == MessagesContentTypeToolResult && c.MessageContentToolResult != nil {
+				result := *c.MessageContentToolResult
+				result.Content = slices.Clone(result.Content)
+				content[j].MessageContentToolResult = &result
+			}
+		}
+		messages[i].Content = content
+	}
+	return messages
+}
+
+// filesAPIFallback reports whether oversized requests may upload to the Files
+// API, which Vertex AI does not have.
+func (c *ClientConfig) filesAPIFallback() bool {
+	_, vertex := c.Adapter.(*VertexAdapter)
+	return c.FilesAPIFallback && !vertex
+}
+
+func (c *ClientConfig) maxRequestBytes() int64 {
+	if c.MaxRequestBytes == 0 {
+		return MaxMessagesRequestBytes
+	}
+	return c.MaxRequestBytes
+}
+
+// newMessagesRequest builds a Messages API request for body, which holds
+// request. The body is marshaled once and its size checked against the
+// configured limit. With the Files API fallback, the largest inline documents
+// and images are uploaded until the request fits, and the request is
+// marshaled again with file sources, which need the Files API beta. The IDs
+// of the uploaded files are returned, also on error, so that the caller can
+// delete them once the call is done.
+func (c *Client) newMessagesRequest(
+	ctx context.Context,
+	body any,
+	request *MessagesRequest,
+) (*http.Request, []FileId, error) {
+	fullURL, data, err := c.prepareRequestBody(http.MethodPost, "/messages", body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uploaded, err := c.fitRequestSize(ctx, request, serializedSize(body, data))
+	if err != nil {
+		return nil, uploaded, err
+	}
+
+	setters := c.betaSetters()
+	if len(uploaded) > 0 {
+		setters = c.betaSetters(BetaFilesAPI20250414)
+		if data, err = c.marshalRequestBody(body); err != nil {
+			return nil, uploaded, err
+		}
+	}
+
+	req, err := c.newRequestWithBody(ctx, http.MethodPost, fullURL, body, data, setters...)
+	return req, uploaded, err
+}
+
+// fitRequestSize checks size, the serialized size of the request holding
+// request, against the configured limit. With the Files API fallback, it
+// uploads the largest inline documents and images of request until it fits,
+// and returns the IDs of the uploaded files.
+func (c *Client) fitRequestSize(
+	ctx context.Context,
+	request *MessagesRequest,
+	size int64,
+) ([]FileId, error) {
+	limit := c.config.maxRequestBytes()
+	if limit < 0 || size <= limit {
+		return nil, nil
+	}
+
+	fallback := c.config.filesAPIFallback()
+	if fallback {
+		request.Messages = cloneMessages(request.Messages)
+	}
+	blocks, err := requestBlocks(request.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	var uploaded []FileId
+	if fallback {
+		for _, block := range blocks {
+			if size <= limit {
+				break
+			}
+			if !uploadable(block.content) {
+				continue
+			}
+			fileBlock, err := c.uploadBlock(ctx, *block.content, block.Path)
+			if err != nil {
+				return uploaded, fmt.Errorf("error, uploading %s: %w", block.Path, err)
+			}
+			uploaded = append(uploaded, fileBlock.Source.FileID)
+			newSize, err := jsonSize(fileBlock)
+			if err != nil {
+				return uploaded, err
+			}
+			*block.content = fileBlock
+			size -= block.Bytes - newSize
+		}
+		if size <= limit {
+			return uploaded, nil
+		}
+		if blocks, err = requestBlocks(request.Messages); err != nil {
+			return uploaded, err
+		}
+	}
+
+	largest := make([]RequestBlockSize, 0, 3)
+	for _, block := range blocks[:min(3, len(blocks))] {
+		largest = append(largest, block.RequestBlockSize)
+	}
+	return uploaded, &RequestTooLargeError{Size: size, Limit: limit, Largest: largest}
+}
+
+// deleteUploadedFiles deletes the files that newMessagesRequest uploaded once
+// the call is done, even when its context was canceled. It is best effort:
+// the request no longer needs them, and the caller's request still holds the
+// inline data.
+func (c *Client) deleteUploadedFiles(ctx context.Context, ids []FileId) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deleteUploadedFilesTimeout)
+	defer cancel()
+	client := c.withoutCallOptions()
+	for _, id := range ids {
+		_, _ = client.DeleteFile(ctx, id)
+	}
+}
+
+// uploadable reports whether the block carries inline data that the Files
+// API can hold instead.
+func uploadable(c *MessageContent) bool {
+	if c.Source == nil ||
+		(c.Type != MessagesContentTypeDocument && c.Type != MessagesContentTypeImage) {
+		return false
+	}
+
+	switch c.Source.Type {
+	case MessagesContentSourceTypeBase64:
+		switch c.Source.Data.(type) {
+		case string, *Base64Stream:
+			return true
+		}
+	case MessagesContentSourceTypeText:
+		_, ok := c.Source.Data.(string)
+		return ok
+	}
+	return false
+}
+
+// uploadBlock uploads the inline data of block and returns the block with a
+// file source in its place.
+func (c *Client) uploadBlock(
+	ctx context.Context,
+	block MessageContent,
+	path string,
+) (MessageContent, error) {
+	var r io.Reader
+	switch data := block.Source.Data.(type) {
+	case string:
+		r = strings.NewReader(data)
+		if block.Source.Type == MessagesContentSourceTypeBase64 {
+			r = base64.NewDecoder(base64.StdEncoding, r)
+		}
+	case *Base64Stream:
+		rc, err := data.open()
+		if err != nil {
+			return block, err
+		}
+		defer rc.Close()
+		r = rc
+	}
+
+	mediaType := block.Source.MediaType
+	if mediaType == "" {
+		mediaType = "text/plain"
+	}
+	filename := strings.NewReplacer("[", "-", "]", "", ".", "-").Replace(path)
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		filename += exts[0]
+	}
+
+	resp, err := c.withoutCallOptions().UploadFile(ctx, UploadFileRequest{
+		Filename: filename,
+		MimeType: mediaType,
+		File:     r,
+	})
+	if err != nil {
+		return block, err
+	}
+
+	block.Source = &MessageContentSource{
+		Type:   MessagesContentSourceTypeFile,
+		FileID: resp.ID,
+	}
+	return block, nil
+}
@@ -0,0 +1,162 @@
+package anthropic_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestRequestSizePreflight(t *testing.T) {
+	var uploads, deleted []string
+	var sent []byte
+	var sentBeta string
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/files", func(w http.ResponseWriter, r *http.Request) {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		content, _ := io.ReadAll(file)
+		uploads = append(uploads, header.Filename+":"+string(content))
+		_, _ = w.Write([]byte(`{"id":"file_1","type":"file"}`))
+	})
+	server.RegisterHandler("/v1/files/file_1", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleted = append(deleted, "file_1")
+		}
+		_, _ = w.Write([]byte(`{"id":"file_1","type":"file_deleted"}`))
+	})
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, r *http.Request) {
+		sent, _ = io.ReadAll(r.Body)
+		sentBeta = r.Header.Get("anthropic-beta")
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+			`"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	newClient := func(opts ...anthropic.ClientOption) *anthropic.Client {
+		opts = append([]anthropic.ClientOption{
+			anthropic.WithBaseURL(ts.URL + "/v1"),
+			anthropic.WithMaxRequestBytes(10_000),
+		}, opts...)
+		return anthropic.NewClient(test.GetTestToken(), opts...)
+	}
+
+	pdf := strings.Repeat("%PDF", 4_000)
+	request := anthropic.MessagesRequest{
+		Model: anthropic.ModelClaudeSonnet4Dot5,
+		Messages: []anthropic.Message{{
+			Role: anthropic.RoleUser,
+			Content: []anthropic.MessageContent{
+				anthropic.NewTextMessageContent("summarize"),
+				anthropic.NewPDFDocumentMessageContent(
+					base64.StdEncoding.EncodeToString([]byte(pdf)),
+					"report",
+					"",
+					false,
+				),
+			},
+		}},
+		MaxTokens: 100,
+	}
+	ctx := context.Background()
+
+	t.Run("fails fast", func(t *testing.T) {
+		sent = nil
+		_, err := newClient().CreateMessages(ctx, request)
+		var tooLarge *anthropic.RequestTooLargeError
+		if !errors.As(err, &tooLarge) || !errors.Is(err, anthropic.ErrRequestTooLarge) {
+			t.Fatalf("expected RequestTooLargeError, got %v", err)
+		}
+		if tooLarge.Limit != 10_000 || len(tooLarge.Largest) != 2 ||
+			tooLarge.Largest[0].Path != "messages[0].content[1]" ||
+			tooLarge.Largest[0].Type != anthropic.MessagesContentTypeDocument {
+			t.Fatalf("unexpected error %+v", tooLarge)
+		}
+		if sent != nil {
+			t.Fatal("the request was sent")
+		}
+	})
+
+	t.Run("counts streamed files", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "report.pdf")
+		if err := os.WriteFile(path, []byte(pdf), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		streamed := request
+		streamed.Messages = []anthropic.Message{{
+			Role: anthropic.RoleUser,
+			Content: []anthropic.MessageContent{anthropic.NewDocumentMessageContent(
+				anthropic.NewBase64FileSource("application/pdf", path), "", "", false,
+			)},
+		}}
+		_, err := newClient().CreateMessagesStream(ctx, anthropic.MessagesStreamRequest{
+			MessagesRequest: streamed,
+		})
+		if !errors.Is(err, anthropic.ErrRequestTooLarge) {
+			t.Fatalf("expected ErrRequestTooLarge, got %v", err)
+		}
+	})
+
+	t.Run("uploads to the Files API", func(t *testing.T) {
+		client := newClient(anthropic.WithFilesAPIFallback())
+		if _, err := client.CreateMessages(ctx, request); err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		if len(uploads) != 1 || uploads[0] != "messages-0-content-1.pdf:"+pdf {
+			t.Fatalf("unexpected uploads %.40q", uploads)
+		}
+		if !bytes.Contains(sent, []byte(`"source":{"type":"file","file_id":"file_1"}`)) ||
+			!bytes.Contains(sent, []byte(`"title":"report"`)) {
+			t.Fatalf("unexpected request %s", sent)
+		}
+		if sentBeta != string(anthropic.BetaFilesAPI20250414) {
+			t.Fatalf("unexpected beta header %q", sentBeta)
+		}
+		if request.Messages[0].Content[1].Source.Type != anthropic.MessagesContentSourceTypeBase64 {
+			t.Fatal("the caller's request was changed")
+		}
+		if len(deleted) != 1 {
+			t.Fatalf("the uploaded file was not deleted: %v", deleted)
+		}
+	})
+
+	t.Run("no Files API on Vertex AI", func(t *testing.T) {
+		uploads = nil
+		client := newClient(
+			anthropic.WithVertexAI("project", "us-east5"),
+			anthropic.WithBaseURL(ts.URL+"/v1"),
+			anthropic.WithFilesAPIFallback(),
+		)
+		_, err := client.CreateMessages(ctx, request)
+		if !errors.Is(err, anthropic.ErrRequestTooLarge) || len(uploads) != 0 {
+			t.Fatalf("expected ErrRequestTooLarge without uploads, got %v, %d uploads",
+				err, len(uploads))
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		client := newClient(anthropic.WithMaxRequestBytes(-1))
+		if _, err := client.CreateMessages(ctx, request); err != nil {
+			t.Fatalf("CreateMessages error: %v", err)
+		}
+		var body struct{ Messages []anthropic.Message }
+		if err := json.Unmarshal(sent, &body); err != nil || len(body.Messages) != 1 {
+			t.Fatalf("unexpected request %.100s, %v", sent, err)
+		}
+	})
+}
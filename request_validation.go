@@ -0,0 +1,165 @@
+package anthropic
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidRequest is wrapped by every RequestValidationError.
+var ErrInvalidRequest = errors.New("invalid request")
+
+// RequestValidationError is one problem found by MessagesRequest.Validate.
+type RequestValidationError struct {
+	// Path is the JSON path of the offending value, e.g.
+	// "messages[2].content[0].tool_use_id". It is empty for problems that
+	// concern the request as a whole.
+	Path    string
+	Message string
+	// Err is a more specific cause, such as ErrInvalidCacheControl.
+	Err error
+}
+
+func (e *RequestValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", ErrInvalidRequest, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidRequest, e.Path, e.Message)
+}
+
+func (e *RequestValidationError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrInvalidRequest}
+	}
+	return []error{ErrInvalidRequest, e.Err}
+}
+
+// Validate checks the request for mistakes the API would reject with a 400:
+// missing fields, misordered or empty messages, tool results without a
+// matching tool use, tool and tool_choice mismatches, thinking settings and
+// cache_control breakpoints. It returns every problem found, joined, each a
+// *RequestValidationError; use errors.As or errors.Is(err, ErrInvalidRequest).
+func (m MessagesRequest) Validate() error {
+	return m.validate(nil)
+}
+
+// validate is Validate for a client that sends betas, which relax some rules.
+func (m MessagesRequest) validate(betas []BetaVersion) error {
+	var problems []*RequestValidationError
+	problem := func(path, format string, args ...any) {
+		problems = append(problems, &RequestValidationError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if m.Model == "" {
+		problem("model", "is required")
+	}
+	if m.MaxTokens < 1 {
+		problem("max_tokens", "must be at least 1, got %d", m.MaxTokens)
+	}
+
+	if len(m.Messages) == 0 {
+		problem("messages", "at least one message is required")
+	} else if m.Messages[0].Role != RoleUser {
+		problem("messages[0].role", "the first message must be from the user, got %q",
+			m.Messages[0].Role)
+	}
+	for i, msg := range m.Messages {
+		at := fmt.Sprintf("messages[%d]", i)
+		if msg.Role != RoleUser && msg.Role != RoleAssistant {
+			problem(at+".role", "must be %q or %q, got %q", RoleUser, RoleAssistant, msg.Role)
+		} else if i > 0 && msg.Role == m.Messages[i-1].Role {
+			problem(at+".role", "roles must alternate, but messages[%d] is also from the %s",
+				i-1, msg.Role)
+		}
+		if len(msg.Content) == 0 {
+			problem(at+".content", "must not be empty")
+		}
+
+		var toolUses []string
+		if i > 0 && m.Messages[i-1].Role == RoleAssistant {
+			for _, c := range m.Messages[i-1].Content {
+				if c.Type == MessagesContentTypeToolUse && c.MessageContentToolUse != nil {
+					toolUses = append(toolUses, c.MessageContentToolUse.ID)
+				}
+			}
+		}
+		for j, c := range msg.Content {
+			cat := fmt.Sprintf("%s.content[%d]", at, j)
+			switch c.Type {
+			case MessagesContentTypeText:
+				if c.Text == nil || strings.TrimSpace(*c.Text) == "" {
+					problem(cat+".text", "text blocks must contain non-whitespace text")
+				}
+			case MessagesContentTypeToolResult:
+				if msg.Role != RoleUser {
+					problem(cat, "tool_result blocks must be in a user message")
+					continue
+				}
+				id := ""
+				if result := c.MessageContentToolResult; result != nil && result.ToolUseID != nil {
+					id = *result.ToolUseID
+				}
+				if !slices.Contains(toolUses, id) {
+					problem(cat+".tool_use_id",
+						"%q does not match a tool_use block in the previous assistant message", id)
+				}
+			}
+		}
+	}
+
+	if m.Thinking != nil && m.Thinking.Type != ThinkingTypeDisabled {
+		if m.Thinking.Type == ThinkingTypeEnabled {
+			budget := m.Thinking.BudgetTokens
+			if budget < MinThinkingBudgetTokens {
+				problem("thinking.budget_tokens", "must be at least %d, got %d",
+					MinThinkingBudgetTokens, budget)
+			}
+			if budget >= m.MaxTokens && !slices.Contains(betas, BetaInterleavedThinking20250514) {
+				problem("thinking.budget_tokens", "must be less than max_tokens (%d), got %d",
+					m.MaxTokens, budget)
+			}
+		}
+		if m.Temperature != nil && *m.Temperature != 1 {
+			problem("temperature", "cannot be changed when thinking is enabled")
+		}
+		if m.TopK != nil {
+			problem("top_k", "cannot be set when thinking is enabled")
+		}
+		if m.TopP != nil && *m.TopP < 0.95 {
+			problem("top_p", "must be between 0.95 and 1 when thinking is enabled")
+		}
+	}
+
+	names := make(map[string]int, len(m.Tools))
+	for i, tool := range m.Tools {
+		at := fmt.Sprintf("tools[%d].name", i)
+		if tool.Name == "" {
+			problem(at, "is required")
+		} else if first, ok := names[tool.Name]; ok {
+			problem(at, "%q is already used by tools[%d]", tool.Name, first)
+		} else {
+			names[tool.Name] = i
+		}
+	}
+	if tc := m.ToolChoice; tc != nil {
+		if (tc.Type == ToolChoiceTypeAny || tc.Type == ToolChoiceTypeTool) && len(m.Tools) == 0 {
+			problem("tool_choice.type", "%q requires tools", tc.Type)
+		} else if tc.Type == ToolChoiceTypeTool {
+			if _, ok := names[tc.Name]; !ok {
+				problem("tool_choice.name", "%q is not one of the tools", tc.Name)
+			}
+		}
+	}
+
+	problems = append(problems, cacheControlProblems(m)...)
+
+	errs := make([]error, len(problems))
+	for i, p := range problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
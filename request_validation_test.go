@@ -0,0 +1,134 @@
+package anthropic_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+func TestMessagesRequestValidate(t *testing.T) {
+	valid := anthropic.MessagesRequest{
+		Model: anthropic.ModelClaudeSonnet4Dot5,
+		Messages: []anthropic.Message{
+			anthropic.NewUserTextMessage("What is the weather?"),
+			{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{
+				anthropic.NewToolUseMessageContent("toolu_1", "get_weather", []byte(`{}`)),
+			}},
+			anthropic.NewToolResultsMessage("toolu_1", "sunny", false),
+		},
+		MaxTokens:  2048,
+		Tools:      []anthropic.ToolDefinition{{Name: "get_weather"}},
+		ToolChoice: &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeTool, Name: "get_weather"},
+		Thinking:   &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: 1024},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	invalid := anthropic.MessagesRequest{
+		Model: anthropic.ModelClaudeSonnet4Dot5,
+		Messages: []anthropic.Message{
+			anthropic.NewAssistantTextMessage("Hi"),
+			anthropic.NewAssistantTextMessage(" "),
+			anthropic.NewToolResultsMessage("toolu_2", "sunny", false),
+			{Role: anthropic.RoleUser},
+		},
+		MaxTokens:   1024,
+		Temperature: toPtr(float32(0.5)),
+		Tools:       []anthropic.ToolDefinition{{Name: "get_weather"}, {Name: "get_weather"}},
+		ToolChoice:  &anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeTool, Name: "search"},
+		Thinking:    &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: 1024},
+	}
+	for i := range invalid.Messages[:3] {
+		invalid.Messages[i].Content[0].SetCacheControl()
+	}
+	invalid.CacheControl = &anthropic.MessageCacheControl{Type: anthropic.CacheControlTypeEphemeral}
+	invalid.Tools[0].CacheControl = &anthropic.MessageCacheControl{
+		Type: anthropic.CacheControlTypeEphemeral,
+	}
+
+	err := invalid.Validate()
+	if !errors.Is(err, anthropic.ErrInvalidRequest) {
+		t.Fatalf("expected ErrInvalidRequest, got %v", err)
+	}
+	if !errors.Is(err, anthropic.ErrInvalidCacheControl) {
+		t.Fatalf("expected ErrInvalidCacheControl, got %v", err)
+	}
+
+	var got []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var verr *anthropic.RequestValidationError
+		if !errors.As(e, &verr) {
+			t.Fatalf("unexpected error type %T", e)
+		}
+		got = append(got, verr.Path)
+	}
+	want := []string{
+		"messages[0].role",
+		"messages[1].role",
+		"messages[1].content[0].text",
+		"messages[2].content[0].tool_use_id",
+		"messages[3].role",
+		"messages[3].content",
+		"thinking.budget_tokens",
+		"temperature",
+		"tools[1].name",
+		"tool_choice.name",
+		"",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got problems %q, want %q\n%v", got, want, err)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("problem %d: got path %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWithRequestValidation(t *testing.T) {
+	requests := 0
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant",` +
+			`"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	})
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	defer ts.Close()
+
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaudeSonnet4Dot5,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("hi")},
+		MaxTokens: 2000,
+		Thinking:  &anthropic.Thinking{Type: anthropic.ThinkingTypeEnabled, BudgetTokens: 1500},
+		TopK:      toPtr(5),
+	}
+
+	client := anthropic.NewClient(
+		test.GetTestToken(),
+		anthropic.WithBaseURL(ts.URL+"/v1"),
+		anthropic.WithRequestValidation(),
+	)
+	_, err := client.CreateMessagesStream(context.Background(), anthropic.MessagesStreamRequest{
+		MessagesRequest: request,
+	})
+	var verr *anthropic.RequestValidationError
+	if !errors.As(err, &verr) || verr.Path != "top_k" || requests != 0 {
+		t.Fatalf("expected a top_k validation error before sending, got %v", err)
+	}
+
+	request.TopK = nil
+	client = anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+	if _, err := client.CreateMessages(context.Background(), request); err != nil {
+		t.Fatalf("CreateMessages error: %v", err)
+	}
+	if requests != 1 {
+		t.Fatal("the request was not sent")
+	}
+}
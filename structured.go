@@ -0,0 +1,271 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/liushuangls/go-anthropic/v2/jsonschema"
+)
+
+var (
+	// ErrStructuredOutputRefusal reports that the model stopped with the
+	// refusal stop reason instead of producing output.
+	ErrStructuredOutputRefusal = errors.New("the model refused to produce structured output")
+	// ErrStructuredOutputTruncated reports that max_tokens cut the output short.
+	ErrStructuredOutputTruncated = errors.New("structured output was truncated by max_tokens")
+	// ErrStructuredOutputInvalid reports output that does not decode into the
+	// requested type.
+	ErrStructuredOutputInvalid = errors.New("structured output is not valid JSON for the type")
+)
+
+// StructuredOutputError is returned by CreateStructured and
+// CreateStructuredStream when a response arrives but cannot be decoded. Use
+// errors.Is with the ErrStructuredOutput* sentinels to tell the cases apart.
+type StructuredOutputError struct {
+	// Err is one of the ErrStructuredOutput* sentinels.
+	Err error
+	// Cause is the decoding error for ErrStructuredOutputInvalid.
+	Cause error
+	// Text is the raw text the model produced.
+	Text     string
+	Response MessagesResponse
+}
+
+func (e *StructuredOutputError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s", e.Err, e.Cause)
+	}
+	return e.Err.Error()
+}
+
+func (e *StructuredOutputError) Unwrap() []error {
+	if e.Cause != nil {
+		return []error{e.Err, e.Cause}
+	}
+	return []error{e.Err}
+}
+
+// NewOutputFormatForType derives a JSON schema from the Go type of v, see
+// jsonschema.GenerateSchemaForType, and returns it as a json_schema format.
+func NewOutputFormatForType(v any) (*OutputFormat, error) {
+	schema, err := jsonschema.GenerateSchemaForType(v)
+	if err != nil {
+		return nil, err
+	}
+	return &OutputFormat{Type: OutputFormatJsonSchema, Schema: schema}, nil
+}
+
+// CreateStructured sends the request with output_config.format set to the
+// schema of T and decodes the response text into T. A format already set on
+// the request is left untouched, so callers can supply their own schema.
+func CreateStructured[T any](
+	ctx context.Context,
+	client *Client,
+	request MessagesRequest,
+) (T, MessagesResponse, error) {
+	var out T
+
+	request, err := withStructuredOutputFormat[T](request)
+	if err != nil {
+		return out, MessagesResponse{}, err
+	}
+
+	resp, err := client.CreateMessages(ctx, request)
+	if err != nil {
+		return out, resp, err
+	}
+
+	out, err = decodeStructuredOutput[T](resp)
+	return out, resp, err
+}
+
+// CreateStructuredStream is the streaming variant of CreateStructured.
+// onPartial, if set, is called with a best-effort decoding of the output so
+// far each time a text delta arrives; fields not yet streamed hold their
+// zero values. The request's own callbacks are still invoked.
+func CreateStructuredStream[T any](
+	ctx context.Context,
+	client *Client,
+	request MessagesStreamRequest,
+	onPartial func(T),
+) (T, MessagesResponse, error) {
+	var out T
+
+	var err error
+	request.MessagesRequest, err = withStructuredOutputFormat[T](request.MessagesRequest)
+	if err != nil {
+		return out, MessagesResponse{}, err
+	}
+
+	if onPartial != nil {
+		var text strings.Builder
+		var last string
+		onDelta := request.OnContentBlockDelta
+		request.OnContentBlockDelta = func(d MessagesEventContentBlockDeltaData) {
+			if onDelta != nil {
+				onDelta(d)
+			}
+			if d.Delta.Type != MessagesContentTypeTextDelta {
+				return
+			}
+			text.WriteString(d.Delta.GetText())
+
+			completed, ok := completePartialJSON(text.String())
+			if !ok || completed == last {
+				return
+			}
+			var partial T
+			if json.Unmarshal([]byte(completed), &partial) == nil {
+				last = completed
+				onPartial(partial)
+			}
+		}
+	}
+
+	resp, err := client.CreateMessagesStream(ctx, request)
+	if err != nil {
+		return out, resp, err
+	}
+
+	out, err = decodeStructuredOutput[T](resp)
+	return out, resp, err
+}
+
+func withStructuredOutputFormat[T any](request MessagesRequest) (MessagesRequest, error) {
+	if request.OutputConfig != nil && request.OutputConfig.Format != nil {
+		return request, nil
+	}
+
+	var zero T
+	format, err := NewOutputFormatForType(zero)
+	if err != nil {
+		return request, fmt.Errorf("error, deriving output schema: %w", err)
+	}
+
+	config := OutputConfig{}
+	if request.OutputConfig != nil {
+		config = *request.OutputConfig
+	}
+	config.Format = format
+	request.OutputConfig = &config
+	return request, nil
+}
+
+func decodeStructuredOutput[T any](resp MessagesResponse) (T, error) {
+	var out T
+
+	var text strings.Builder
+	for _, c := range resp.Content {
+		if c.Type == MessagesContentTypeText {
+			text.WriteString(c.GetText())
+		}
+	}
+
+	fail := func(sentinel, cause error) error {
+		return &StructuredOutputError{
+			Err:      sentinel,
+			Cause:    cause,
+			Text:     text.String(),
+			Response: resp,
+		}
+	}
+
+	switch resp.StopReason {
+	case MessagesStopRefusal:
+		return out, fail(ErrStructuredOutputRefusal, nil)
+	case MessagesStopReasonMaxTokens:
+		return out, fail(ErrStructuredOutputTruncated, nil)
+	}
+
+	if err := json.Unmarshal([]byte(text.String()), &out); err != nil {
+		return out, fail(ErrStructuredOutputInvalid, err)
+	}
+	return out, nil
+}
+
+// completePartialJSON closes the open strings, arrays and objects of a JSON
+// prefix so that it can be decoded. When the prefix ends inside a value that
+// cannot be closed, such as a literal or an object key, it falls back to the
+// last complete element.
+func completePartialJSON(s string) (string, bool) {
+	if strings.TrimSpace(s) == "" {
+		return "", false
+	}
+
+	candidate := closeJSONPrefix(s)
+	if json.Valid([]byte(candidate)) {
+		return candidate, true
+	}
+
+	_, _, _, cuts := scanJSONPrefix(s)
+	for i := len(cuts) - 1; i >= 0 && i >= len(cuts)-8; i-- {
+		candidate = closeJSONPrefix(s[:cuts[i]])
+		if json.Valid([]byte(candidate)) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func closeJSONPrefix(prefix string) string {
+	closers, inString, escaped, _ := scanJSONPrefix(prefix)
+	if inString {
+		if escaped {
+			// Drop the dangling backslash.
+			prefix = prefix[:len(prefix)-1]
+		}
+		prefix += `"`
+	}
+
+	prefix = strings.TrimRight(prefix, " \t\r\n")
+	if strings.HasSuffix(prefix, ":") {
+		prefix += "null"
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i := len(closers) - 1; i >= 0; i-- {
+		b.WriteByte(closers[i])
+	}
+	return b.String()
+}
+
+// scanJSONPrefix returns the closing brackets still needed by a JSON prefix,
+// whether it ends inside a string (right after a backslash), and the prefix
+// lengths after which the document can be closed.
+func scanJSONPrefix(s string) (closers []byte, inString, escaped bool, cuts []int) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+			cuts = append(cuts, i+1)
+		case '[':
+			closers = append(closers, ']')
+			cuts = append(cuts, i+1)
+		case '}', ']':
+			if len(closers) > 0 {
+				closers = closers[:len(closers)-1]
+			}
+		case ',':
+			cuts = append(cuts, i)
+		}
+	}
+	return closers, inString, escaped, cuts
+}
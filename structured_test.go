@@ -0,0 +1,173 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+	"github.com/liushuangls/go-anthropic/v2/internal/test"
+)
+
+type structuredContact struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func newStructuredTestClient(t *testing.T, handler test.Handler) *anthropic.Client {
+	t.Helper()
+	server := test.NewTestServer()
+	server.RegisterHandler("/v1/messages", handler)
+	ts := server.AnthropicTestServer()
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	return anthropic.NewClient(test.GetTestToken(), anthropic.WithBaseURL(ts.URL+"/v1"))
+}
+
+func structuredResponseHandler(stopReason, text string) test.Handler {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var config struct {
+			Format struct {
+				Type   string          `json:"type"`
+				Schema json.RawMessage `json:"schema"`
+			} `json:"format"`
+		}
+		_ = json.Unmarshal(req["output_config"], &config)
+		if config.Format.Type != "json_schema" || len(config.Format.Schema) == 0 {
+			http.Error(w, "missing output_config.format", http.StatusBadRequest)
+			return
+		}
+
+		textJSON, _ := json.Marshal(text)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant",`+
+			`"content":[{"type":"text","text":%s}],"stop_reason":%q,`+
+			`"usage":{"input_tokens":1,"output_tokens":1}}`, textJSON, stopReason)
+	}
+}
+
+func TestCreateStructured(t *testing.T) {
+	request := anthropic.MessagesRequest{
+		Model:     anthropic.ModelClaude3Haiku20240307,
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Extract the contact")},
+		MaxTokens: 1000,
+	}
+
+	t.Run("decodes the output", func(t *testing.T) {
+		client := newStructuredTestClient(t, structuredResponseHandler(
+			"end_turn",
+			`{"name":"John","email":"john@example.com","tags":["lead"]}`,
+		))
+		out, resp, err := anthropic.CreateStructured[structuredContact](
+			context.Background(),
+			client,
+			request,
+		)
+		if err != nil {
+			t.Fatalf("CreateStructured error: %v", err)
+		}
+		if out.Name != "John" || out.Email != "john@example.com" || len(out.Tags) != 1 {
+			t.Fatalf("unexpected output %+v", out)
+		}
+		if resp.ID != "msg_1" {
+			t.Fatalf("unexpected response %+v", resp)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		stopReason string
+		text       string
+		want       error
+	}{
+		{"refusal", "refusal", "", anthropic.ErrStructuredOutputRefusal},
+		{"truncated", "max_tokens", `{"name":"Jo`, anthropic.ErrStructuredOutputTruncated},
+		{"invalid", "end_turn", `{"name":1}`, anthropic.ErrStructuredOutputInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newStructuredTestClient(t, structuredResponseHandler(tt.stopReason, tt.text))
+			_, _, err := anthropic.CreateStructured[structuredContact](
+				context.Background(),
+				client,
+				request,
+			)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got error %v, want %v", err, tt.want)
+			}
+			var structuredErr *anthropic.StructuredOutputError
+			if !errors.As(err, &structuredErr) || structuredErr.Text != tt.text {
+				t.Fatalf("expected a StructuredOutputError with the raw text, got %#v", err)
+			}
+		})
+	}
+}
+
+func TestCreateStructuredStream(t *testing.T) {
+	chunks := []string{`{"name":"Jo`, `hn","ema`, `il":"j@x.io","tags":["a`, `","b"]}`}
+
+	client := newStructuredTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		write := func(event, data string) {
+			_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		}
+		write("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message",`+
+			`"role":"assistant","content":[],"usage":{"input_tokens":1,"output_tokens":1}}}`)
+		write("content_block_start",
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		for _, chunk := range chunks {
+			text, _ := json.Marshal(chunk)
+			write("content_block_delta", fmt.Sprintf(
+				`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%s}}`,
+				text,
+			))
+		}
+		write("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		write("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},`+
+			`"usage":{"output_tokens":10}}`)
+		write("message_stop", `{"type":"message_stop"}`)
+	})
+
+	var partials []structuredContact
+	deltas := 0
+	out, _, err := anthropic.CreateStructuredStream(
+		context.Background(),
+		client,
+		anthropic.MessagesStreamRequest{
+			MessagesRequest: anthropic.MessagesRequest{
+				Model:     anthropic.ModelClaude3Haiku20240307,
+				Messages:  []anthropic.Message{anthropic.NewUserTextMessage("Extract")},
+				MaxTokens: 1000,
+			},
+			OnContentBlockDelta: func(anthropic.MessagesEventContentBlockDeltaData) {
+				deltas++
+			},
+		},
+		func(partial structuredContact) {
+			partials = append(partials, partial)
+		},
+	)
+	if err != nil {
+		t.Fatalf("CreateStructuredStream error: %v", err)
+	}
+	if out.Name != "John" || out.Email != "j@x.io" || len(out.Tags) != 2 {
+		t.Fatalf("unexpected output %+v", out)
+	}
+	if deltas != len(chunks) {
+		t.Fatalf("the request callback was not kept: got %d deltas", deltas)
+	}
+
+	if len(partials) != len(chunks) {
+		t.Fatalf("got %d partials, want %d: %+v", len(partials), len(chunks), partials)
+	}
+	if partials[0].Name != "Jo" || partials[1].Email != "" || partials[2].Tags[0] != "a" {
+		t.Fatalf("unexpected partials %+v", partials)
+	}
+}
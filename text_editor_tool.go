@@ -0,0 +1,508 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	textEditorSnippetLines  = 4
+	defaultTextEditorMaxLen = 16000
+
+	textEditorClippedNotice = "<response clipped><NOTE>To save on context only part of this " +
+		"file has been shown to you. You should retry this tool after you have searched inside " +
+		"the file with `grep -n` in order to find the line numbers of what you are looking " +
+		"for.</NOTE>"
+)
+
+type TextEditorCommand string
+
+const (
+	TextEditorCommandView       TextEditorCommand = "view"
+	TextEditorCommandCreate     TextEditorCommand = "create"
+	TextEditorCommandStrReplace TextEditorCommand = "str_replace"
+	TextEditorCommandInsert     TextEditorCommand = "insert"
+	TextEditorCommandUndoEdit   TextEditorCommand = "undo_edit"
+)
+
+// TextEditorToolInput is the input of the Anthropic-defined text editor tool.
+// Only the fields relevant to Command are set.
+type TextEditorToolInput struct {
+	Command    TextEditorCommand `json:"command"`
+	Path       string            `json:"path"`
+	FileText   *string           `json:"file_text,omitempty"`
+	OldStr     *string           `json:"old_str,omitempty"`
+	NewStr     *string           `json:"new_str,omitempty"`
+	InsertLine *int              `json:"insert_line,omitempty"`
+	// InsertText is the text to insert for the insert command of the
+	// text_editor_20250728 tool; older versions send NewStr instead.
+	InsertText *string `json:"insert_text,omitempty"`
+	ViewRange  []int   `json:"view_range,omitempty"`
+}
+
+// TextEditor is a reference executor for the text editor tool. Every path
+// the model passes must be absolute and resolve inside Root; the editor never
+// reads or writes outside of it, including through symlinks.
+type TextEditor struct {
+	root   string
+	maxLen int
+
+	mu      sync.Mutex
+	history map[string][]string
+}
+
+type TextEditorOption func(e *TextEditor)
+
+// WithTextEditorMaxCharacters truncates view output to the given length,
+// mirroring the max_characters parameter of text_editor_20250728.
+func WithTextEditorMaxCharacters(maxCharacters int) TextEditorOption {
+	return func(e *TextEditor) {
+		e.maxLen = maxCharacters
+	}
+}
+
+// NewTextEditor creates an editor sandboxed to the root directory.
+func NewTextEditor(root string, opts ...TextEditorOption) (*TextEditor, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &TextEditor{
+		root:    resolved,
+		maxLen:  defaultTextEditorMaxLen,
+		history: make(map[string][]string),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e, nil
+}
+
+// Root returns the directory the editor is confined to.
+func (e *TextEditor) Root() string {
+	return e.root
+}
+
+// Execute runs a decoded text editor command and returns the text for its
+// tool_result. Error messages are worded the way the model expects them.
+func (e *TextEditor) Execute(ctx context.Context, input TextEditorToolInput) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	path, err := e.validatePath(input.Command, input.Path)
+	if err != nil {
+		return "", err
+	}
+
+	switch input.Command {
+	case TextEditorCommandView:
+		return e.view(input.Path, path, input.ViewRange)
+	case TextEditorCommandCreate:
+		if input.FileText == nil {
+			return "", errors.New("Parameter `file_text` is required for command: create")
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", fmt.Errorf("Ran into %s while trying to write to %s", err, input.Path)
+		}
+		if err := os.WriteFile(path, []byte(*input.FileText), 0o644); err != nil {
+			return "", fmt.Errorf("Ran into %s while trying to write to %s", err, input.Path)
+		}
+		return fmt.Sprintf("File created successfully at: %s", input.Path), nil
+	case TextEditorCommandStrReplace:
+		if input.OldStr == nil {
+			return "", errors.New("Parameter `old_str` is required for command: str_replace")
+		}
+		newStr := ""
+		if input.NewStr != nil {
+			newStr = *input.NewStr
+		}
+		return e.strReplace(input.Path, path, *input.OldStr, newStr)
+	case TextEditorCommandInsert:
+		if input.InsertLine == nil {
+			return "", errors.New("Parameter `insert_line` is required for command: insert")
+		}
+		text := input.NewStr
+		if input.InsertText != nil {
+			text = input.InsertText
+		}
+		if text == nil {
+			return "", errors.New("Parameter `new_str` is required for command: insert")
+		}
+		return e.insert(input.Path, path, *input.InsertLine, *text)
+	case TextEditorCommandUndoEdit:
+		return e.undoEdit(input.Path, path)
+	}
+
+	return "", fmt.Errorf(
+		"Unrecognized command %s. The allowed commands are: view, create, str_replace, insert, "+
+			"undo_edit",
+		input.Command,
+	)
+}
+
+// HandleToolUse executes a text editor tool_use block and returns its
+// tool_result.
+func (e *TextEditor) HandleToolUse(
+	ctx context.Context,
+	toolUse MessageContentToolUse,
+) MessageContent {
+	return textToolResult(toolUse, func(input json.RawMessage) (string, error) {
+		var in TextEditorToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return e.Execute(ctx, in)
+	})
+}
+
+// ToolFunc adapts the editor to a ToolFunc for use with a ToolExecutor.
+func (e *TextEditor) ToolFunc() ToolFunc {
+	return textToolFunc(func(ctx context.Context, input json.RawMessage) (string, error) {
+		var in TextEditorToolInput
+		if err := json.Unmarshal(input, &in); err != nil {
+			return "", err
+		}
+		return e.Execute(ctx, in)
+	})
+}
+
+// validatePath checks the model supplied path and maps it to a real path
+// inside the root.
+func (e *TextEditor) validatePath(command TextEditorCommand, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		suggested := filepath.Join(e.root, path)
+		return "", fmt.Errorf(
+			"The path %s is not an absolute path, it should start with `/`. Maybe you meant %s?",
+			path,
+			suggested,
+		)
+	}
+
+	resolved, err := resolveInRoot(e.root, path)
+	if err != nil {
+		return "", fmt.Errorf("The path %s is outside of the allowed directory %s", path, e.root)
+	}
+
+	info, statErr := os.Stat(resolved)
+	exists := statErr == nil
+	if !exists && command != TextEditorCommandCreate {
+		return "", fmt.Errorf("The path %s does not exist. Please provide a valid path.", path)
+	}
+	if exists && command == TextEditorCommandCreate {
+		return "", fmt.Errorf(
+			"File already exists at: %s. Cannot overwrite files using command `create`.",
+			path,
+		)
+	}
+	if exists && info.IsDir() && command != TextEditorCommandView {
+		return "", fmt.Errorf(
+			"The path %s is a directory and only the `view` command can be used on directories",
+			path,
+		)
+	}
+
+	return resolved, nil
+}
+
+// resolveInRoot cleans path, resolves symlinks of its existing ancestors and
+// fails if the result leaves root.
+func resolveInRoot(root, path string) (string, error) {
+	cleaned := filepath.Clean(path)
+
+	// Resolve the deepest existing ancestor so that symlinks cannot be used to
+	// escape the root; the missing tail is appended unchanged.
+	existing, tail := cleaned, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		tail = filepath.Join(filepath.Base(existing), tail)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	resolved = filepath.Join(resolved, tail)
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside of %s", path, root)
+	}
+	return resolved, nil
+}
+
+func (e *TextEditor) view(displayPath, path string, viewRange []int) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		if viewRange != nil {
+			return "", errors.New(
+				"The `view_range` parameter is not allowed when `path` points to a directory.",
+			)
+		}
+		return e.viewDir(displayPath, path)
+	}
+
+	content, err := e.readFile(displayPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	content, initLine, err := applyViewRange(content, viewRange)
+	if err != nil {
+		return "", err
+	}
+
+	return e.catN(content, displayPath, initLine), nil
+}
+
+// applyViewRange returns the lines of content selected by a [start, end]
+// view_range, with end -1 meaning the end of the file, and the number of the
+// first returned line.
+func applyViewRange(content string, viewRange []int) (string, int, error) {
+	if viewRange == nil {
+		return content, 1, nil
+	}
+	if len(viewRange) != 2 {
+		return "", 0, errors.New("Invalid `view_range`. It should be a list of two integers.")
+	}
+
+	lines := strings.Split(content, "\n")
+	nLines := len(lines)
+	start, end := viewRange[0], viewRange[1]
+	if start < 1 || start > nLines {
+		return "", 0, fmt.Errorf(
+			"Invalid `view_range`: %v. Its first element `%d` should be within the range of "+
+				"lines of the file: [1 %d]",
+			viewRange, start, nLines,
+		)
+	}
+	if end > nLines {
+		return "", 0, fmt.Errorf(
+			"Invalid `view_range`: %v. Its second element `%d` should be smaller than the "+
+				"number of lines in the file: `%d`",
+			viewRange, end, nLines,
+		)
+	}
+	if end != -1 && end < start {
+		return "", 0, fmt.Errorf(
+			"Invalid `view_range`: %v. Its second element `%d` should be larger or equal "+
+				"than its first `%d`",
+			viewRange, end, start,
+		)
+	}
+
+	if end == -1 {
+		return strings.Join(lines[start-1:], "\n"), start, nil
+	}
+	return strings.Join(lines[start-1:end], "\n"), start, nil
+}
+
+func (e *TextEditor) viewDir(displayPath, path string) (string, error) {
+	entries := []string{displayPath}
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if p == path {
+			return nil
+		}
+		rel, _ := filepath.Rel(path, p)
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		entries = append(entries, filepath.Join(displayPath, rel))
+		if d.IsDir() && strings.Count(rel, string(filepath.Separator)) >= 1 {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(entries[1:])
+
+	return fmt.Sprintf(
+		"Here's the files and directories up to 2 levels deep in %s, excluding hidden items:\n%s\n",
+		displayPath,
+		strings.Join(entries, "\n"),
+	), nil
+}
+
+func (e *TextEditor) strReplace(displayPath, path, oldStr, newStr string) (string, error) {
+	content, err := e.readFile(displayPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	switch occurrences := strings.Count(content, oldStr); {
+	case occurrences == 0 || oldStr == "":
+		return "", fmt.Errorf(
+			"No replacement was performed, old_str `%s` did not appear verbatim in %s.",
+			oldStr,
+			displayPath,
+		)
+	case occurrences > 1:
+		var lines []string
+		for i, line := range strings.Split(content, "\n") {
+			if strings.Contains(line, oldStr) {
+				lines = append(lines, fmt.Sprint(i+1))
+			}
+		}
+		return "", fmt.Errorf(
+			"No replacement was performed. Multiple occurrences of old_str `%s` in lines [%s]. "+
+				"Please ensure it is unique",
+			oldStr,
+			strings.Join(lines, ", "),
+		)
+	}
+
+	newContent := strings.Replace(content, oldStr, newStr, 1)
+	if err := e.writeFile(displayPath, path, content, newContent); err != nil {
+		return "", err
+	}
+
+	replacementLine := strings.Count(strings.SplitN(content, oldStr, 2)[0], "\n")
+	startLine := max(0, replacementLine-textEditorSnippetLines)
+	endLine := replacementLine + textEditorSnippetLines + strings.Count(newStr, "\n")
+	snippet := sliceLines(newContent, startLine, endLine+1)
+
+	return fmt.Sprintf(
+		"The file %s has been edited. %sReview the changes and make sure they are as expected. "+
+			"Edit the file again if necessary.",
+		displayPath,
+		e.catN(snippet, "a snippet of "+displayPath, startLine+1),
+	), nil
+}
+
+func (e *TextEditor) insert(displayPath, path string, insertLine int, text string) (string, error) {
+	content, err := e.readFile(displayPath, path)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(content, "\n")
+	if insertLine < 0 || insertLine > len(lines) {
+		return "", fmt.Errorf(
+			"Invalid `insert_line` parameter: %d. It should be within the range of lines of the "+
+				"file: [0, %d]",
+			insertLine,
+			len(lines),
+		)
+	}
+
+	textLines := strings.Split(text, "\n")
+	newLines := make([]string, 0, len(lines)+len(textLines))
+	newLines = append(newLines, lines[:insertLine]...)
+	newLines = append(newLines, textLines...)
+	newLines = append(newLines, lines[insertLine:]...)
+	newContent := strings.Join(newLines, "\n")
+
+	if err := e.writeFile(displayPath, path, content, newContent); err != nil {
+		return "", err
+	}
+
+	startLine := max(0, insertLine-textEditorSnippetLines)
+	endLine := insertLine + len(textLines) + textEditorSnippetLines
+	snippet := sliceLines(newContent, startLine, endLine)
+
+	return fmt.Sprintf(
+		"The file %s has been edited. %sReview the changes and make sure they are as expected "+
+			"(correct indentation, no duplicate lines, etc). Edit the file again if necessary.",
+		displayPath,
+		e.catN(snippet, "a snippet of the edited file", startLine+1),
+	), nil
+}
+
+func (e *TextEditor) undoEdit(displayPath, path string) (string, error) {
+	history := e.history[path]
+	if len(history) == 0 {
+		return "", fmt.Errorf("No edit history found for %s.", displayPath)
+	}
+
+	previous := history[len(history)-1]
+	if err := os.WriteFile(path, []byte(previous), 0o644); err != nil {
+		return "", fmt.Errorf("Ran into %s while trying to write to %s", err, displayPath)
+	}
+	e.history[path] = history[:len(history)-1]
+
+	return fmt.Sprintf(
+		"Last edit to %s undone successfully. %s",
+		displayPath,
+		e.catN(previous, displayPath, 1),
+	), nil
+}
+
+func (e *TextEditor) readFile(displayPath, path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Ran into %s while trying to read %s", err, displayPath)
+	}
+	return string(b), nil
+}
+
+func (e *TextEditor) writeFile(displayPath, path, oldContent, newContent string) error {
+	if err := os.WriteFile(path, []byte(newContent), 0o644); err != nil {
+		return fmt.Errorf("Ran into %s while trying to write to %s", err, displayPath)
+	}
+	e.history[path] = append(e.history[path], oldContent)
+	return nil
+}
+
+// catN renders content the way `cat -n` does, numbering lines from initLine.
+func (e *TextEditor) catN(content, descriptor string, initLine int) string {
+	return catN(content, descriptor, initLine, e.maxLen)
+}
+
+func catN(content, descriptor string, initLine, maxLen int) string {
+	content = truncateToolText(content, maxLen)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Here's the result of running `cat -n` on %s:\n", descriptor)
+	for i, line := range strings.Split(content, "\n") {
+		fmt.Fprintf(&b, "%6d\t%s\n", i+initLine, line)
+	}
+	return b.String()
+}
+
+func truncateToolText(s string, maxLen int) string {
+	if maxLen <= 0 || len(s) <= maxLen {
+		return s
+	}
+	return clipUTF8(s, maxLen) + textEditorClippedNotice
+}
+
+// sliceLines returns lines [start, end) of s, clamped to its bounds.
+func sliceLines(s string, start, end int) string {
+	lines := strings.Split(s, "\n")
+	start = min(max(start, 0), len(lines))
+	end = min(max(end, start), len(lines))
+	return strings.Join(lines[start:end], "\n")
+}
@@ -0,0 +1,240 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestTextEditor(t *testing.T) {
+	root := t.TempDir()
+	editor, err := anthropic.NewTextEditor(root)
+	if err != nil {
+		t.Fatalf("NewTextEditor error: %v", err)
+	}
+	root = editor.Root()
+	file := filepath.Join(root, "src", "main.py")
+	ctx := context.Background()
+
+	run := func(t *testing.T, input anthropic.TextEditorToolInput) string {
+		t.Helper()
+		out, err := editor.Execute(ctx, input)
+		if err != nil {
+			t.Fatalf("Execute(%s) error: %v", input.Command, err)
+		}
+		return out
+	}
+	runErr := func(t *testing.T, input anthropic.TextEditorToolInput, want string) {
+		t.Helper()
+		_, err := editor.Execute(ctx, input)
+		if err == nil || err.Error() != want {
+			t.Fatalf("got error %v, want %q", err, want)
+		}
+	}
+
+	t.Run("create", func(t *testing.T) {
+		out := run(t, anthropic.TextEditorToolInput{
+			Command:  anthropic.TextEditorCommandCreate,
+			Path:     file,
+			FileText: toPtr("def main():\n    print('hi')\n"),
+		})
+		if out != "File created successfully at: "+file {
+			t.Fatalf("unexpected output %q", out)
+		}
+
+		runErr(t, anthropic.TextEditorToolInput{
+			Command:  anthropic.TextEditorCommandCreate,
+			Path:     file,
+			FileText: toPtr(""),
+		}, "File already exists at: "+file+". Cannot overwrite files using command `create`.")
+	})
+
+	t.Run("view file", func(t *testing.T) {
+		out := run(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandView,
+			Path:    file,
+		})
+		want := "Here's the result of running `cat -n` on " + file + ":\n" +
+			"     1\tdef main():\n" +
+			"     2\t    print('hi')\n" +
+			"     3\t\n"
+		if out != want {
+			t.Fatalf("got %q, want %q", out, want)
+		}
+
+		out = run(t, anthropic.TextEditorToolInput{
+			Command:   anthropic.TextEditorCommandView,
+			Path:      file,
+			ViewRange: []int{2, -1},
+		})
+		if !strings.Contains(out, "     2\t    print('hi')\n") || strings.Contains(out, "def") {
+			t.Fatalf("unexpected ranged output %q", out)
+		}
+	})
+
+	t.Run("view directory", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(root, ".hidden"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		out := run(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandView,
+			Path:    root,
+		})
+		if !strings.Contains(out, filepath.Join(root, "src", "main.py")) ||
+			strings.Contains(out, ".hidden") {
+			t.Fatalf("unexpected directory listing %q", out)
+		}
+	})
+
+	t.Run("str_replace and undo", func(t *testing.T) {
+		runErr(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandStrReplace,
+			Path:    file,
+			OldStr:  toPtr("missing"),
+			NewStr:  toPtr("x"),
+		}, "No replacement was performed, old_str `missing` did not appear verbatim in "+file+".")
+
+		runErr(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandStrReplace,
+			Path:    file,
+			OldStr:  toPtr("i"),
+			NewStr:  toPtr("x"),
+		}, "No replacement was performed. Multiple occurrences of old_str `i` in lines [1, 2]. "+
+			"Please ensure it is unique")
+
+		out := run(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandStrReplace,
+			Path:    file,
+			OldStr:  toPtr("'hi'"),
+			NewStr:  toPtr("'hello'"),
+		})
+		if !strings.HasPrefix(out, "The file "+file+" has been edited.") ||
+			!strings.Contains(out, "print('hello')") {
+			t.Fatalf("unexpected output %q", out)
+		}
+
+		run(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandUndoEdit,
+			Path:    file,
+		})
+		b, _ := os.ReadFile(file)
+		if string(b) != "def main():\n    print('hi')\n" {
+			t.Fatalf("undo did not restore the file: %q", b)
+		}
+
+		runErr(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandUndoEdit,
+			Path:    file,
+		}, "No edit history found for "+file+".")
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		run(t, anthropic.TextEditorToolInput{
+			Command:    anthropic.TextEditorCommandInsert,
+			Path:       file,
+			InsertLine: toPtr(0),
+			InsertText: toPtr("import os"),
+		})
+		b, _ := os.ReadFile(file)
+		if !strings.HasPrefix(string(b), "import os\ndef main():") {
+			t.Fatalf("unexpected content %q", b)
+		}
+
+		runErr(t, anthropic.TextEditorToolInput{
+			Command:    anthropic.TextEditorCommandInsert,
+			Path:       file,
+			InsertLine: toPtr(99),
+			NewStr:     toPtr("x"),
+		}, "Invalid `insert_line` parameter: 99. It should be within the range of lines of the "+
+			"file: [0, 4]")
+	})
+
+	t.Run("rejects paths outside the root", func(t *testing.T) {
+		outside := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, path := range []string{
+			filepath.Join(root, "..", "etc"),
+			filepath.Join(root, "escape", "file.txt"),
+		} {
+			_, err := editor.Execute(ctx, anthropic.TextEditorToolInput{
+				Command:  anthropic.TextEditorCommandCreate,
+				Path:     path,
+				FileText: toPtr("x"),
+			})
+			if err == nil || !strings.Contains(err.Error(), "outside of the allowed directory") {
+				t.Fatalf("expected %s to be rejected, got %v", path, err)
+			}
+		}
+
+		runErr(t, anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandView,
+			Path:    "src/main.py",
+		}, "The path src/main.py is not an absolute path, it should start with `/`. "+
+			"Maybe you meant "+filepath.Join(root, "src/main.py")+"?")
+	})
+
+	t.Run("handles tool use blocks", func(t *testing.T) {
+		input, _ := json.Marshal(anthropic.TextEditorToolInput{
+			Command: anthropic.TextEditorCommandView,
+			Path:    filepath.Join(root, "nope.txt"),
+		})
+		result := editor.HandleToolUse(ctx, *anthropic.NewMessageContentToolUse(
+			"toolu_1",
+			anthropic.ToolNameTextEditor,
+			input,
+		))
+		if !*result.IsError {
+			t.Fatalf("expected an error result")
+		}
+		want := "The path " + filepath.Join(root, "nope.txt") +
+			" does not exist. Please provide a valid path."
+		if got := result.MessageContentToolResult.Content[0].GetText(); got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	})
+}
+
+func TestTextEditorClipsWholeCharacters(t *testing.T) {
+	root := t.TempDir()
+	editor, err := anthropic.NewTextEditor(root, anthropic.WithTextEditorMaxCharacters(4))
+	if err != nil {
+		t.Fatalf("NewTextEditor error: %v", err)
+	}
+	file := filepath.Join(editor.Root(), "a.txt")
+	if err := os.WriteFile(file, []byte("aéé"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := editor.Execute(context.Background(), anthropic.TextEditorToolInput{
+		Command: anthropic.TextEditorCommandView,
+		Path:    file,
+	})
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if !utf8.ValidString(out) || !strings.Contains(out, "\taé<response clipped>") {
+		t.Fatalf("unexpected output %q", out)
+	}
+}
+
+func TestTextEditorToolDefinitions(t *testing.T) {
+	def := anthropic.NewTextEditor20250728ToolDefinition(toPtr(10000))
+	b, err := json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"str_replace_based_edit_tool","type":"text_editor_20250728",` +
+		`"max_characters":10000}`
+	if string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+}
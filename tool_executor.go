@@ -0,0 +1,179 @@
+package anthropic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrToolNotFound = errors.New("tool not found")
+	ErrToolTimeout  = errors.New("tool execution timed out")
+)
+
+// ToolFunc executes a single tool_use block and returns the content of its
+// tool_result. A returned error is reported back to Claude as an is_error result.
+type ToolFunc func(ctx context.Context, toolUse MessageContentToolUse) ([]MessageContent, error)
+
+// ToolExecutor runs the tool_use blocks of a response concurrently and
+// assembles their tool_result blocks in the order the API requires.
+type ToolExecutor struct {
+	mu       sync.RWMutex
+	handlers map[string]ToolFunc
+
+	maxConcurrency int
+	timeout        time.Duration
+}
+
+type ToolExecutorOption func(e *ToolExecutor)
+
+// WithToolHandler registers the handler for the tool with the given name.
+func WithToolHandler(name string, fn ToolFunc) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.handlers[name] = fn
+	}
+}
+
+// WithToolConcurrency limits how many tools run at the same time.
+// A limit <= 0 runs every tool_use block at once.
+func WithToolConcurrency(limit int) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.maxConcurrency = limit
+	}
+}
+
+// WithToolTimeout bounds the execution time of every single tool call.
+// A timeout <= 0 disables it.
+func WithToolTimeout(timeout time.Duration) ToolExecutorOption {
+	return func(e *ToolExecutor) {
+		e.timeout = timeout
+	}
+}
+
+func NewToolExecutor(opts ...ToolExecutorOption) *ToolExecutor {
+	e := &ToolExecutor{
+		handlers: make(map[string]ToolFunc),
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Register adds or replaces the handler for the tool with the given name.
+func (e *ToolExecutor) Register(name string, fn ToolFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.handlers[name] = fn
+}
+
+func (e *ToolExecutor) handler(name string) (ToolFunc, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	fn, ok := e.handlers[name]
+	return fn, ok
+}
+
+// Execute runs the given tool_use blocks and returns one tool_result block per
+// tool_use, in the same order. Unknown tools, errors, timeouts and panics are
+// all turned into is_error results so a single failing tool never aborts the turn.
+func (e *ToolExecutor) Execute(
+	ctx context.Context,
+	toolUses []MessageContentToolUse,
+) []MessageContent {
+	results := make([]MessageContent, len(toolUses))
+
+	limit := e.maxConcurrency
+	if limit <= 0 || limit > len(toolUses) {
+		limit = len(toolUses)
+	}
+	sem := make(chan struct{}, limit)
+
+	var wg sync.WaitGroup
+	for i, toolUse := range toolUses {
+		wg.Add(1)
+		go func(i int, toolUse MessageContentToolUse) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = newToolErrorResult(toolUse.ID, ctx.Err())
+				return
+			}
+
+			content, err := e.call(ctx, toolUse)
+			if err != nil {
+				results[i] = newToolErrorResult(toolUse.ID, err)
+				return
+			}
+			results[i] = NewToolResultBlocksMessageContent(toolUse.ID, content, false)
+		}(i, toolUse)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// ExecuteResponse runs every tool_use block of the response and returns the
+// user message carrying the tool_result blocks, ready to be appended to the
+// conversation.
+func (e *ToolExecutor) ExecuteResponse(ctx context.Context, response MessagesResponse) Message {
+	return Message{
+		Role:    RoleUser,
+		Content: e.Execute(ctx, response.GetToolUses()),
+	}
+}
+
+type toolCallResult struct {
+	content []MessageContent
+	err     error
+}
+
+func (e *ToolExecutor) call(
+	ctx context.Context,
+	toolUse MessageContentToolUse,
+) ([]MessageContent, error) {
+	fn, ok := e.handler(toolUse.Name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, toolUse.Name)
+	}
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+
+	// The handler runs in its own goroutine so that a handler ignoring its
+	// context cannot hold up the whole turn past the timeout.
+	done := make(chan toolCallResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- toolCallResult{err: fmt.Errorf("tool %s panicked: %v", toolUse.Name, r)}
+			}
+		}()
+		content, err := fn(ctx, toolUse)
+		done <- toolCallResult{content: content, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.content, res.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: %s", ErrToolTimeout, toolUse.Name)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func newToolErrorResult(toolUseID string, err error) MessageContent {
+	return NewToolResultMessageContent(toolUseID, err.Error(), true)
+}
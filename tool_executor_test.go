@@ -0,0 +1,191 @@
+package anthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func textTool(text string, delay time.Duration) anthropic.ToolFunc {
+	return func(
+		ctx context.Context,
+		toolUse anthropic.MessageContentToolUse,
+	) ([]anthropic.MessageContent, error) {
+		time.Sleep(delay)
+		return []anthropic.MessageContent{anthropic.NewTextMessageContent(text)}, nil
+	}
+}
+
+func TestToolExecutorPreservesOrder(t *testing.T) {
+	executor := anthropic.NewToolExecutor(
+		anthropic.WithToolHandler("slow", textTool("slow", 50*time.Millisecond)),
+		anthropic.WithToolHandler("fast", textTool("fast", 0)),
+	)
+
+	resp := anthropic.MessagesResponse{
+		Content: []anthropic.MessageContent{
+			anthropic.NewTextMessageContent("let me check"),
+			anthropic.NewToolUseMessageContent("toolu_1", "slow", nil),
+			anthropic.NewToolUseMessageContent("toolu_2", "fast", nil),
+			anthropic.NewToolUseMessageContent("toolu_3", "slow", nil),
+		},
+	}
+
+	msg := executor.ExecuteResponse(context.Background(), resp)
+	if msg.Role != anthropic.RoleUser {
+		t.Fatalf("Role mismatch. got %s, want %s", msg.Role, anthropic.RoleUser)
+	}
+	if len(msg.Content) != 3 {
+		t.Fatalf("expected 3 tool results, got %d", len(msg.Content))
+	}
+
+	wantIDs := []string{"toolu_1", "toolu_2", "toolu_3"}
+	wantTexts := []string{"slow", "fast", "slow"}
+	for i, c := range msg.Content {
+		if c.Type != anthropic.MessagesContentTypeToolResult {
+			t.Fatalf("expected tool_result, got %s", c.Type)
+		}
+		if got := *c.MessageContentToolResult.ToolUseID; got != wantIDs[i] {
+			t.Errorf("result %d: got tool_use_id %s, want %s", i, got, wantIDs[i])
+		}
+		if *c.MessageContentToolResult.IsError {
+			t.Errorf("result %d: unexpected error result", i)
+		}
+		if got := c.MessageContentToolResult.Content[0].GetText(); got != wantTexts[i] {
+			t.Errorf("result %d: got text %s, want %s", i, got, wantTexts[i])
+		}
+	}
+}
+
+func TestToolExecutorConcurrencyLimit(t *testing.T) {
+	var active, maxActive int32
+	tool := func(
+		ctx context.Context,
+		toolUse anthropic.MessageContentToolUse,
+	) ([]anthropic.MessageContent, error) {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			m := atomic.LoadInt32(&maxActive)
+			if n <= m || atomic.CompareAndSwapInt32(&maxActive, m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		return nil, nil
+	}
+
+	executor := anthropic.NewToolExecutor(
+		anthropic.WithToolHandler("work", tool),
+		anthropic.WithToolConcurrency(2),
+	)
+
+	var toolUses []anthropic.MessageContentToolUse
+	for i := 0; i < 6; i++ {
+		toolUses = append(toolUses, *anthropic.NewMessageContentToolUse("toolu", "work", nil))
+	}
+
+	results := executor.Execute(context.Background(), toolUses)
+	if len(results) != 6 {
+		t.Fatalf("expected 6 results, got %d", len(results))
+	}
+	if maxActive > 2 {
+		t.Fatalf("expected at most 2 concurrent tools, got %d", maxActive)
+	}
+}
+
+func TestToolExecutorErrors(t *testing.T) {
+	executor := anthropic.NewToolExecutor(
+		anthropic.WithToolTimeout(20 * time.Millisecond),
+	)
+	executor.Register("boom", func(
+		ctx context.Context,
+		toolUse anthropic.MessageContentToolUse,
+	) ([]anthropic.MessageContent, error) {
+		panic("kaboom")
+	})
+	executor.Register("fail", func(
+		ctx context.Context,
+		toolUse anthropic.MessageContentToolUse,
+	) ([]anthropic.MessageContent, error) {
+		return nil, errors.New("invalid location")
+	})
+	executor.Register("hang", textTool("too late", time.Second))
+
+	cases := []struct {
+		name string
+		tool string
+		want string
+	}{
+		{name: "unknown tool", tool: "missing", want: "tool not found"},
+		{name: "handler error", tool: "fail", want: "invalid location"},
+		{name: "panic", tool: "boom", want: "kaboom"},
+		{name: "timeout", tool: "hang", want: "timed out"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			results := executor.Execute(
+				context.Background(),
+				[]anthropic.MessageContentToolUse{
+					*anthropic.NewMessageContentToolUse("toolu_1", c.tool, json.RawMessage(`{}`)),
+				},
+			)
+			if len(results) != 1 {
+				t.Fatalf("expected 1 result, got %d", len(results))
+			}
+			if !*results[0].IsError {
+				t.Fatalf("expected an error result")
+			}
+			text := results[0].MessageContentToolResult.Content[0].GetText()
+			if !strings.Contains(text, c.want) {
+				t.Fatalf("expected error text to contain %q, got %q", c.want, text)
+			}
+		})
+	}
+}
+
+func TestToolChoiceMarshal(t *testing.T) {
+	cases := []struct {
+		name   string
+		choice anthropic.ToolChoice
+		want   string
+	}{
+		{
+			name:   "auto",
+			choice: anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeAuto},
+			want:   `{"type":"auto"}`,
+		},
+		{
+			name:   "none",
+			choice: anthropic.ToolChoice{Type: anthropic.ToolChoiceTypeNone},
+			want:   `{"type":"none"}`,
+		},
+		{
+			name: "disable parallel tool use",
+			choice: anthropic.ToolChoice{
+				Type:                   anthropic.ToolChoiceTypeAny,
+				DisableParallelToolUse: toPtr(true),
+			},
+			want: `{"type":"any","disable_parallel_tool_use":true}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := json.Marshal(c.choice)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != c.want {
+				t.Fatalf("got %s, want %s", b, c.want)
+			}
+		})
+	}
+}
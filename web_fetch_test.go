@@ -0,0 +1,116 @@
+package anthropic_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/liushuangls/go-anthropic/v2"
+)
+
+func TestWebFetchToolDefinition(t *testing.T) {
+	def := anthropic.NewWebFetchToolDefinition()
+	def.MaxUses = toPtr(3)
+	def.MaxContentTokens = toPtr(50000)
+	def.AllowedDomains = []string{"go.dev"}
+	def.Citations = &anthropic.DocumentCitations{Enabled: true}
+
+	b, err := json.Marshal(def)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"web_fetch","type":"web_fetch_20250910","max_uses":3,` +
+		`"allowed_domains":["go.dev"],"max_content_tokens":50000,"citations":{"enabled":true}}`
+	if string(b) != want {
+		t.Fatalf("got %s, want %s", b, want)
+	}
+}
+
+func TestWebFetchToolResult(t *testing.T) {
+	data := `[
+		{
+			"type": "web_fetch_tool_result",
+			"tool_use_id": "srvtoolu_1",
+			"content": {
+				"type": "web_fetch_result",
+				"url": "https://go.dev/doc/",
+				"content": {
+					"type": "document",
+					"source": {"type": "text", "media_type": "text/plain", "data": "Go is fun."},
+					"title": "Documentation",
+					"citations": {"enabled": true}
+				},
+				"retrieved_at": "2025-09-10T10:30:00Z"
+			}
+		},
+		{
+			"type": "text",
+			"text": "Go is fun.",
+			"citations": [{
+				"type": "char_location",
+				"cited_text": "Go is fun.",
+				"document_index": 0,
+				"document_title": "Documentation",
+				"start_char_index": 0,
+				"end_char_index": 10
+			}]
+		},
+		{
+			"type": "web_fetch_tool_result",
+			"tool_use_id": "srvtoolu_2",
+			"content": {"type": "web_fetch_tool_result_error", "error_code": "url_not_accessible"}
+		}
+	]`
+
+	var content []anthropic.MessageContent
+	if err := json.Unmarshal([]byte(data), &content); err != nil {
+		t.Fatal(err)
+	}
+
+	fetched := content[0].MessageContentWebFetchToolResult
+	if fetched == nil || fetched.Content == nil || fetched.Content.IsError() {
+		t.Fatalf("web_fetch_tool_result was not decoded: %+v", content[0])
+	}
+	doc := fetched.Content.Content
+	if doc.Type != anthropic.MessagesContentTypeDocument || doc.Title != "Documentation" ||
+		doc.Source.Data != "Go is fun." || doc.DocumentCitations == nil ||
+		!doc.DocumentCitations.Enabled {
+		t.Fatalf("unexpected document %+v", doc)
+	}
+	if fetched.Content.RetrievedAt == nil || fetched.Content.RetrievedAt.Year() != 2025 {
+		t.Fatalf("unexpected retrieved_at %v", fetched.Content.RetrievedAt)
+	}
+
+	if len(content[1].Citations) != 1 || content[1].Citations[0].DocumentTitle != "Documentation" {
+		t.Fatalf("citations on the fetched document were not decoded: %+v", content[1].Citations)
+	}
+
+	failed := content[2].MessageContentWebFetchToolResult.Content
+	if !failed.IsError() || failed.ErrorCode != anthropic.WebFetchErrorCodeURLNotAccessible {
+		t.Fatalf("unexpected error result %+v", failed)
+	}
+
+	for _, c := range []anthropic.MessageContent{content[0], content[2]} {
+		b, err := json.Marshal(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), `"tool_use_id"`) {
+			t.Fatalf("tool_use_id was dropped: %s", b)
+		}
+		var roundTrip anthropic.MessageContent
+		if err := json.Unmarshal(b, &roundTrip); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(roundTrip, c) {
+			t.Fatalf("round trip mismatch:\n got %+v\nwant %+v\njson %s", roundTrip, c, b)
+		}
+	}
+
+	var merged anthropic.MessageContent
+	merged.MergeContentDelta(content[0])
+	if merged.MessageContentWebFetchToolResult != content[0].MessageContentWebFetchToolResult {
+		t.Fatalf("MergeContentDelta did not keep the web fetch result")
+	}
+}